@@ -11,10 +11,21 @@ type Account struct {
 	Salt     string `db:"SALT"`
 	Role     string `db:"ROLE"`
 	LastAuth int    `db:"LAST_AUTH"`
+	Deleted  int    `db:"DELETED"`
+	Version  int    `db:"VERSION"`
 }
 
 type Accounts []Account
 
+// AccountTaskCount is the number of tasks an account owns.
+type AccountTaskCount struct {
+	AccountId int
+	Name      string
+	Count     int
+}
+
+type AccountTaskCounts []AccountTaskCount
+
 type accountSort struct {
 	accounts Accounts
 	by       func(a1, a2 *Account) bool
@@ -37,7 +48,7 @@ func (a Accounts) sortBy(by func(a1, a2 *Account) bool) *Accounts {
 		accounts: a,
 		by:       by,
 	}
-	sort.Sort(ts)
+	sort.Stable(ts)
 	return &a
 }
 
@@ -60,3 +71,74 @@ func (a *Accounts) SortByEmail(order string) *Accounts {
 	})
 	return a
 }
+
+func (a *Accounts) SortById(order string) *Accounts {
+	a.sortBy(func(a1, a2 *Account) bool {
+		if order == "DESC" {
+			return a1.Id > a2.Id
+		}
+		return a1.Id < a2.Id
+	})
+	return a
+}
+
+var accountSorters = map[string]func(a *Accounts, order string) *Accounts{
+	"Email": (*Accounts).SortByEmail,
+	"Id":    (*Accounts).SortById,
+	"Name":  (*Accounts).SortByName,
+}
+
+// accountSortFields are the fields of the sort parameter, names and emails are sorted in Go as they are not case sensitive.
+var accountSortFields = map[string]sortField{
+	"id":    {"Id", "ID", false, false},
+	"name":  {"Name", "", true, false},
+	"email": {"Email", "", true, false},
+}
+
+// sortValues returns the values of the sort keys of the account, as ints or strings.
+func (a *Account) sortValues(keys SortKeys) []interface{} {
+	values := []interface{}{}
+	for _, key := range keys {
+		switch key.Field {
+		case "id":
+			values = append(values, a.Id)
+		case "name":
+			values = append(values, a.Name)
+		case "email":
+			values = append(values, a.Email)
+		}
+	}
+	return values
+}
+
+// Page returns the accounts of the page in order, see pageRows.
+func (a Accounts) Page(keys SortKeys, page Page, inSQL bool) (*Accounts, PageResult) {
+	indexes, result := pageRows(len(a), func(i int) ([]interface{}, int) {
+		return a[i].sortValues(keys), a[i].Id
+	}, "accounts", keys, accountSortFields, page, inSQL)
+
+	as := Accounts{}
+	for _, i := range indexes {
+		as = append(as, a[i])
+	}
+	return &as, result
+}
+
+func (a *Accounts) SortByField(field string, order string) *Accounts {
+	if sorter, ok := accountSorters[field]; ok {
+		sorter(a, order)
+	}
+	return a
+}
+
+// SortByKeys sorts by all keys, the first key being the most significant one.
+func (a *Accounts) SortByKeys(keys SortKeys) *Accounts {
+	for i := len(keys) - 1; i >= 0; i-- {
+		a.SortByField(accountSortFields[keys[i].Field].Sorter, keys[i].Order())
+	}
+	return a
+}
+
+func (a *Account) IsDeleted() bool {
+	return a.Deleted > 0
+}
@@ -5,15 +5,15 @@ import "strings"
 
 func Test_account_SortBy(t *testing.T) {
 	var as1 = Accounts{
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
 	}
 
 	var as2 = Accounts{
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
 	}
 
 	// sort by domain name in lowercase
@@ -33,21 +33,21 @@ func Test_account_SortBy(t *testing.T) {
 
 func Test_account_SortByName(t *testing.T) {
 	var as1 = Accounts{
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
 	}
 
 	var as2 = Accounts{
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
 	}
 
 	var as3 = Accounts{
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
 	}
 
 	as1.SortByName("ASC")
@@ -69,21 +69,21 @@ func Test_account_SortByName(t *testing.T) {
 
 func Test_account_SortByEmail(t *testing.T) {
 	var as1 = Accounts{
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
 	}
 
 	var as2 = Accounts{
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
 	}
 
 	var as3 = Accounts{
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
 	}
 
 	as1.SortByEmail("ASC")
@@ -102,3 +102,25 @@ func Test_account_SortByEmail(t *testing.T) {
 		}
 	}
 }
+
+func Test_account_SortByKeys(t *testing.T) {
+	var as1 = Accounts{
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 2, Name: "clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 3, Name: "Clude", Email: "another@clude", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
+	}
+
+	var as2 = Accounts{
+		Account{Id: 3, Name: "Clude", Email: "another@clude", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
+		Account{Id: 2, Name: "clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+	}
+
+	as1.SortByKeys(SortKeys{{"name", false}, {"id", true}})
+	for i, a1 := range as1 {
+		if a1 != as2[i] {
+			t.Errorf("SortByKeys name,-id is not as expected: [%v], instead of [%v]", as1, as2)
+			return
+		}
+	}
+}
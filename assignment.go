@@ -0,0 +1,34 @@
+package main
+
+import "log"
+
+// Assignment records who assigned a task to whom, and when.
+// An AssigneeId of 0 means the task got unassigned.
+type Assignment struct {
+	Id         int `db:"ID"`
+	TaskId     int `db:"TASK_ID"`
+	AssigneeId int `db:"ASSIGNEE_ID"`
+	AssignedBy int `db:"ASSIGNED_BY"`
+	Assigned   int `db:"ASSIGNED"`
+}
+
+type Assignments []Assignment
+
+// Notifier informs accounts about changes concerning them.
+type Notifier interface {
+	TaskAssigned(task *Task, assignment *Assignment) error
+}
+
+// logNotifier is the default notifier, it only writes notifications to the log.
+type logNotifier struct{}
+
+func (n *logNotifier) TaskAssigned(task *Task, assignment *Assignment) error {
+	log.Printf("Task[%v] assigned to Account[%v] by Account[%v]", task.Id, assignment.AssigneeId, assignment.AssignedBy)
+	return nil
+}
+
+var notifier Notifier = &logNotifier{}
+
+func SetNotifier(n Notifier) {
+	notifier = n
+}
@@ -0,0 +1,23 @@
+package main
+
+// attachments larger than this are rejected on upload
+var maxAttachmentSize int64 = 10 << 20
+
+// Attachment holds the metadata of a file attached to a task,
+// the file itself is kept within the BlobStore under BlobKey.
+type Attachment struct {
+	Id          int    `db:"ID"`
+	TaskId      int    `db:"TASK_ID"`
+	AccountId   int    `db:"ACCOUNT_ID"`
+	Name        string `db:"NAME"`
+	ContentType string `db:"CONTENT_TYPE"`
+	Size        int64  `db:"SIZE"`
+	Created     int    `db:"CREATED"`
+	BlobKey     string `db:"BLOB_KEY" json:"-"`
+}
+
+type Attachments []Attachment
+
+func SetMaxAttachmentSize(size int64) {
+	maxAttachmentSize = size
+}
@@ -0,0 +1,117 @@
+package main
+
+import "os"
+import "io"
+import "sync"
+import "bytes"
+import "errors"
+import "io/ioutil"
+import "path/filepath"
+
+var ErrBlobNotFound = errors.New("Blob not found")
+
+// BlobStore keeps the contents of attachments, while their metadata is kept in the db.
+type BlobStore interface {
+	Put(key string, r io.Reader) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// LocalBlobStore keeps each blob as a file within a local directory.
+type LocalBlobStore struct {
+	dir string
+}
+
+func NewLocalBlobStore(dir string) *LocalBlobStore {
+	return &LocalBlobStore{dir}
+}
+
+func (s *LocalBlobStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.Base(key))
+}
+
+func (s *LocalBlobStore) Put(key string, r io.Reader) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+
+	file, err := os.Create(s.path(key))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		os.Remove(s.path(key))
+		return err
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) Get(key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	return file, err
+}
+
+func (s *LocalBlobStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MemoryBlobStore keeps all blobs in memory, it is meant to be used for testing.
+type MemoryBlobStore struct {
+	mutex sync.Mutex
+	blobs map[string][]byte
+}
+
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: map[string][]byte{}}
+}
+
+func (s *MemoryBlobStore) Put(key string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.blobs[key] = data
+	return nil
+}
+
+func (s *MemoryBlobStore) Get(key string) (io.ReadCloser, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	data, ok := s.blobs[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryBlobStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.blobs, key)
+	return nil
+}
+
+// Len returns the number of blobs currently stored.
+func (s *MemoryBlobStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.blobs)
+}
+
+var blobStore BlobStore = NewLocalBlobStore("./data/attachments")
+
+func SetBlobStore(s BlobStore) {
+	blobStore = s
+}
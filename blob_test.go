@@ -0,0 +1,51 @@
+package main
+
+import "os"
+import "strings"
+import "testing"
+import "io/ioutil"
+
+func _blob_testStore(t *testing.T, store BlobStore) {
+	if err := store.Put("screenshot", strings.NewReader("PNG data")); err != nil {
+		t.Fatal(err)
+	}
+
+	blob, err := store.Get("screenshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(blob)
+	blob.Close()
+	if err != nil {
+		t.Error(err)
+	}
+	if string(data) != "PNG data" {
+		t.Errorf("Blob is not as expected: [%v], instead of [%v]", string(data), "PNG data")
+	}
+
+	if err := store.Delete("screenshot"); err != nil {
+		t.Error(err)
+	}
+	if _, err := store.Get("screenshot"); err != ErrBlobNotFound {
+		t.Errorf("Get() after Delete() returned [%v], instead of [%v]", err, ErrBlobNotFound)
+	}
+	if err := store.Delete("screenshot"); err != nil {
+		t.Errorf("Deleting a missing blob should not fail: [%v]", err)
+	}
+}
+
+func Test_blob_LocalBlobStore(t *testing.T) {
+	dir := "./data/attachments_test"
+	defer os.RemoveAll(dir)
+
+	_blob_testStore(t, NewLocalBlobStore(dir))
+}
+
+func Test_blob_MemoryBlobStore(t *testing.T) {
+	store := NewMemoryBlobStore()
+	_blob_testStore(t, store)
+
+	if store.Len() != 0 {
+		t.Errorf("MemoryBlobStore should be empty, instead of [%v] blobs", store.Len())
+	}
+}
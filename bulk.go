@@ -0,0 +1,77 @@
+package main
+
+import "strings"
+import "encoding/json"
+
+// most operations a single bulk request may contain
+const maxBulkOperations = 1000
+
+// BulkRequest contains a list of create, update and delete operations on tasks.
+// By default all operations succeed or none of them is applied, with ContinueOnError
+// all valid operations are applied and only the failing ones are skipped.
+type BulkRequest struct {
+	ContinueOnError bool
+	Operations      []BulkOperation
+}
+
+// BulkOperation is a single operation of a bulk request, Op being one of ActionCreate, ActionUpdate or ActionDelete.
+// Deleting only needs the Id of the task, updating keeps the current values of all fields left out of the task.
+type BulkOperation struct {
+	Op     string
+	Task   Task
+	fields map[string]bool
+}
+
+// UnmarshalJSON remembers which fields the task of the operation contains, see Has.
+func (op *BulkOperation) UnmarshalJSON(data []byte) error {
+	var o struct {
+		Op   string
+		Task json.RawMessage
+	}
+	if err := json.Unmarshal(data, &o); err != nil {
+		return err
+	}
+
+	var task Task
+	var fields map[string]json.RawMessage
+	if len(o.Task) > 0 {
+		if err := json.Unmarshal(o.Task, &task); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(o.Task, &fields); err != nil {
+			return err
+		}
+	}
+
+	op.Op, op.Task, op.fields = o.Op, task, map[string]bool{}
+	for name := range fields {
+		op.fields[strings.ToLower(name)] = true
+	}
+	return nil
+}
+
+// Has returns true if the task of the operation contains the field, names match case-insensitively like they do in encoding/json.
+func (op *BulkOperation) Has(field string) bool {
+	return op.fields[strings.ToLower(field)]
+}
+
+// BulkResult reports the outcome of a single operation, Status being the HTTP status code
+// the operation would have gotten on its own.
+type BulkResult struct {
+	Op     string
+	Id     int
+	Status int
+	Error  string
+}
+
+type BulkResults []BulkResult
+
+// Failed returns true if any of the operations failed.
+func (rs BulkResults) Failed() bool {
+	for _, r := range rs {
+		if r.Status != 200 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+import "encoding/json"
+
+func Test_bulk_Failed(t *testing.T) {
+	results := BulkResults{
+		{ActionCreate, 1, 200, ""},
+		{ActionDelete, 2, 200, ""},
+	}
+	if results.Failed() {
+		t.Errorf("BulkResults [%v] should not have failed", results)
+	}
+
+	results = append(results, BulkResult{ActionUpdate, 3, 404, "404 page not found"})
+	if !results.Failed() {
+		t.Errorf("BulkResults [%v] should have failed", results)
+	}
+}
+
+func Test_bulk_BulkOperation(t *testing.T) {
+	var op BulkOperation
+	if err := json.Unmarshal([]byte(`{"Op": "Update", "Task": {"Id": 3, "status": "Done", "ProjectId": 0}}`), &op); err != nil {
+		t.Fatal(err)
+	}
+	if op.Op != ActionUpdate || op.Task.Id != 3 || op.Task.Status != "Done" {
+		t.Errorf("BulkOperation is not as expected: [%v]", op)
+	}
+	for field, expected := range map[string]bool{"Id": true, "Status": true, "ProjectId": true, "Priority": false, "Task": false} {
+		if op.Has(field) != expected {
+			t.Errorf("BulkOperation.Has(%v) returned [%v], instead of [%v]", field, op.Has(field), expected)
+		}
+	}
+
+	if err := json.Unmarshal([]byte(`{"Op": "Delete"}`), &op); err != nil || op.Op != ActionDelete || op.Task.Id != 0 || op.Has("Id") {
+		t.Errorf("BulkOperation is not as expected: [%v], [%v]", op, err)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import "regexp"
+import "strings"
+
+// mentions start with an @ that is not part of a word or an email address
+var validMention = regexp.MustCompile(`(?:^|[^\w@])@(\w+(?:[.-]\w+)*)`)
+
+// Comment on a task. The body is markdown and stored as raw text,
+// Mentions holds the ids of all accounts mentioned within the body.
+type Comment struct {
+	Id          int    `db:"ID"`
+	TaskId      int    `db:"TASK_ID"`
+	AccountId   int    `db:"ACCOUNT_ID"`
+	Created     int    `db:"CREATED"`
+	LastUpdated int    `db:"LAST_UPDATED"`
+	Body        string `db:"BODY"`
+	Mentions    []int  `db:"-"`
+}
+
+type Comments []Comment
+
+// ParseMentions returns the account names mentioned by @name within the body, without duplicates.
+func ParseMentions(body string) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, match := range validMention.FindAllStringSubmatch(body, -1) {
+		name := match[1]
+		if !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *Comment) IsMentioned(accountId int) bool {
+	for _, id := range c.Mentions {
+		if id == accountId {
+			return true
+		}
+	}
+	return false
+}
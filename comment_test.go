@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func Test_comment_ParseMentions(t *testing.T) {
+	var tests = []struct {
+		body     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"no mentions here", []string{}},
+		{"@ozzie please have a look", []string{"ozzie"}},
+		{"thanks @Clude, and @ozzie.", []string{"Clude", "ozzie"}},
+		{"ping @ozzie @OZZIE (@james.clonk)", []string{"ozzie", "james.clonk"}},
+		{"mail me at ozzie@abrakadabra", []string{}},
+	}
+
+	for _, test := range tests {
+		names := ParseMentions(test.body)
+		if len(names) != len(test.expected) {
+			t.Errorf("ParseMentions of [%v] returned [%v], instead of [%v]", test.body, names, test.expected)
+			continue
+		}
+		for i, name := range names {
+			if name != test.expected[i] {
+				t.Errorf("ParseMentions of [%v] returned [%v], instead of [%v]", test.body, names, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func Test_comment_IsMentioned(t *testing.T) {
+	comment := Comment{1, 1, 1, 1234567890, 1234567890, "@Clude @ozzie", []int{2, 3}}
+
+	if !comment.IsMentioned(3) {
+		t.Errorf("Account [%v] should be mentioned in [%v]", 3, comment)
+	}
+	if comment.IsMentioned(1) {
+		t.Errorf("Account [%v] should not be mentioned in [%v]", 1, comment)
+	}
+}
@@ -7,6 +7,10 @@ type Config struct {
 	Logging      bool
 	Port         int
 	DatabaseFile string
+	TrashDays    int           // days until deleted items are purged from the trash, 0 keeps them forever
+	UndoDepth    int           // number of mutations per account that can be undone
+	Priorities   PriorityScale // priority levels of tasks, the default is 1 (low) to 5 (urgent)
+	CursorSecret string        // key to sign page cursors with, a random one is used if empty
 }
 
 func parseConfig(filename string) (*Config, error) {
@@ -24,3 +28,21 @@ func parseConfig(filename string) (*Config, error) {
 
 	return cfg, nil
 }
+
+// applyConfig sets everything the tasks are handled with, before the commandline options export, import or sync any of them.
+func applyConfig(cfg *Config) error {
+	if cfg.UndoDepth > 0 {
+		SetUndoDepth(cfg.UndoDepth)
+	}
+
+	if cfg.CursorSecret != "" {
+		SetCursorSecret(cfg.CursorSecret)
+	}
+
+	if cfg.Priorities.Max > 0 {
+		if err := SetPriorityScale(cfg.Priorities); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -9,7 +9,29 @@ func Test_config_parseConfig(t *testing.T) {
 		return
 	}
 
-	if cfg.Port != 8008 || cfg.Logging != true || cfg.DatabaseFile != "data/tasks.db" {
+	if cfg.Port != 8008 || cfg.Logging != true || cfg.DatabaseFile != "data/tasks.db" || cfg.TrashDays != 30 || cfg.UndoDepth != 20 {
 		t.Errorf("Configfile was not as expected: [%v]", cfg)
 	}
+	if cfg.Priorities.Min != 1 || cfg.Priorities.Max != 5 || len(cfg.Priorities.Levels) != 5 || cfg.Priorities.Levels[4].Name != "urgent" {
+		t.Errorf("Priorities in configfile were not as expected: [%v]", cfg.Priorities)
+	}
+}
+
+func Test_config_applyConfig(t *testing.T) {
+	defaultScale := GetPriorityScale()
+	defaultDepth := undoDepth
+	defer SetPriorityScale(defaultScale)
+	defer SetUndoDepth(defaultDepth)
+
+	cfg := &Config{UndoDepth: 3, Priorities: PriorityScale{0, 2, []PriorityLevel{{0, "later", ""}, {2, "now", ""}}}}
+	if err := applyConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if scale := GetPriorityScale(); scale.Min != 0 || scale.Max != 2 || undoDepth != 3 {
+		t.Errorf("Config was not applied: [%v], [%v]", scale, undoDepth)
+	}
+
+	if err := applyConfig(&Config{Priorities: PriorityScale{3, 1, nil}}); err == nil {
+		t.Error("applyConfig should refuse an invalid priority scale")
+	}
 }
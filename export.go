@@ -0,0 +1,181 @@
+package main
+
+import "io"
+import "errors"
+import "strconv"
+import "strings"
+import "encoding/csv"
+import "encoding/json"
+
+var ErrInvalidFormat = errors.New("Invalid format")
+var ErrNotAcceptable = errors.New("Not Acceptable")
+
+// export formats with their content types
+var exportFormats = map[string]string{
+	"json":     "application/json",
+	"csv":      "text/csv; charset=utf-8",
+	"markdown": "text/markdown; charset=utf-8",
+	"ical":     "text/calendar; charset=utf-8",
+}
+
+var exportFileExtensions = map[string]string{
+	"json":     "json",
+	"csv":      "csv",
+	"markdown": "md",
+	"ical":     "ics",
+}
+
+// the columns of CSV exports, in the order of the Task fields
+var csvColumns = []string{"Id", "AccountId", "Created", "LastUpdated", "Priority", "Task", "ProjectId", "AssigneeId", "Status", "Rank"}
+
+// TaskExporter writes tasks one by one in an export format. Close has to be called after the last task.
+type TaskExporter interface {
+	Export(t *Task) error
+	Close() error
+}
+
+// NegotiateExportFormat picks the export format from the format parameter, or otherwise from the Accept header.
+// Without either of them tasks are exported as JSON.
+func NegotiateExportFormat(format string, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		if format == "md" {
+			format = "markdown"
+		} else if format == "ics" {
+			format = "ical"
+		}
+		if _, ok := exportFormats[format]; !ok {
+			return "", ErrInvalidFormat
+		}
+		return format, nil
+	}
+	if strings.TrimSpace(accept) == "" {
+		return "json", nil
+	}
+
+	// the media types are tried in the order given, quality values are not taken into account
+	for _, mediaType := range strings.Split(accept, ",") {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(mediaType, ";")[0]))
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			return "json", nil
+		case "text/csv":
+			return "csv", nil
+		case "text/markdown", "text/*":
+			return "markdown", nil
+		case "text/calendar":
+			return "ical", nil
+		}
+	}
+	return "", ErrNotAcceptable
+}
+
+// NewTaskExporter returns an exporter writing to w in one of the formats "json", "csv", "markdown" or "ical".
+func NewTaskExporter(format string, w io.Writer) (TaskExporter, error) {
+	switch format {
+	case "json":
+		return &jsonExporter{w: w}, nil
+	case "csv":
+		return &csvExporter{w: csv.NewWriter(w)}, nil
+	case "markdown":
+		return &markdownExporter{w: w}, nil
+	case "ical":
+		return &icalExporter{w: w}, nil
+	}
+	return nil, ErrInvalidFormat
+}
+
+// jsonExporter writes a JSON array of tasks, just like GET on /tasks/ returns them, so that it can be imported again.
+type jsonExporter struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonExporter) Export(t *Task) error {
+	js, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	separator := ",\n"
+	if e.count == 0 {
+		separator = "[\n"
+	}
+	e.count++
+	_, err = io.WriteString(e.w, separator+string(js))
+	return err
+}
+
+func (e *jsonExporter) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
+
+// csvExporter writes a header row with the column names, followed by a row for each task.
+type csvExporter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (e *csvExporter) Export(t *Task) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	row := []string{
+		strconv.Itoa(t.Id),
+		strconv.Itoa(t.AccountId),
+		strconv.Itoa(t.Created),
+		strconv.Itoa(t.LastUpdated),
+		strconv.Itoa(int(t.Priority)),
+		t.Task,
+		strconv.Itoa(t.ProjectId),
+		strconv.Itoa(t.AssigneeId),
+		t.Status,
+		t.Rank,
+	}
+	if err := e.w.Write(row); err != nil {
+		return err
+	}
+	// flushing every row keeps the export streaming instead of piling up in the buffer
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExporter) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.w.Write(csvColumns)
+}
+
+// markdownExporter writes a checklist, with tasks that are done checked.
+type markdownExporter struct {
+	w io.Writer
+}
+
+func (e *markdownExporter) Export(t *Task) error {
+	check := " "
+	if t.Status == "Done" {
+		check = "x"
+	}
+	// every task has to stay on its own line
+	text := strings.Join(strings.Fields(t.Task), " ")
+	_, err := io.WriteString(e.w, "- ["+check+"] "+text+"\n")
+	return err
+}
+
+func (e *markdownExporter) Close() error {
+	return nil
+}
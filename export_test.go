@@ -0,0 +1,87 @@
+package main
+
+import "bytes"
+import "testing"
+import "encoding/json"
+
+func Test_export_NegotiateExportFormat(t *testing.T) {
+	var tests = []struct {
+		format   string
+		accept   string
+		expected string
+		err      error
+	}{
+		{"", "", "json", nil},
+		{"CSV", "application/json", "csv", nil},
+		{"md", "", "markdown", nil},
+		{"xml", "", "", ErrInvalidFormat},
+		{"", "text/csv", "csv", nil},
+		{"", "text/markdown;q=0.9, text/csv", "markdown", nil},
+		{"", "application/xml, */*;q=0.1", "json", nil},
+		{"", "image/png", "", ErrNotAcceptable},
+		{"ics", "", "ical", nil},
+		{"", "text/calendar", "ical", nil},
+	}
+	for _, test := range tests {
+		format, err := NegotiateExportFormat(test.format, test.accept)
+		if format != test.expected || err != test.err {
+			t.Errorf("NegotiateExportFormat of [%v], [%v] returned [%v], [%v] instead of [%v], [%v]", test.format, test.accept, format, err, test.expected, test.err)
+		}
+	}
+}
+
+func Test_export_TaskExporter(t *testing.T) {
+	tasks := Tasks{
+		{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567896, Priority: 5, Task: "Say \"hello\",\nthen leave", ProjectId: 4, AssigneeId: 3, Status: "Done", Version: 2},
+	}
+	export := func(format string, tasks Tasks) string {
+		var buf bytes.Buffer
+		exporter, err := NewTaskExporter(format, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range tasks {
+			if err := exporter.Export(&tasks[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := exporter.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	// ============================================ JSON ============================================
+	var imported Tasks
+	if err := json.Unmarshal([]byte(export("json", tasks)), &imported); err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 || imported[0] != tasks[0] || imported[1] != tasks[1] {
+		t.Errorf("JSON export did not round-trip: [%v]", imported)
+	}
+	if js := export("json", Tasks{}); js != "[]\n" {
+		t.Errorf("JSON export without tasks is [%v]", js)
+	}
+
+	// ============================================ CSV ============================================
+	expected := "Id,AccountId,Created,LastUpdated,Priority,Task,ProjectId,AssigneeId,Status,Rank\n" +
+		"1,1,1234567890,1234567895,3,Buy food!,0,0,Open,V\n" +
+		"2,2,1234567891,1234567896,5,\"Say \"\"hello\"\",\nthen leave\",4,3,Done,\n"
+	if csv := export("csv", tasks); csv != expected {
+		t.Errorf("CSV export is [%v], instead of [%v]", csv, expected)
+	}
+	if csv := export("csv", Tasks{}); csv != "Id,AccountId,Created,LastUpdated,Priority,Task,ProjectId,AssigneeId,Status,Rank\n" {
+		t.Errorf("CSV export without tasks is [%v]", csv)
+	}
+
+	// ============================================ Markdown ============================================
+	expected = "- [ ] Buy food!\n- [x] Say \"hello\", then leave\n"
+	if md := export("markdown", tasks); md != expected {
+		t.Errorf("Markdown export is [%v], instead of [%v]", md, expected)
+	}
+
+	if _, err := NewTaskExporter("xml", &bytes.Buffer{}); err != ErrInvalidFormat {
+		t.Errorf("NewTaskExporter of an unknown format returned [%v]", err)
+	}
+}
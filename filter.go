@@ -0,0 +1,327 @@
+package main
+
+import "fmt"
+import "errors"
+import "time"
+import "strconv"
+import "strings"
+
+// kinds of values a filter field can be compared with
+const (
+	filterNumber = iota
+	filterDate
+	filterText
+	filterPriority
+	filterStatus
+)
+
+type filterField struct {
+	Column string
+	Kind   int
+}
+
+// filterFields is the whitelist of task fields that can be used in filter expressions.
+var filterFields = map[string]filterField{
+	"id":       {"ID", filterNumber},
+	"account":  {"ACCOUNT_ID", filterNumber},
+	"created":  {"CREATED", filterDate},
+	"updated":  {"LAST_UPDATED", filterDate},
+	"priority": {"PRIORITY", filterPriority},
+	"text":     {"TASK", filterText},
+	"project":  {"PROJECT_ID", filterNumber},
+	"assignee": {"ASSIGNEE_ID", filterNumber},
+	"status":   {"STATUS", filterStatus},
+}
+
+// "~" is "contains" and only works on text
+var filterOperators = []string{"<=", ">=", "!=", "=", "<", ">", "~"}
+
+// FilterError tells where a filter expression is invalid, positions start at 1.
+type FilterError struct {
+	Pos int
+	Msg string
+}
+
+func (e *FilterError) Error() string {
+	return fmt.Sprintf("Invalid filter at position %v: %v", e.Pos, e.Msg)
+}
+
+// FilterNode is a node of the syntax tree of a filter expression:
+// either a *FilterLogical, a *FilterNot or a *FilterComparison.
+type FilterNode interface {
+	Position() int
+}
+
+// FilterLogical combines two expressions with "AND" or "OR".
+type FilterLogical struct {
+	Op    string
+	Left  FilterNode
+	Right FilterNode
+	Pos   int
+}
+
+type FilterNot struct {
+	Expr FilterNode
+	Pos  int
+}
+
+// FilterComparison compares a whitelisted field with a value, which is either an int or a string.
+type FilterComparison struct {
+	Field string
+	Op    string
+	Value interface{}
+	Pos   int
+}
+
+func (n *FilterLogical) Position() int    { return n.Pos }
+func (n *FilterNot) Position() int        { return n.Pos }
+func (n *FilterComparison) Position() int { return n.Pos }
+
+// kinds of tokens
+const (
+	tokenEnd = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+type filterToken struct {
+	Kind  int
+	Text  string
+	Pos   int
+	Value string // unquoted text of strings
+}
+
+type filterParser struct {
+	tokens []filterToken
+	next   int
+}
+
+// ParseFilter parses a filter expression like `priority>=3 AND created>2024-01-01 AND text~"buy"`.
+// Expressions are comparisons of fields with values, combined with AND, OR, NOT and parentheses.
+func ParseFilter(expr string) (FilterNode, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{tokens, 0}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.Kind != tokenEnd {
+		return nil, &FilterError{t.Pos, fmt.Sprintf("unexpected %q", t.Text)}
+	}
+	return node, nil
+}
+
+// AndFilters combines filters with AND, nil filters are left out. Without any filter the result is nil as well.
+func AndFilters(filters ...FilterNode) FilterNode {
+	var result FilterNode
+	for _, filter := range filters {
+		if filter == nil {
+			continue
+		}
+		if result == nil {
+			result = filter
+		} else {
+			result = &FilterLogical{"AND", result, filter, 0}
+		}
+	}
+	return result
+}
+
+func tokenizeFilter(expr string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, filterToken{tokenOpen, "(", i + 1, ""})
+			i++
+		case c == ')':
+			tokens = append(tokens, filterToken{tokenClose, ")", i + 1, ""})
+			i++
+		case c == '"':
+			start := i
+			value := []byte{}
+			for i++; i < len(expr) && expr[i] != '"'; i++ {
+				if expr[i] == '\\' && i+1 < len(expr) {
+					i++
+				}
+				value = append(value, expr[i])
+			}
+			if i >= len(expr) {
+				return nil, &FilterError{start + 1, "unterminated string"}
+			}
+			i++
+			tokens = append(tokens, filterToken{tokenString, expr[start:i], start + 1, string(value)})
+		case strings.IndexByte("<>=!~", c) >= 0:
+			op := ""
+			for _, o := range filterOperators {
+				if strings.HasPrefix(expr[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &FilterError{i + 1, fmt.Sprintf("unknown operator %q", string(c))}
+			}
+			tokens = append(tokens, filterToken{tokenOperator, op, i + 1, ""})
+			i += len(op)
+		default:
+			start := i
+			for i < len(expr) && strings.IndexByte(" \t\n\r()\"<>=!~", expr[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, filterToken{tokenWord, expr[start:i], start + 1, ""})
+		}
+	}
+	return append(tokens, filterToken{tokenEnd, "end of filter", len(expr) + 1, ""}), nil
+}
+
+func (p *filterParser) peek() filterToken {
+	return p.tokens[p.next]
+}
+
+func (p *filterParser) take() filterToken {
+	t := p.tokens[p.next]
+	if t.Kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+// isKeyword checks for AND, OR and NOT, which are not case sensitive
+func (t filterToken) isKeyword(keyword string) bool {
+	return t.Kind == tokenWord && strings.ToUpper(t.Text) == keyword
+}
+
+func (p *filterParser) parseOr() (FilterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("OR") {
+		t := p.take()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &FilterLogical{"OR", left, right, t.Pos}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (FilterNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("AND") {
+		t := p.take()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &FilterLogical{"AND", left, right, t.Pos}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseNot() (FilterNode, error) {
+	if p.peek().isKeyword("NOT") {
+		t := p.take()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &FilterNot{expr, t.Pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *filterParser) parsePrimary() (FilterNode, error) {
+	t := p.take()
+	if t.Kind == tokenOpen {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.take(); c.Kind != tokenClose {
+			return nil, &FilterError{c.Pos, fmt.Sprintf("expected \")\" instead of %q", c.Text)}
+		}
+		return expr, nil
+	}
+	if t.Kind != tokenWord || t.isKeyword("AND") || t.isKeyword("OR") {
+		return nil, &FilterError{t.Pos, fmt.Sprintf("expected a field instead of %q", t.Text)}
+	}
+
+	name := strings.ToLower(t.Text)
+	field, ok := filterFields[name]
+	if !ok {
+		return nil, &FilterError{t.Pos, fmt.Sprintf("unknown field %q", t.Text)}
+	}
+
+	op := p.take()
+	if op.Kind != tokenOperator {
+		return nil, &FilterError{op.Pos, fmt.Sprintf("expected an operator instead of %q", op.Text)}
+	}
+	if op.Text == "~" && field.Kind != filterText {
+		return nil, &FilterError{op.Pos, fmt.Sprintf("operator \"~\" only works on text, not on %q", t.Text)}
+	}
+
+	v := p.take()
+	if v.Kind != tokenWord && v.Kind != tokenString {
+		return nil, &FilterError{v.Pos, fmt.Sprintf("expected a value instead of %q", v.Text)}
+	}
+	text := v.Text
+	if v.Kind == tokenString {
+		text = v.Value
+	}
+	value, err := parseFilterValue(field.Kind, text)
+	if err != nil {
+		return nil, &FilterError{v.Pos, fmt.Sprintf("invalid value %q for %q", v.Text, t.Text)}
+	}
+	return &FilterComparison{name, op.Text, value, t.Pos}, nil
+}
+
+// parseFilterValue converts the value to what is stored in the database for the kind of field.
+func parseFilterValue(kind int, text string) (interface{}, error) {
+	switch kind {
+	case filterNumber:
+		return strconv.Atoi(text)
+	case filterDate:
+		return parseFilterDate(text)
+	case filterPriority:
+		if p, err := strconv.Atoi(text); err == nil {
+			return p, nil
+		}
+		p, err := ParsePriority(text)
+		return int(p), err
+	case filterStatus:
+		if text == "" {
+			return nil, errors.New("Invalid status")
+		}
+		return ParseTaskStatus(text)
+	}
+	return text, nil
+}
+
+// parseFilterDate accepts unix timestamps, dates and RFC 3339 timestamps. Dates are midnight UTC.
+func parseFilterDate(text string) (int, error) {
+	if timestamp, err := strconv.Atoi(text); err == nil {
+		return timestamp, nil
+	}
+	for _, layout := range []string{"2006-01-02", time.RFC3339} {
+		if t, err := time.Parse(layout, text); err == nil {
+			return int(t.Unix()), nil
+		}
+	}
+	return 0, errors.New("Invalid date")
+}
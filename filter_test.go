@@ -0,0 +1,93 @@
+package main
+
+import "fmt"
+import "testing"
+
+func Test_filter_ParseFilter(t *testing.T) {
+	var tests = []struct {
+		expr  string
+		where string
+		args  []interface{}
+	}{
+		{`priority>=3`, `T.PRIORITY >= ?`, []interface{}{3}},
+		{`priority >= high`, `T.PRIORITY >= ?`, []interface{}{4}},
+		{`text~"buy"`, `T.TASK like ? escape '\'`, []interface{}{"%buy%"}},
+		{`text~"100%_\"sure\""`, `T.TASK like ? escape '\'`, []interface{}{`%100\%\_"sure"%`}},
+		{`created>2024-01-01`, `T.CREATED > ?`, []interface{}{1704067200}},
+		{`updated<=2024-01-01T01:00:00+01:00`, `T.LAST_UPDATED <= ?`, []interface{}{1704067200}},
+		{`Status = Done`, `T.STATUS = ?`, []interface{}{"Done"}},
+		{`priority>=3 AND created>2024-01-01 AND text~"buy"`,
+			`((T.PRIORITY >= ? and T.CREATED > ?) and T.TASK like ? escape '\')`, []interface{}{3, 1704067200, "%buy%"}},
+		{`id=1 or id=2 and not project!=0`, `(T.ID = ? or (T.ID = ? and not T.PROJECT_ID != ?))`, []interface{}{1, 2, 0}},
+		{`(id=1 OR id=2) AND assignee=3`, `((T.ID = ? or T.ID = ?) and T.ASSIGNEE_ID = ?)`, []interface{}{1, 2, 3}},
+		{`NOT (account=1)`, `not T.ACCOUNT_ID = ?`, []interface{}{1}},
+	}
+
+	for _, test := range tests {
+		filter, err := ParseFilter(test.expr)
+		if err != nil {
+			t.Errorf("ParseFilter of [%v] failed: [%v]", test.expr, err)
+			continue
+		}
+		where, args := compileFilter(filter)
+		if where != test.where || fmt.Sprint(args) != fmt.Sprint(test.args) {
+			t.Errorf("Filter [%v] was compiled to [%v] %v, instead of [%v] %v", test.expr, where, args, test.where, test.args)
+		}
+	}
+}
+
+func Test_filter_ParseFilterErrors(t *testing.T) {
+	var tests = []struct {
+		expr string
+		pos  int
+	}{
+		{``, 1},
+		{`secret=1`, 1},
+		{`priority`, 9},
+		{`priority>`, 10},
+		{`priority>=urgentest`, 11},
+		{`priority~3`, 9},
+		{`id=1 AND`, 9},
+		{`id=1 id=2`, 6},
+		{`(id=1 OR id=2`, 14},
+		{`id=1)`, 5},
+		{`text~"buy`, 6},
+		{`id=1 AND created>yesterday`, 18},
+		{`status=Sleeping`, 8},
+		{`id=!1`, 4},
+		{`id=1 OR AND id=2`, 9},
+	}
+
+	for _, test := range tests {
+		_, err := ParseFilter(test.expr)
+		ferr, ok := err.(*FilterError)
+		if !ok {
+			t.Errorf("ParseFilter of [%v] should fail with a FilterError, but returned [%v]", test.expr, err)
+			continue
+		}
+		if ferr.Pos != test.pos {
+			t.Errorf("ParseFilter of [%v] failed at position [%v], instead of [%v]: [%v]", test.expr, ferr.Pos, test.pos, ferr)
+		}
+	}
+
+	_, err := ParseFilter(`secret=1`)
+	if err == nil || err.Error() != `Invalid filter at position 1: unknown field "secret"` {
+		t.Errorf("ParseFilter returned the error [%v]", err)
+	}
+}
+
+func Test_filter_AndFilters(t *testing.T) {
+	id, _ := ParseFilter(`id=1`)
+	priority, _ := ParseFilter(`priority>=3`)
+
+	if AndFilters() != nil || AndFilters(nil, nil) != nil {
+		t.Error("AndFilters without filters should be nil")
+	}
+	if where, _ := compileFilter(AndFilters(nil, id, nil)); where != `T.ID = ?` {
+		t.Errorf("AndFilters of a single filter was compiled to [%v]", where)
+	}
+	where, args := compileFilter(AndFilters(id, nil, priority))
+	if where != `(T.ID = ? and T.PRIORITY >= ?)` || fmt.Sprint(args) != "[1 3]" {
+		t.Errorf("AndFilters was compiled to [%v] %v", where, args)
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import "fmt"
+import "reflect"
+
+// actions recorded in the history
+const (
+	ActionCreate  = "Create"
+	ActionUpdate  = "Update"
+	ActionDelete  = "Delete"
+	ActionRestore = "Restore"
+	ActionPurge   = "Purge"
+	ActionUndo    = "Undo"
+)
+
+// entities recorded in the history
+const (
+	EntityTask    = "Task"
+	EntityAccount = "Account"
+)
+
+// fields that change all the time, and are therefore not recorded
+var historyIgnored = map[string]bool{"Id": true, "LastUpdated": true, "LastAuth": true, "Version": true}
+
+// fields whose values must not show up in the history, only the fact that they have been changed
+var historyHidden = map[string]bool{"Password": true, "Salt": true}
+
+const hiddenValue = "********"
+
+// Change records the change of a single field of an entity, together with who changed it and when.
+// Changes are only ever appended, never updated or deleted.
+type Change struct {
+	Id        int    `db:"ID"`
+	Entity    string `db:"ENTITY"`
+	EntityId  int    `db:"ENTITY_ID"`
+	Action    string `db:"ACTION"`
+	AccountId int    `db:"ACCOUNT_ID"`
+	Changed   int    `db:"CHANGED"`
+	Field     string `db:"FIELD"`
+	OldValue  string `db:"OLD_VALUE"`
+	NewValue  string `db:"NEW_VALUE"`
+}
+
+type Changes []Change
+
+// AuditFilter restricts the audit log, zero values do not filter at all.
+type AuditFilter struct {
+	AccountId int
+	Entity    string
+	EntityId  int
+	From      int
+	To        int
+}
+
+// DiffChanges compares two versions of the same entity (a Task or an Account) field by field,
+// and returns a Change for each field that differs. Creations are compared against an empty entity
+// as the old version, deletions against an empty entity as the new version.
+func DiffChanges(entity string, entityId int, action string, accountId int, changed int, old interface{}, new interface{}) Changes {
+	changes := Changes{}
+
+	o := reflect.Indirect(reflect.ValueOf(old))
+	n := reflect.Indirect(reflect.ValueOf(new))
+	for i := 0; i < o.NumField(); i++ {
+		field := o.Type().Field(i)
+		if historyIgnored[field.Name] || field.Tag.Get("db") == "-" {
+			continue
+		}
+
+		oldValue := fmt.Sprint(o.Field(i).Interface())
+		newValue := fmt.Sprint(n.Field(i).Interface())
+		if oldValue == newValue {
+			continue
+		}
+		if historyHidden[field.Name] {
+			oldValue, newValue = hiddenValue, hiddenValue
+		}
+
+		changes = append(changes, Change{-1, entity, entityId, action, accountId, changed, field.Name, oldValue, newValue})
+	}
+	return changes
+}
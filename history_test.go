@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func Test_history_DiffChanges(t *testing.T) {
+	old := Task{Id: 7, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Sleep", Status: "Open", Version: 1}
+	new := Task{Id: 7, AccountId: 2, Created: 1234567890, LastUpdated: 1234567899, Priority: 3, Task: "Sleep more", AssigneeId: 3, Status: "Open", Version: 1}
+
+	changes := DiffChanges(EntityTask, 7, ActionUpdate, 2, 1234567899, old, new)
+	expected := Changes{
+		{-1, EntityTask, 7, ActionUpdate, 2, 1234567899, "Priority", "1", "3"},
+		{-1, EntityTask, 7, ActionUpdate, 2, 1234567899, "Task", "Sleep", "Sleep more"},
+		{-1, EntityTask, 7, ActionUpdate, 2, 1234567899, "AssigneeId", "0", "3"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("DiffChanges returned [%v], instead of [%v]", changes, expected)
+	}
+	for i, c := range changes {
+		if c != expected[i] {
+			t.Errorf("DiffChanges returned [%v], instead of [%v]", changes, expected)
+			break
+		}
+	}
+
+	if changes := DiffChanges(EntityTask, 7, ActionUpdate, 2, 1234567899, old, old); len(changes) != 0 {
+		t.Errorf("DiffChanges of unchanged task returned [%v]", changes)
+	}
+
+	// creations are compared to an empty entity, secrets are never recorded
+	account := Account{Id: 5, Name: "Samurai", Email: "Samurai@Ronin", Password: "abcdef", Salt: "123456789", Role: "User", LastAuth: 1234567890, Version: 1}
+	changes = DiffChanges(EntityAccount, 5, ActionCreate, 1, 1234567899, Account{}, account)
+	fields := map[string]Change{}
+	for _, c := range changes {
+		fields[c.Field] = c
+	}
+	if len(changes) != 5 || fields["Name"].NewValue != "Samurai" || fields["Role"].OldValue != "" {
+		t.Errorf("DiffChanges of new account returned [%v]", changes)
+	}
+	if fields["Password"].NewValue != hiddenValue || fields["Salt"].OldValue != hiddenValue {
+		t.Errorf("DiffChanges should hide secrets: [%v]", changes)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import "io"
+import "time"
+import "strconv"
+import "strings"
+import "crypto/sha256"
+import "encoding/hex"
+
+const icalDateLayout = "20060102T150405Z"
+
+// lines of iCalendar are folded after this many octets, not counting the line break
+const icalLineLength = 75
+
+var icalStatuses = map[string]string{
+	"Open":       "NEEDS-ACTION",
+	"InProgress": "IN-PROCESS",
+	"Done":       "COMPLETED",
+}
+
+// FeedToken gives access to the iCalendar feed of an account without signing requests, since calendar clients cannot do that.
+// Only the hash of the token is stored, the token itself is shown once when it is created.
+type FeedToken struct {
+	AccountId int    `db:"ACCOUNT_ID"`
+	TokenHash string `db:"TOKEN_HASH"`
+	Created   int    `db:"CREATED"`
+}
+
+// NewFeedToken generates a new random token for the account, and returns it along with the feed token to store.
+func NewFeedToken(accountId int) (*FeedToken, string, error) {
+	token, err := GenerateRandomString()
+	if err != nil {
+		return nil, "", err
+	}
+	return &FeedToken{accountId, HashFeedToken(*token), int(time.Now().Unix())}, *token, nil
+}
+
+func HashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ICalendar returns the iCalendar priority, which goes from 1 for the highest to 9 for the lowest priority.
+// The priority scale is spread over that range, a scale of a single priority is right in the middle.
+func (p Priority) ICalendar() int {
+	if priorityScale.Max == priorityScale.Min {
+		return 5
+	}
+	p = p.Normalize()
+	span := priorityScale.Max - priorityScale.Min
+	return 1 + ((priorityScale.Max-int(p))*8+span/2)/span
+}
+
+// icalExporter writes an iCalendar object with a VTODO component for every task, see RFC 5545.
+// Every task keeps its UID, and its version is the SEQUENCE, so that calendar clients can follow its changes.
+type icalExporter struct {
+	w      io.Writer
+	header bool
+}
+
+func (e *icalExporter) Export(t *Task) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	lines := []string{
+		"BEGIN:VTODO",
+		"UID:task-" + strconv.Itoa(t.Id) + "@go-todo",
+		// without a METHOD, DTSTAMP is when the task was last changed
+		"DTSTAMP:" + icalDate(t.LastUpdated),
+		"CREATED:" + icalDate(t.Created),
+		"LAST-MODIFIED:" + icalDate(t.LastUpdated),
+		"SEQUENCE:" + strconv.Itoa(t.Version),
+		"SUMMARY:" + icalText(t.Task),
+		"PRIORITY:" + strconv.Itoa(t.Priority.ICalendar()),
+	}
+	if status, ok := icalStatuses[t.Status]; ok {
+		lines = append(lines, "STATUS:"+status)
+	}
+	if t.Status == "Done" {
+		lines = append(lines, "COMPLETED:"+icalDate(t.LastUpdated), "PERCENT-COMPLETE:100")
+	}
+	lines = append(lines, "END:VTODO")
+	return e.writeLines(lines)
+}
+
+func (e *icalExporter) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.writeLines([]string{"END:VCALENDAR"})
+}
+
+func (e *icalExporter) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.writeLines([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//go-todo//go-todo//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:go-todo",
+	})
+}
+
+func (e *icalExporter) writeLines(lines []string) error {
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(icalFold(line))
+	}
+	_, err := io.WriteString(e.w, b.String())
+	return err
+}
+
+func icalDate(timestamp int) string {
+	return time.Unix(int64(timestamp), 0).UTC().Format(icalDateLayout)
+}
+
+// icalText escapes a text value, line breaks become \n.
+func icalText(text string) string {
+	return strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n", "\r", "\\n").Replace(text)
+}
+
+// icalFold ends the line with CRLF, and breaks it into lines of at most 75 octets, each continued line starting with a space.
+// Lines are never broken within a multi-byte character.
+func icalFold(line string) string {
+	var b strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > icalLineLength {
+			b.WriteString("\r\n ")
+			length = 1
+		}
+		b.WriteRune(r)
+		length += size
+	}
+	b.WriteString("\r\n")
+	return b.String()
+}
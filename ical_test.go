@@ -0,0 +1,109 @@
+package main
+
+import "bytes"
+import "strings"
+import "testing"
+
+func Test_ical_Priority(t *testing.T) {
+	expected := map[Priority]int{5: 1, 4: 3, 3: 5, 2: 7, 1: 9}
+	for p, priority := range expected {
+		if p.ICalendar() != priority {
+			t.Errorf("Priority [%v] has iCalendar priority [%v], instead of [%v]", p, p.ICalendar(), priority)
+		}
+	}
+}
+
+func Test_ical_icalFold(t *testing.T) {
+	if line := icalFold("SUMMARY:short"); line != "SUMMARY:short\r\n" {
+		t.Errorf("icalFold returned [%q]", line)
+	}
+
+	line := icalFold("SUMMARY:" + strings.Repeat("ä", 80))
+	parts := strings.Split(strings.TrimSuffix(line, "\r\n"), "\r\n")
+	if len(parts) != 3 || strings.Replace(strings.TrimSuffix(line, "\r\n"), "\r\n ", "", -1) != "SUMMARY:"+strings.Repeat("ä", 80) {
+		t.Errorf("icalFold returned [%q]", line)
+	}
+	for i, part := range parts {
+		if len(part) > 75 || (i > 0 && part[0] != ' ') {
+			t.Errorf("icalFold returned the line [%q]", part)
+		}
+	}
+
+	if text := icalText("Say \"hello\", then; leave\\\nnow"); text != `Say "hello"\, then\; leave\\\nnow` {
+		t.Errorf("icalText returned [%v]", text)
+	}
+}
+
+func Test_ical_icalExporter(t *testing.T) {
+	tasks := Tasks{
+		{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567896, Priority: 5, Task: "Say hello,\nthen leave", ProjectId: 4, AssigneeId: 3, Status: "Done", Version: 2},
+	}
+	var buf bytes.Buffer
+	exporter, err := NewTaskExporter("ical", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tasks {
+		if err := exporter.Export(&tasks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := exporter.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//go-todo//go-todo//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:go-todo",
+		"BEGIN:VTODO",
+		"UID:task-1@go-todo",
+		"DTSTAMP:20090213T233135Z",
+		"CREATED:20090213T233130Z",
+		"LAST-MODIFIED:20090213T233135Z",
+		"SEQUENCE:1",
+		"SUMMARY:Buy food!",
+		"PRIORITY:5",
+		"STATUS:NEEDS-ACTION",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:task-2@go-todo",
+		"DTSTAMP:20090213T233136Z",
+		"CREATED:20090213T233131Z",
+		"LAST-MODIFIED:20090213T233136Z",
+		"SEQUENCE:2",
+		"SUMMARY:Say hello\\,\\nthen leave",
+		"PRIORITY:1",
+		"STATUS:COMPLETED",
+		"COMPLETED:20090213T233136Z",
+		"PERCENT-COMPLETE:100",
+		"END:VTODO",
+		"END:VCALENDAR",
+	}, "\r\n") + "\r\n"
+	if buf.String() != expected {
+		t.Errorf("icalExporter wrote [%v], instead of [%v]", buf.String(), expected)
+	}
+
+	buf.Reset()
+	exporter, _ = NewTaskExporter("ical", &buf)
+	exporter.Close()
+	if !strings.HasPrefix(buf.String(), "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(buf.String(), "X-WR-CALNAME:go-todo\r\nEND:VCALENDAR\r\n") {
+		t.Errorf("icalExporter wrote [%v] without tasks", buf.String())
+	}
+}
+
+func Test_ical_NewFeedToken(t *testing.T) {
+	token, secret, err := NewFeedToken(2)
+	if err != nil || token.AccountId != 2 || token.Created == 0 || token.TokenHash != HashFeedToken(secret) || token.TokenHash == secret {
+		t.Errorf("NewFeedToken returned [%v], [%v], [%v]", token, secret, err)
+	}
+	if !validFeedPath.MatchString("/feed/" + secret + ".ics") {
+		t.Errorf("Feed path of token [%v] is not valid", secret)
+	}
+	if other, _, _ := NewFeedToken(2); other.TokenHash == token.TokenHash {
+		t.Error("NewFeedToken returned the same token twice")
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import "io"
+import "time"
+import "errors"
+import "strconv"
+import "strings"
+import "encoding/csv"
+import "encoding/json"
+
+// tasks are saved in batches of this size, all within the same transaction
+const importBatchSize = 100
+
+const maxImportRows = 10000
+
+var ErrInvalidColumns = errors.New("Invalid columns")
+
+// importFields are the task fields that can be imported, by their lower case names.
+// The Id of exported tasks is imported as external id, so that importing an export twice does not duplicate its tasks.
+var importFields = map[string]string{
+	"externalid":  "ExternalId",
+	"id":          "ExternalId",
+	"task":        "Task",
+	"priority":    "Priority",
+	"status":      "Status",
+	"created":     "Created",
+	"lastupdated": "LastUpdated",
+	"projectid":   "ProjectId",
+}
+
+// ImportRow is a single task of an import, with its fields as given in the import. Rows are counted from 1.
+type ImportRow struct {
+	Row         int
+	ExternalId  string
+	Task        string
+	Priority    string
+	Status      string
+	Created     string
+	LastUpdated string
+	ProjectId   string
+	Error       string // the row could not be read at all
+}
+
+// ImportResult reports what happened to a single row, Action being ActionCreate or ActionUpdate.
+// Task is the task as it is, or would be for a dry run, after the import.
+type ImportResult struct {
+	Row        int
+	ExternalId string
+	Action     string
+	Task       *Task `json:",omitempty"`
+	Error      string
+}
+
+// ImportReport lists the results of all rows. Nothing is imported for a dry run, or if any row failed without ContinueOnError.
+type ImportReport struct {
+	DryRun   bool
+	Imported bool
+	Created  int
+	Updated  int
+	Failed   int
+	Results  []ImportResult
+}
+
+// ParseImport reads the rows of an import in the format "csv" or "json", the column mapping only applies to CSV.
+func ParseImport(r io.Reader, format string, columns map[string]string) ([]ImportRow, error) {
+	switch format {
+	case "csv":
+		return ParseCSVImport(r, columns)
+	case "json":
+		return ParseJSONImport(r)
+	}
+	return nil, ErrInvalidFormat
+}
+
+// ParseColumnMapping parses a mapping of CSV columns to task fields like "Title:Task,Prio:Priority".
+func ParseColumnMapping(value string) (map[string]string, error) {
+	columns := map[string]string{}
+	if strings.TrimSpace(value) == "" {
+		return columns, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, ErrInvalidColumns
+		}
+		if _, ok := importFields[strings.ToLower(strings.TrimSpace(parts[1]))]; !ok {
+			return nil, ErrInvalidColumns
+		}
+		columns[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	}
+	return columns, nil
+}
+
+// ParseCSVImport reads the rows of a CSV file with a header row. Columns are mapped to task fields by the given mapping,
+// otherwise by their names, which is the case for CSV exports. Columns that are not task fields are ignored.
+func ParseCSVImport(r io.Reader, columns map[string]string) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidColumns
+	}
+	fields := make([]string, len(header))
+	hasTask := false
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if field, ok := columns[name]; ok {
+			name = strings.ToLower(field)
+		}
+		fields[i] = importFields[name]
+		hasTask = hasTask || fields[i] == "Task"
+	}
+	if !hasTask {
+		return nil, ErrInvalidColumns
+	}
+
+	rows := []ImportRow{}
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(rows) >= maxImportRows {
+			return nil, errors.New("Too many rows")
+		}
+		// a broken quote cannot be recovered from, everything after it would be read wrong
+		if err != nil {
+			return nil, err
+		}
+		row := ImportRow{Row: n}
+		for i, value := range record {
+			if i < len(fields) {
+				row.set(fields[i], value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// ParseJSONImport reads the rows of a JSON array of tasks, like JSON exports.
+// Each task can have an ExternalId, otherwise its Id is used.
+func ParseJSONImport(r io.Reader) ([]ImportRow, error) {
+	var elements []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&elements); err != nil {
+		return nil, errors.New("Invalid data")
+	}
+	if len(elements) > maxImportRows {
+		return nil, errors.New("Too many rows")
+	}
+
+	rows := []ImportRow{}
+	for i, element := range elements {
+		row := ImportRow{Row: i + 1}
+		var task struct {
+			Id          int
+			ExternalId  string
+			Created     int
+			LastUpdated int
+			Priority    json.RawMessage
+			Task        string
+			ProjectId   int
+			Status      string
+		}
+		if err := json.Unmarshal(element, &task); err != nil {
+			row.Error = "Invalid data"
+			rows = append(rows, row)
+			continue
+		}
+
+		row.ExternalId = task.ExternalId
+		if row.ExternalId == "" && task.Id > 0 {
+			row.ExternalId = strconv.Itoa(task.Id)
+		}
+		row.Task = task.Task
+		row.Status = task.Status
+		if task.Created > 0 {
+			row.Created = strconv.Itoa(task.Created)
+		}
+		if task.LastUpdated > 0 {
+			row.LastUpdated = strconv.Itoa(task.LastUpdated)
+		}
+		if task.ProjectId > 0 {
+			row.ProjectId = strconv.Itoa(task.ProjectId)
+		}
+		// priorities can be numbers or names
+		var name string
+		if err := json.Unmarshal(task.Priority, &name); err == nil {
+			row.Priority = name
+		} else if len(task.Priority) > 0 && string(task.Priority) != "null" {
+			row.Priority = string(task.Priority)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (row *ImportRow) set(field string, value string) {
+	value = strings.TrimSpace(value)
+	switch field {
+	case "ExternalId":
+		row.ExternalId = value
+	case "Task":
+		row.Task = value
+	case "Priority":
+		row.Priority = value
+	case "Status":
+		row.Status = value
+	case "Created":
+		row.Created = value
+	case "LastUpdated":
+		row.LastUpdated = value
+	case "ProjectId":
+		row.ProjectId = value
+	}
+}
+
+// Validate turns the row into a new task of the account. Without a priority the task gets the one in the middle of the priority scale,
+// and without timestamps the current time is used. Dates can be given like in filters, see parseFilterDate.
+func (row *ImportRow) Validate(accountId int) (Task, error) {
+	if row.Error != "" {
+		return Task{}, errors.New(row.Error)
+	}
+	if strings.TrimSpace(row.Task) == "" {
+		return Task{}, errors.New("Invalid task")
+	}
+
+	now := int(time.Now().Unix())
+	task := Task{-1, accountId, now, now, 0, row.Task, 0, 0, "", 0, 0, ""}
+
+	task.Priority = Priority((priorityScale.Min + priorityScale.Max) / 2)
+	if row.Priority != "" {
+		priority, err := ParsePriority(row.Priority)
+		if err != nil {
+			return Task{}, err
+		}
+		task.Priority = priority
+	}
+
+	status, err := ParseTaskStatus(row.Status)
+	if err != nil {
+		return Task{}, err
+	}
+	task.Status = status
+
+	if row.Created != "" {
+		if task.Created, err = parseFilterDate(row.Created); err != nil {
+			return Task{}, errors.New("Invalid created date")
+		}
+		task.LastUpdated = task.Created
+	}
+	if row.LastUpdated != "" {
+		if task.LastUpdated, err = parseFilterDate(row.LastUpdated); err != nil {
+			return Task{}, errors.New("Invalid last updated date")
+		}
+	}
+
+	if row.ProjectId != "" {
+		if task.ProjectId, err = strconv.Atoi(row.ProjectId); err != nil || task.ProjectId < 0 {
+			return Task{}, errors.New("Invalid project")
+		}
+	}
+	return task, nil
+}
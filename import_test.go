@@ -0,0 +1,107 @@
+package main
+
+import "fmt"
+import "bytes"
+import "strings"
+import "testing"
+
+func Test_import_ParseColumnMapping(t *testing.T) {
+	columns, err := ParseColumnMapping(" Title : Task,Prio:priority ")
+	if err != nil || fmt.Sprint(columns) != "map[prio:priority title:Task]" {
+		t.Errorf("ParseColumnMapping returned [%v], [%v]", columns, err)
+	}
+	if columns, err := ParseColumnMapping(""); err != nil || len(columns) != 0 {
+		t.Errorf("ParseColumnMapping of nothing returned [%v], [%v]", columns, err)
+	}
+	for _, value := range []string{"Title", "Title:Secret", ":Task", "Title:Task:Text"} {
+		if _, err := ParseColumnMapping(value); err != ErrInvalidColumns {
+			t.Errorf("ParseColumnMapping of [%v] returned [%v]", value, err)
+		}
+	}
+}
+
+func Test_import_ParseCSVImport(t *testing.T) {
+	data := "Title,Prio,Notes,Status\n" +
+		"Buy milk,high,ignored,Open\n" +
+		"\"Say \"\"hello\"\",\nthen leave\",2\n"
+	rows, err := ParseCSVImport(strings.NewReader(data), map[string]string{"title": "Task", "prio": "Priority"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ImportRow{
+		{Row: 1, Task: "Buy milk", Priority: "high", Status: "Open"},
+		{Row: 2, Task: "Say \"hello\",\nthen leave", Priority: "2"},
+	}
+	if fmt.Sprint(rows) != fmt.Sprint(expected) {
+		t.Errorf("ParseCSVImport returned [%v], instead of [%v]", rows, expected)
+	}
+
+	// exports can be imported without a mapping, their ids become external ids
+	rows, err = ParseCSVImport(strings.NewReader("Id,AccountId,Task,ProjectId\n7,2,Buy food!,0\n"), nil)
+	if err != nil || len(rows) != 1 || rows[0].ExternalId != "7" || rows[0].Task != "Buy food!" || rows[0].ProjectId != "0" {
+		t.Errorf("ParseCSVImport of an export returned [%v], [%v]", rows, err)
+	}
+
+	for _, data := range []string{"", "Title,Prio\nBuy milk,3\n"} {
+		if _, err := ParseCSVImport(strings.NewReader(data), nil); err != ErrInvalidColumns {
+			t.Errorf("ParseCSVImport of [%v] returned [%v]", data, err)
+		}
+	}
+	if _, err := ParseCSVImport(strings.NewReader("Task\n\"broken\n"), nil); err == nil {
+		t.Error("ParseCSVImport of a broken quote should fail")
+	}
+}
+
+func Test_import_ParseJSONImport(t *testing.T) {
+	tasks := Tasks{
+		{Id: 3, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 4, Task: "Buy food!", Status: "Done", Version: 1, Rank: "V"},
+	}
+	var buf bytes.Buffer
+	exporter, _ := NewTaskExporter("json", &buf)
+	exporter.Export(&tasks[0])
+	exporter.Close()
+
+	rows, err := ParseJSONImport(strings.NewReader(buf.String()))
+	expected := ImportRow{Row: 1, ExternalId: "3", Task: "Buy food!", Priority: "4", Status: "Done", Created: "1234567890", LastUpdated: "1234567895"}
+	if err != nil || len(rows) != 1 || rows[0] != expected {
+		t.Errorf("ParseJSONImport of an export returned [%v], [%v] instead of [%v]", rows, err, expected)
+	}
+
+	rows, err = ParseJSONImport(strings.NewReader(`[{"ExternalId": "a-1", "Id": 5, "Task": "Water plants", "Priority": "low"}, "nonsense"]`))
+	if err != nil || len(rows) != 2 || rows[0].ExternalId != "a-1" || rows[0].Priority != "low" || rows[1].Error != "Invalid data" {
+		t.Errorf("ParseJSONImport returned [%v], [%v]", rows, err)
+	}
+
+	if _, err := ParseJSONImport(strings.NewReader(`{"Task": "not a list"}`)); err == nil {
+		t.Error("ParseJSONImport of an object should fail")
+	}
+}
+
+func Test_import_Validate(t *testing.T) {
+	row := ImportRow{Row: 1, Task: "Buy milk", Priority: "urgent", Status: "InProgress", Created: "2024-01-01", ProjectId: "2"}
+	task, err := row.Validate(3)
+	expected := Task{Id: -1, AccountId: 3, Created: 1704067200, LastUpdated: 1704067200, Priority: 5, Task: "Buy milk", ProjectId: 2, Status: "InProgress"}
+	if err != nil || task != expected {
+		t.Errorf("Validate returned [%v], [%v] instead of [%v]", task, err, expected)
+	}
+
+	// without priority and status the task is of normal priority and open
+	row = ImportRow{Row: 2, Task: "Water plants"}
+	if task, err := row.Validate(3); err != nil || task.Priority != 3 || task.Status != "Open" || task.Created == 0 {
+		t.Errorf("Validate returned [%v], [%v]", task, err)
+	}
+
+	for _, row := range []ImportRow{
+		{Row: 1, Task: "  "},
+		{Row: 2, Task: "Buy milk", Priority: "extreme"},
+		{Row: 3, Task: "Buy milk", Status: "Sleeping"},
+		{Row: 4, Task: "Buy milk", Created: "yesterday"},
+		{Row: 5, Task: "Buy milk", LastUpdated: "tomorrow"},
+		{Row: 6, Task: "Buy milk", ProjectId: "-1"},
+		{Row: 7, Error: "Invalid data"},
+	} {
+		if _, err := row.Validate(3); err == nil {
+			t.Errorf("Validate of [%v] should fail", row)
+		}
+	}
+}
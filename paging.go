@@ -0,0 +1,200 @@
+package main
+
+import "sort"
+import "bytes"
+import "errors"
+import "strings"
+import "crypto/hmac"
+import "crypto/rand"
+import "crypto/sha256"
+import "encoding/json"
+import "encoding/base64"
+
+const defaultPageSize = 50
+const maxPageSize = 500
+
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// cursors are signed, so that clients cannot make up positions. Without a configured secret they are only valid until a restart.
+var cursorSecret = func() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(err)
+	}
+	return secret
+}()
+
+func SetCursorSecret(secret string) {
+	cursorSecret = []byte(secret)
+}
+
+// Cursor is the position of a page within a sorted list: right after, or right before the row with these sort key values and id.
+type Cursor struct {
+	List   string // "tasks" or "accounts"
+	Sort   string // the sort keys the values belong to
+	Values []interface{}
+	Id     int
+	Before bool
+}
+
+// Page tells which rows of a sorted list to read, a limit of 0 reads all rows.
+type Page struct {
+	Cursor *Cursor
+	Limit  int
+}
+
+// PageResult tells whether there are rows before or after a page.
+type PageResult struct {
+	Next *Cursor
+	Prev *Cursor
+}
+
+func (k SortKeys) String() string {
+	names := []string{}
+	for _, key := range k {
+		if key.Desc {
+			names = append(names, "-"+key.Field)
+		} else {
+			names = append(names, key.Field)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// EncodeCursor turns a cursor into an opaque string to be used in URLs.
+func EncodeCursor(c *Cursor) string {
+	js, err := json.Marshal(c)
+	if err != nil {
+		panic(err) // a cursor only holds ints and strings
+	}
+	return base64.RawURLEncoding.EncodeToString(js) + "." + base64.RawURLEncoding.EncodeToString(signCursor(js))
+}
+
+// DecodeCursor checks the signature of an encoded cursor, and that it belongs to the list and its sort keys.
+func DecodeCursor(value string, list string, keys SortKeys) (*Cursor, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	js, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signCursor(js)) {
+		return nil, ErrInvalidCursor
+	}
+
+	c := &Cursor{}
+	decoder := json.NewDecoder(bytes.NewReader(js))
+	decoder.UseNumber()
+	if err := decoder.Decode(c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.List != list || c.Sort != keys.String() || len(c.Values) != len(keys) {
+		return nil, ErrInvalidCursor
+	}
+	// sort key values are either ints or strings
+	for i, v := range c.Values {
+		if n, ok := v.(json.Number); ok {
+			i64, err := n.Int64()
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			c.Values[i] = int(i64)
+		} else if _, ok := v.(string); !ok {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return c, nil
+}
+
+func signCursor(js []byte) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(js)
+	return mac.Sum(nil)
+}
+
+// pageOf cuts a page out of a list of n rows sorted in the direction of the cursor,
+// which has been read with one row more than the limit, to know whether there are more rows.
+// It returns how many rows belong to the page and the cursors around it.
+// The cursor function returns a cursor at the row with the given index.
+func pageOf(n int, page Page, cursor func(i int, before bool) *Cursor) (int, PageResult) {
+	result := PageResult{}
+	more := page.Limit > 0 && n > page.Limit
+	if more {
+		n = page.Limit
+	}
+	if n == 0 {
+		return 0, result
+	}
+
+	backwards := page.Cursor != nil && page.Cursor.Before
+	first, last := 0, n-1
+	if backwards {
+		first, last = n-1, 0
+	}
+	if more || (page.Cursor != nil && backwards) {
+		result.Next = cursor(last, false)
+	}
+	if (more && backwards) || (page.Cursor != nil && !backwards) {
+		result.Prev = cursor(first, true)
+	}
+	return n, result
+}
+
+// pageRows returns the indexes of the n rows on the page, in order.
+// Rows the database has read for the page are already sorted in the direction of the cursor, with one row more than the limit.
+// Otherwise all rows are sorted by the sort keys and ids here, and the page is cut out of them.
+func pageRows(n int, values func(i int) ([]interface{}, int), list string, keys SortKeys, fields map[string]sortField, page Page, inSQL bool) ([]int, PageResult) {
+	compare := func(v1 []interface{}, id1 int, v2 []interface{}, id2 int) int {
+		for k, key := range keys {
+			if c := compareSortValues(fields[key.Field], key.Desc, v1[k], v2[k]); c != 0 {
+				return c
+			}
+		}
+		return id1 - id2
+	}
+	backwards := page.Cursor != nil && page.Cursor.Before
+
+	indexes := []int{}
+	for i := 0; i < n; i++ {
+		indexes = append(indexes, i)
+	}
+	if !inSQL {
+		sort.SliceStable(indexes, func(a, b int) bool {
+			va, ida := values(indexes[a])
+			vb, idb := values(indexes[b])
+			if backwards {
+				return compare(va, ida, vb, idb) > 0
+			}
+			return compare(va, ida, vb, idb) < 0
+		})
+		if page.Cursor != nil {
+			rows := []int{}
+			for _, i := range indexes {
+				v, id := values(i)
+				c := compare(v, id, page.Cursor.Values, page.Cursor.Id)
+				if (!backwards && c > 0) || (backwards && c < 0) {
+					rows = append(rows, i)
+				}
+			}
+			indexes = rows
+		}
+		if page.Limit > 0 && len(indexes) > page.Limit+1 {
+			indexes = indexes[:page.Limit+1]
+		}
+	}
+
+	count, result := pageOf(len(indexes), page, func(i int, before bool) *Cursor {
+		v, id := values(indexes[i])
+		return &Cursor{list, keys.String(), v, id, before}
+	})
+	indexes = indexes[:count]
+	if backwards {
+		for i, j := 0, len(indexes)-1; i < j; i, j = i+1, j-1 {
+			indexes[i], indexes[j] = indexes[j], indexes[i]
+		}
+	}
+	return indexes, result
+}
@@ -0,0 +1,95 @@
+package main
+
+import "fmt"
+import "testing"
+
+func Test_paging_Cursor(t *testing.T) {
+	keys := SortKeys{{"priority", true}, {"text", false}}
+	cursor := &Cursor{"tasks", keys.String(), []interface{}{3, "Buy milk"}, 7, true}
+
+	value := EncodeCursor(cursor)
+	decoded, err := DecodeCursor(value, "tasks", keys)
+	if err != nil || fmt.Sprint(decoded) != fmt.Sprint(cursor) {
+		t.Errorf("DecodeCursor returned [%v], [%v] instead of [%v]", decoded, err, cursor)
+	}
+	if keys.String() != "-priority,text" {
+		t.Errorf("SortKeys.String() returned [%v]", keys.String())
+	}
+
+	// other lists, other sort keys, tampered or garbage cursors
+	tampered := EncodeCursor(&Cursor{"tasks", keys.String(), []interface{}{3, "Buy milk"}, 8, true})
+	tampered = value[:len(value)-43] + tampered[len(tampered)-43:]
+	var tests = []struct {
+		value string
+		list  string
+		keys  SortKeys
+	}{
+		{value, "accounts", keys},
+		{value, "tasks", SortKeys{{"priority", false}, {"text", false}}},
+		{value, "tasks", SortKeys{{"priority", true}}},
+		{tampered, "tasks", keys},
+		{"", "tasks", keys},
+		{"abc.def", "tasks", keys},
+		{value + "x", "tasks", keys},
+	}
+	for _, test := range tests {
+		if _, err := DecodeCursor(test.value, test.list, test.keys); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor of [%v] for [%v], [%v] should fail, but returned [%v]", test.value, test.list, test.keys, err)
+		}
+	}
+
+	// cursors signed with another secret are no longer valid
+	secret := cursorSecret
+	SetCursorSecret("another secret")
+	if _, err := DecodeCursor(value, "tasks", keys); err != ErrInvalidCursor {
+		t.Errorf("DecodeCursor with another secret returned [%v]", err)
+	}
+	cursorSecret = secret
+}
+
+func Test_paging_pageRows(t *testing.T) {
+	// priorities of the rows with ids 1 to 7
+	priorities := []int{3, 1, 3, 2, 3, 1, 2}
+	values := func(i int) ([]interface{}, int) {
+		return []interface{}{priorities[i]}, i + 1
+	}
+	keys := SortKeys{{"priority", true}}
+	ids := func(indexes []int) []int {
+		result := []int{}
+		for _, i := range indexes {
+			result = append(result, i+1)
+		}
+		return result
+	}
+
+	// all rows in descending priority and ascending ids
+	rows, result := pageRows(len(priorities), values, "tasks", keys, taskSortFields, Page{}, false)
+	if fmt.Sprint(ids(rows)) != "[1 3 5 4 7 2 6]" || result.Next != nil || result.Prev != nil {
+		t.Errorf("pageRows without page returned [%v], [%v]", ids(rows), result)
+	}
+
+	// forwards through pages of 3 rows
+	rows, result = pageRows(len(priorities), values, "tasks", keys, taskSortFields, Page{nil, 3}, false)
+	if fmt.Sprint(ids(rows)) != "[1 3 5]" || result.Next == nil || result.Prev != nil {
+		t.Fatalf("pageRows of the first page returned [%v], [%v]", ids(rows), result)
+	}
+	rows, result = pageRows(len(priorities), values, "tasks", keys, taskSortFields, Page{result.Next, 3}, false)
+	if fmt.Sprint(ids(rows)) != "[4 7 2]" || result.Next == nil || result.Prev == nil {
+		t.Fatalf("pageRows of the second page returned [%v], [%v]", ids(rows), result)
+	}
+	next, prev := result.Next, result.Prev
+	rows, result = pageRows(len(priorities), values, "tasks", keys, taskSortFields, Page{next, 3}, false)
+	if fmt.Sprint(ids(rows)) != "[6]" || result.Next != nil || result.Prev == nil {
+		t.Errorf("pageRows of the last page returned [%v], [%v]", ids(rows), result)
+	}
+
+	// and backwards again
+	rows, result = pageRows(len(priorities), values, "tasks", keys, taskSortFields, Page{prev, 3}, false)
+	if fmt.Sprint(ids(rows)) != "[1 3 5]" || result.Next == nil || result.Prev != nil {
+		t.Errorf("pageRows of the previous page returned [%v], [%v]", ids(rows), result)
+	}
+	rows, result = pageRows(len(priorities), values, "tasks", keys, taskSortFields, Page{&Cursor{"tasks", "-priority", []interface{}{1}, 6, true}, 2}, false)
+	if fmt.Sprint(ids(rows)) != "[7 2]" || result.Next == nil || result.Prev == nil {
+		t.Errorf("pageRows before the last row returned [%v], [%v]", ids(rows), result)
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import "errors"
+import "reflect"
+import "strconv"
+import "strings"
+import "encoding/json"
+
+// content types of the supported patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var ErrInvalidPatch = errors.New("Invalid patch")
+var ErrPatchTestFailed = errors.New("Patch test failed")
+
+// PatchOperation is a single operation of a JSON patch (RFC 6902).
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MergePatch applies a JSON merge patch (RFC 7396) to a JSON document.
+// Members of the patch replace those of the document, null removes them.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var d, p interface{}
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, ErrInvalidPatch
+	}
+	return json.Marshal(mergePatch(d, p))
+}
+
+func mergePatch(target interface{}, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+		} else {
+			t[key] = mergePatch(t[key], value)
+		}
+	}
+	return t
+}
+
+// JSONPatch applies a JSON patch (RFC 6902) to a JSON document.
+// The operations are applied in order, if any of them fails the whole patch fails.
+func JSONPatch(doc []byte, patch []byte) ([]byte, error) {
+	var d interface{}
+	if err := json.Unmarshal(doc, &d); err != nil {
+		return nil, err
+	}
+	var ops []PatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, ErrInvalidPatch
+	}
+
+	for _, op := range ops {
+		var err error
+		if d, err = op.apply(d); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(d)
+}
+
+func (op *PatchOperation) apply(doc interface{}) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		var value interface{}
+		if op.Value == nil || json.Unmarshal(op.Value, &value) != nil {
+			return nil, ErrInvalidPatch
+		}
+		if op.Op == "test" {
+			current, err := getPointer(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, ErrPatchTestFailed
+			}
+			return doc, nil
+		}
+		if op.Op == "replace" {
+			if doc, _, err = removePointer(doc, path); err != nil {
+				return nil, err
+			}
+		}
+		return addPointer(doc, path, value)
+	case "remove":
+		doc, _, err = removePointer(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if op.Op == "move" {
+			doc, value, err = removePointer(doc, from)
+		} else {
+			value, err = getPointer(doc, from)
+			value = copyValue(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addPointer(doc, path, value)
+	}
+	return nil, ErrInvalidPatch
+}
+
+// parsePointer splits a JSON pointer (RFC 6901) into its unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, ErrInvalidPatch
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// arrayIndex parses an array index, which must be within 0 and max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return -1, ErrInvalidPatch
+	}
+	return i, nil
+}
+
+func getPointer(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch c := doc.(type) {
+		case map[string]interface{}:
+			value, ok := c[token]
+			if !ok {
+				return nil, ErrInvalidPatch
+			}
+			doc = value
+		case []interface{}:
+			i, err := arrayIndex(token, len(c)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = c[i]
+		default:
+			return nil, ErrInvalidPatch
+		}
+	}
+	return doc, nil
+}
+
+// addPointer adds the value at the path and returns the changed document.
+func addPointer(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			c[token] = value
+			return c, nil
+		}
+		child, ok := c[token]
+		if !ok {
+			return nil, ErrInvalidPatch
+		}
+		child, err := addPointer(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c[token] = child
+		return c, nil
+	case []interface{}:
+		if len(path) == 1 {
+			if token == "-" {
+				return append(c, value), nil
+			}
+			i, err := arrayIndex(token, len(c))
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		i, err := arrayIndex(token, len(c)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := addPointer(c[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	}
+	return nil, ErrInvalidPatch
+}
+
+// removePointer removes the value at the path and returns the changed document together with the removed value.
+func removePointer(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, ErrInvalidPatch
+	}
+
+	token := path[0]
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		child, ok := c[token]
+		if !ok {
+			return nil, nil, ErrInvalidPatch
+		}
+		if len(path) == 1 {
+			delete(c, token)
+			return c, child, nil
+		}
+		child, removed, err := removePointer(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		c[token] = child
+		return c, removed, nil
+	case []interface{}:
+		i, err := arrayIndex(token, len(c)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := c[i]
+			return append(c[:i], c[i+1:]...), removed, nil
+		}
+		child, removed, err := removePointer(c[i], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		c[i] = child
+		return c, removed, nil
+	}
+	return nil, nil, ErrInvalidPatch
+}
+
+// copyValue deep copies a decoded JSON value, so that a copied value does not share maps or arrays with its origin.
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			c[key] = copyValue(value)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(v))
+		for i, value := range v {
+			c[i] = copyValue(value)
+		}
+		return c
+	}
+	return value
+}
@@ -0,0 +1,109 @@
+package main
+
+import "errors"
+import "strconv"
+import "strings"
+import "encoding/json"
+
+var ErrInvalidPriority = errors.New("Invalid priority")
+
+// Priority of a task, within the configured priority scale.
+// In JSON it can be given either as number or as name of a priority level.
+type Priority int
+
+type PriorityLevel struct {
+	Value int
+	Name  string
+	Color string
+}
+
+type PriorityScale struct {
+	Min    int
+	Max    int
+	Levels []PriorityLevel
+}
+
+var priorityScale = PriorityScale{1, 5, []PriorityLevel{
+	{1, "low", "#33ff33"},
+	{2, "minor", "#6666ff"},
+	{3, "normal", "#ffff00"},
+	{4, "high", "#ff9933"},
+	{5, "urgent", "#ff3333"},
+}}
+
+// SetPriorityScale replaces the default priority scale of 1 (low) to 5 (urgent).
+func SetPriorityScale(scale PriorityScale) error {
+	if scale.Min > scale.Max {
+		return errors.New("Invalid priority scale")
+	}
+	names := map[string]bool{}
+	for _, level := range scale.Levels {
+		name := strings.ToLower(level.Name)
+		if level.Value < scale.Min || level.Value > scale.Max || name == "" || names[name] {
+			return errors.New("Invalid priority level")
+		}
+		if _, err := strconv.Atoi(name); err == nil {
+			return errors.New("Invalid priority level")
+		}
+		names[name] = true
+	}
+	priorityScale = scale
+	return nil
+}
+
+func GetPriorityScale() PriorityScale {
+	return priorityScale
+}
+
+// ParsePriority validates a priority given either as number or as name of a priority level.
+func ParsePriority(value string) (Priority, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		for _, level := range priorityScale.Levels {
+			if strings.EqualFold(level.Name, strings.TrimSpace(value)) {
+				return Priority(level.Value), nil
+			}
+		}
+		return 0, ErrInvalidPriority
+	}
+	if !Priority(p).IsValid() {
+		return 0, ErrInvalidPriority
+	}
+	return Priority(p), nil
+}
+
+func (p Priority) IsValid() bool {
+	return int(p) >= priorityScale.Min && int(p) <= priorityScale.Max
+}
+
+// Normalize moves a priority outside of the priority scale to its nearest end.
+func (p Priority) Normalize() Priority {
+	if int(p) < priorityScale.Min {
+		return Priority(priorityScale.Min)
+	}
+	if int(p) > priorityScale.Max {
+		return Priority(priorityScale.Max)
+	}
+	return p
+}
+
+// UnmarshalJSON accepts names of priority levels besides numbers.
+// The range is not checked here, so that stored tasks can always be read.
+func (p *Priority) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		var value int
+		if err := json.Unmarshal(data, &value); err != nil {
+			return ErrInvalidPriority
+		}
+		*p = Priority(value)
+		return nil
+	}
+
+	priority, err := ParsePriority(name)
+	if err != nil {
+		return err
+	}
+	*p = priority
+	return nil
+}
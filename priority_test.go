@@ -0,0 +1,81 @@
+package main
+
+import "testing"
+import "encoding/json"
+
+func Test_priority_ParsePriority(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected Priority
+		err      error
+	}{
+		{"1", 1, nil},
+		{"5", 5, nil},
+		{" 3 ", 3, nil},
+		{"low", 1, nil},
+		{"Urgent", 5, nil},
+		{"0", 0, ErrInvalidPriority},
+		{"6", 0, ErrInvalidPriority},
+		{"9999", 0, ErrInvalidPriority},
+		{"-5", 0, ErrInvalidPriority},
+		{"extreme", 0, ErrInvalidPriority},
+		{"", 0, ErrInvalidPriority},
+	}
+
+	for _, test := range tests {
+		priority, err := ParsePriority(test.value)
+		if priority != test.expected || err != test.err {
+			t.Errorf("ParsePriority of [%v] returned [%v], [%v] instead of [%v], [%v]", test.value, priority, err, test.expected, test.err)
+		}
+	}
+}
+
+func Test_priority_Normalize(t *testing.T) {
+	if Priority(-5).Normalize() != 1 || Priority(9999).Normalize() != 5 || Priority(3).Normalize() != 3 {
+		t.Error("Normalize does not move priorities into the priority scale as expected")
+	}
+}
+
+func Test_priority_UnmarshalJSON(t *testing.T) {
+	var task Task
+	if err := json.Unmarshal([]byte(`{"Priority": "high"}`), &task); err != nil || task.Priority != 4 {
+		t.Errorf("Unmarshal of a priority name returned [%v], [%v]", task.Priority, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Priority": 7}`), &task); err != nil || task.Priority != 7 {
+		t.Errorf("Unmarshal of a priority number returned [%v], [%v]", task.Priority, err)
+	}
+	if err := json.Unmarshal([]byte(`{"Priority": "extreme"}`), &task); err == nil {
+		t.Error("Unmarshal of an unknown priority name should fail")
+	}
+	if err := json.Unmarshal([]byte(`{"Priority": true}`), &task); err == nil {
+		t.Error("Unmarshal of an invalid priority should fail")
+	}
+}
+
+func Test_priority_SetPriorityScale(t *testing.T) {
+	defaultScale := GetPriorityScale()
+	defer SetPriorityScale(defaultScale)
+
+	if err := SetPriorityScale(PriorityScale{5, 1, nil}); err == nil {
+		t.Error("SetPriorityScale should refuse a minimum above the maximum")
+	}
+	if err := SetPriorityScale(PriorityScale{1, 3, []PriorityLevel{{4, "critical", "#ff0000"}}}); err == nil {
+		t.Error("SetPriorityScale should refuse levels outside of the scale")
+	}
+	if err := SetPriorityScale(PriorityScale{1, 3, []PriorityLevel{{1, "low", ""}, {2, "Low", ""}}}); err == nil {
+		t.Error("SetPriorityScale should refuse duplicate names")
+	}
+	if err := SetPriorityScale(PriorityScale{1, 3, []PriorityLevel{{1, "2", ""}}}); err == nil {
+		t.Error("SetPriorityScale should refuse numeric names")
+	}
+
+	if err := SetPriorityScale(PriorityScale{0, 10, []PriorityLevel{{10, "critical", "#ff0000"}}}); err != nil {
+		t.Error(err)
+	}
+	if p, err := ParsePriority("critical"); p != 10 || err != nil {
+		t.Errorf("ParsePriority with a configured scale returned [%v], [%v]", p, err)
+	}
+	if p, err := ParsePriority("0"); p != 0 || err != nil {
+		t.Errorf("ParsePriority with a configured scale returned [%v], [%v]", p, err)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import "sort"
+import "errors"
+import "strings"
+
+var ErrInvalidProject = errors.New("Invalid project")
+
+type Project struct {
+	Id        int    `db:"ID"`
+	AccountId int    `db:"ACCOUNT_ID"`
+	Name      string `db:"NAME"`
+	SortBy    string `db:"SORT_BY"`
+	SortOrder string `db:"SORT_ORDER"`
+	Archived  int    `db:"ARCHIVED"`
+	TeamId    int    `db:"TEAM_ID"`
+}
+
+type Projects []Project
+
+type projectSort struct {
+	projects Projects
+	by       func(p1, p2 *Project) bool
+}
+
+func (p *projectSort) Len() int {
+	return len(p.projects)
+}
+
+func (p *projectSort) Swap(l, r int) {
+	p.projects[l], p.projects[r] = p.projects[r], p.projects[l]
+}
+
+func (p *projectSort) Less(l, r int) bool {
+	return p.by(&p.projects[l], &p.projects[r])
+}
+
+func (p Projects) sortBy(by func(p1, p2 *Project) bool) *Projects {
+	ps := &projectSort{
+		projects: p,
+		by:       by,
+	}
+	sort.Stable(ps)
+	return &p
+}
+
+func (p *Projects) SortByName(order string) *Projects {
+	p.sortBy(func(p1, p2 *Project) bool {
+		if order == "DESC" {
+			return strings.ToLower(p1.Name) > strings.ToLower(p2.Name)
+		}
+		return strings.ToLower(p1.Name) < strings.ToLower(p2.Name)
+	})
+	return p
+}
+
+func (p *Project) IsArchived() bool {
+	return p.Archived > 0
+}
+
+// SortTasks applies the projects default sort order to the given tasks.
+// Without a default sort order the tasks are left in the order returned by the db.
+func (p *Project) SortTasks(ts *Tasks) *Tasks {
+	if p.SortBy == "" {
+		return ts
+	}
+	return ts.SortByField(p.SortBy, p.SortOrder)
+}
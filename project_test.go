@@ -0,0 +1,92 @@
+package main
+
+import "testing"
+
+func Test_project_SortByName(t *testing.T) {
+	var ps1 = Projects{
+		Project{1, 1, "b", "", "ASC", 0, 0},
+		Project{2, 1, "C", "", "ASC", 0, 0},
+		Project{3, 2, "a", "", "ASC", 0, 0},
+	}
+
+	var ps2 = Projects{
+		Project{3, 2, "a", "", "ASC", 0, 0},
+		Project{1, 1, "b", "", "ASC", 0, 0},
+		Project{2, 1, "C", "", "ASC", 0, 0},
+	}
+
+	var ps3 = Projects{
+		Project{2, 1, "C", "", "ASC", 0, 0},
+		Project{1, 1, "b", "", "ASC", 0, 0},
+		Project{3, 2, "a", "", "ASC", 0, 0},
+	}
+
+	ps1.SortByName("ASC")
+	for i, p1 := range ps1 {
+		if p1 != ps2[i] {
+			t.Errorf("SortByName ASC is not as expected: [%v], instead of [%v]", ps1, ps2)
+			return
+		}
+	}
+
+	ps1.SortByName("DESC")
+	for i, p1 := range ps1 {
+		if p1 != ps3[i] {
+			t.Errorf("SortByName DESC is not as expected: [%v], instead of [%v]", ps1, ps3)
+			return
+		}
+	}
+
+	// projects of the same name keep their order
+	ps4 := Projects{}
+	for i := 1; i <= 20; i++ {
+		ps4 = append(ps4, Project{i, 1, []string{"b", "A"}[i%2], "", "ASC", 0, 0})
+	}
+	ps4.SortByName("ASC")
+	for i := 1; i < len(ps4); i++ {
+		if ps4[i-1].Name == ps4[i].Name && ps4[i-1].Id > ps4[i].Id {
+			t.Errorf("SortByName should keep the order of projects with the same name: [%v]", ps4)
+			return
+		}
+	}
+}
+
+func Test_project_SortTasks(t *testing.T) {
+	var ts1 = Tasks{
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", ProjectId: 1, Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", ProjectId: 1, Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", ProjectId: 1, Status: "Open", Version: 1},
+	}
+
+	var ts2 = Tasks{
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", ProjectId: 1, Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", ProjectId: 1, Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", ProjectId: 1, Status: "Open", Version: 1},
+	}
+
+	project := Project{1, 1, "Project", "", "ASC", 0, 0}
+	project.SortTasks(&ts1)
+	if ts1[0].Id != 1 || ts1[1].Id != 2 || ts1[2].Id != 3 {
+		t.Errorf("SortTasks without SortBy should not change order: [%v]", ts1)
+	}
+
+	project = Project{1, 1, "Project", "Created", "DESC", 0, 0}
+	project.SortTasks(&ts1)
+	for i, t1 := range ts1 {
+		if t1 != ts2[i] {
+			t.Errorf("SortTasks Created DESC is not as expected: [%v], instead of [%v]", ts1, ts2)
+			return
+		}
+	}
+}
+
+func Test_project_IsArchived(t *testing.T) {
+	project := Project{1, 1, "Project", "", "ASC", 0, 0}
+	if project.IsArchived() {
+		t.Errorf("Project should not be archived: [%v]", project)
+	}
+	project.Archived = 1234567890
+	if !project.IsArchived() {
+		t.Errorf("Project should be archived: [%v]", project)
+	}
+}
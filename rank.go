@@ -0,0 +1,44 @@
+package main
+
+// digits of rank keys, in ascending ASCII order so that ranks compare like plain strings
+const rankDigits = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// RankBetween returns a rank key that sorts between the ranks a and b. An empty a means
+// before all other ranks, and an empty b after all of them. Keys never end with the lowest digit,
+// so there is always room for another key in front of them.
+func RankBetween(a string, b string) string {
+	if b != "" && a >= b {
+		b = ""
+	}
+
+	rank := []byte{}
+	for i := 0; ; i++ {
+		low := 0
+		if i < len(a) {
+			low = rankDigit(a[i])
+		}
+		high := len(rankDigits)
+		if b != "" && i < len(b) { // b can only run out for keys ending with the lowest digit, which are never created
+			high = rankDigit(b[i])
+		}
+
+		if high-low > 1 {
+			return string(append(rank, rankDigits[(low+high)/2]))
+		}
+
+		// no room at this digit, so continue with the next one
+		rank = append(rank, rankDigits[low])
+		if low < high {
+			b = ""
+		}
+	}
+}
+
+func rankDigit(c byte) int {
+	for i := 0; i < len(rankDigits); i++ {
+		if rankDigits[i] == c {
+			return i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,44 @@
+package main
+
+import "testing"
+
+func Test_rank_RankBetween(t *testing.T) {
+	var tests = []struct {
+		a        string
+		b        string
+		expected string
+	}{
+		{"", "", "V"},
+		{"V", "", "k"},
+		{"", "V", "F"},
+		{"V", "W", "VV"},
+		{"V", "k", "c"},
+		{"", "1", "0V"},
+		{"y", "z", "yV"},
+		{"z", "", "zV"},
+		{"zz", "", "zzV"},
+		{"V", "VV", "VF"},
+		{"VV", "W", "Vk"},
+		{"k", "V", "s"}, // invalid bounds only respect a
+	}
+
+	for _, test := range tests {
+		rank := RankBetween(test.a, test.b)
+		if rank != test.expected {
+			t.Errorf("RankBetween of [%v] and [%v] returned [%v], instead of [%v]", test.a, test.b, rank, test.expected)
+		}
+		if rank <= test.a || (test.b > test.a && rank >= test.b) {
+			t.Errorf("RankBetween of [%v] and [%v] returned [%v], which is not in between", test.a, test.b, rank)
+		}
+	}
+
+	// many inserts at the same place still produce ordered keys
+	low, high := "V", "W"
+	for i := 0; i < 100; i++ {
+		rank := RankBetween(low, high)
+		if rank <= low || rank >= high {
+			t.Fatalf("RankBetween of [%v] and [%v] returned [%v]", low, high, rank)
+		}
+		high = rank
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "errors"
+
+// markers around the matching terms of a snippet
+const (
+	highlightStart = "<mark>"
+	highlightEnd   = "</mark>"
+)
+
+const maxSearchResults = 100
+
+var ErrInvalidSearch = errors.New("Invalid search query")
+var ErrSearchUnavailable = errors.New("Search is not available")
+
+// whether the full-text index could be set up, which needs SQLite with FTS5
+var searchAvailable = false
+
+// SearchResult is a task matching a search, with a snippet of its text that highlights the matching terms.
+type SearchResult struct {
+	Task    Task
+	Snippet string
+	Score   float64
+}
+
+type SearchResults []SearchResult
@@ -0,0 +1,57 @@
+package main
+
+import "errors"
+
+// permission levels of a share, each level includes the ones below it
+const (
+	PermissionNone = iota
+	PermissionView
+	PermissionEdit
+	PermissionManage
+)
+
+var permissionNames = []string{"None", "View", "Edit", "Manage"}
+
+// Share gives another account access to either a single task or a whole project.
+type Share struct {
+	Id         int    `db:"ID"`
+	TaskId     int    `db:"TASK_ID"`
+	ProjectId  int    `db:"PROJECT_ID"`
+	AccountId  int    `db:"ACCOUNT_ID"`
+	Permission string `db:"PERMISSION"`
+}
+
+type Shares []Share
+
+func ParsePermission(name string) (int, error) {
+	for level, permission := range permissionNames {
+		if level > PermissionNone && permission == name {
+			return level, nil
+		}
+	}
+	return PermissionNone, errors.New("Invalid permission")
+}
+
+func (s *Share) Level() int {
+	level, err := ParsePermission(s.Permission)
+	if err != nil {
+		return PermissionNone
+	}
+	return level
+}
+
+func maxPermission(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Level returns the highest permission level of all shares.
+func (s Shares) Level() int {
+	level := PermissionNone
+	for _, share := range s {
+		level = maxPermission(level, share.Level())
+	}
+	return level
+}
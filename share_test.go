@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func Test_share_ParsePermission(t *testing.T) {
+	for name, expected := range map[string]int{"View": PermissionView, "Edit": PermissionEdit, "Manage": PermissionManage} {
+		level, err := ParsePermission(name)
+		if err != nil {
+			t.Error(err)
+		}
+		if level != expected {
+			t.Errorf("ParsePermission(%v) returned [%v], expected [%v]", name, level, expected)
+		}
+	}
+
+	for _, name := range []string{"", "None", "view", "Admin"} {
+		if _, err := ParsePermission(name); err == nil {
+			t.Errorf("ParsePermission should not accept [%v]", name)
+		}
+	}
+}
+
+func Test_share_Level(t *testing.T) {
+	shares := Shares{
+		Share{1, 1, 0, 2, "View"},
+		Share{2, 0, 1, 2, "Edit"},
+		Share{3, 0, 2, 2, "Unknown"},
+	}
+	if shares[2].Level() != PermissionNone {
+		t.Errorf("Level of an unknown permission should be [%v], got [%v]", PermissionNone, shares[2].Level())
+	}
+	if shares.Level() != PermissionEdit {
+		t.Errorf("Level of shares should be [%v], got [%v]", PermissionEdit, shares.Level())
+	}
+	if (Shares{}).Level() != PermissionNone {
+		t.Errorf("Level of no shares should be [%v]", PermissionNone)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import "errors"
+import "strings"
+
+// SortKey is one key of a multi-key sort order, as in "?sort=-priority,created".
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+type SortKeys []SortKey
+
+// sortField tells how a field of the sort parameter is sorted:
+// by the Go sorter of that name, and by the SQL column if the database sorts it the same way.
+// Fold and EmptyLast describe the order of the Go sorter, being case insensitive and putting empty values last in both directions.
+type sortField struct {
+	Sorter    string
+	Column    string
+	Fold      bool
+	EmptyLast bool
+}
+
+func (k SortKey) Order() string {
+	if k.Desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// compareSortValues compares two values of a sort key the way its Go sorter orders them, negative if a comes first.
+// Values are either ints or strings.
+func compareSortValues(field sortField, desc bool, a interface{}, b interface{}) int {
+	c := 0
+	switch x := a.(type) {
+	case int:
+		y, _ := b.(int)
+		if x < y {
+			c = -1
+		} else if x > y {
+			c = 1
+		}
+	case string:
+		y, _ := b.(string)
+		if field.EmptyLast && (x == "") != (y == "") {
+			if x == "" {
+				return 1
+			}
+			return -1
+		}
+		if field.Fold {
+			x, y = strings.ToLower(x), strings.ToLower(y)
+		}
+		c = strings.Compare(x, y)
+	}
+	if desc {
+		return -c
+	}
+	return c
+}
+
+// ParseSortKeys parses a comma separated list of field names, each of them optionally prefixed by "-" for descending order.
+func ParseSortKeys(value string, fields map[string]sortField) (SortKeys, error) {
+	keys := SortKeys{}
+	if value == "" {
+		return keys, nil
+	}
+
+	seen := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		key := SortKey{}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if strings.HasPrefix(name, "-") {
+			key.Desc = true
+			name = name[1:]
+		} else if strings.HasPrefix(name, "+") {
+			name = name[1:]
+		}
+		if _, ok := fields[name]; !ok || seen[name] {
+			return nil, errors.New("Invalid sort")
+		}
+		seen[name] = true
+		key.Field = name
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// InSQL tells whether the database can sort by all keys.
+func (k SortKeys) InSQL(fields map[string]sortField) bool {
+	for _, key := range k {
+		if fields[key.Field].Column == "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+package main
+
+import "fmt"
+import "testing"
+
+func Test_sortkeys_ParseSortKeys(t *testing.T) {
+	var tests = []struct {
+		value    string
+		expected SortKeys
+	}{
+		{"", SortKeys{}},
+		{"priority", SortKeys{{"priority", false}}},
+		{"-priority,created", SortKeys{{"priority", true}, {"created", false}}},
+		{" +Text , -RANK ", SortKeys{{"text", false}, {"rank", true}}},
+	}
+	for _, test := range tests {
+		keys, err := ParseSortKeys(test.value, taskSortFields)
+		if err != nil || fmt.Sprint(keys) != fmt.Sprint(test.expected) {
+			t.Errorf("ParseSortKeys of [%v] returned [%v], [%v] instead of [%v]", test.value, keys, err, test.expected)
+		}
+	}
+
+	for _, value := range []string{"secret", "priority,", "-", "priority,-priority", "name"} {
+		if _, err := ParseSortKeys(value, taskSortFields); err == nil {
+			t.Errorf("ParseSortKeys of [%v] should fail", value)
+		}
+	}
+}
+
+func Test_sortkeys_compileOrder(t *testing.T) {
+	var tests = []struct {
+		keys     SortKeys
+		expected string
+	}{
+		{SortKeys{}, "DEFAULT"},
+		{SortKeys{{"priority", true}, {"created", false}}, "T.PRIORITY desc, T.CREATED asc, T.ID asc"},
+		{SortKeys{{"priority", true}, {"text", false}}, "DEFAULT"},
+	}
+	for _, test := range tests {
+		if order := compileOrder(test.keys, taskSortFields, "DEFAULT", "T.ID"); order != test.expected {
+			t.Errorf("compileOrder of [%v] returned [%v], instead of [%v]", test.keys, order, test.expected)
+		}
+	}
+	if !(SortKeys{{"id", true}}).InSQL(accountSortFields) || (SortKeys{{"id", true}, {"name", false}}).InSQL(accountSortFields) {
+		t.Error("InSQL does not tell which keys the database can sort by")
+	}
+}
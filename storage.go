@@ -2,18 +2,25 @@ package main
 
 import "os"
 import "log"
+import "strings"
+import "strconv"
+import "time"
+import "errors"
 import "database/sql"
+import "encoding/json"
 import _ "github.com/mattn/go-sqlite3"
 
 var sqlAccounts = `
-	create table T_ACCOUNTS (
+	create table if not exists T_ACCOUNTS (
 		ID integer not null primary key, 
 		NAME text not null, 
 		EMAIL text not null,
 		PASSWORD text not null,
 		SALT text not null,
 		ROLE text not null,
-		LAST_AUTH integer not null
+		LAST_AUTH integer not null,
+		DELETED integer not null default 0,
+		VERSION integer not null default 1
 	);
 	`
 
@@ -22,43 +29,399 @@ var sqlAccountIndex = `
 	`
 
 var sqlTasks = `
-	create table T_TASKS (
+	create table if not exists T_TASKS (
 		ID integer not null primary key, 
 		ACCOUNT_ID integer not null,   
 		CREATED integer not null, 
 		LAST_UPDATED integer not null, 
 		PRIORITY integer not null, 
 		TASK text not null,
+		PROJECT_ID integer not null default 0,
+		ASSIGNEE_ID integer not null default 0,
+		STATUS text not null default 'Open',
+		DELETED integer not null default 0,
+		VERSION integer not null default 1,
+		RANK text not null default '',
 		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
 	);
 	`
+
+var sqlProjects = `
+	create table if not exists T_PROJECTS (
+		ID integer not null primary key, 
+		ACCOUNT_ID integer not null, 
+		NAME text not null, 
+		SORT_BY text not null, 
+		SORT_ORDER text not null, 
+		ARCHIVED integer not null, 
+		TEAM_ID integer not null default 0, 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlViews = `
+	create table if not exists T_VIEWS (
+		ID integer not null primary key, 
+		ACCOUNT_ID integer not null, 
+		NAME text not null, 
+		FILTER text not null, 
+		SORT text not null, 
+		SHARED integer not null default 0, 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlTaskImports = `
+	create table if not exists T_TASK_IMPORTS (
+		ACCOUNT_ID integer not null, 
+		EXTERNAL_ID text not null, 
+		TASK_ID integer not null, 
+		primary key(ACCOUNT_ID, EXTERNAL_ID), 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID), 
+		foreign key(TASK_ID) references T_TASKS(ID)
+	);
+	`
+
+var sqlFeedTokens = `
+	create table if not exists T_FEED_TOKENS (
+		ACCOUNT_ID integer not null primary key, 
+		TOKEN_HASH text not null unique, 
+		CREATED integer not null, 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlTags = `
+	create table if not exists T_TAGS (
+		ID integer not null primary key, 
+		ACCOUNT_ID integer not null, 
+		NAME text not null collate nocase, 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlTagIndex = `
+	create unique index if not exists IDX_TAG_NAME ON T_TAGS (ACCOUNT_ID, NAME);
+	`
+
+var sqlTaskTags = `
+	create table if not exists T_TASK_TAGS (
+		TASK_ID integer not null, 
+		TAG_ID integer not null, 
+		primary key(TASK_ID, TAG_ID), 
+		foreign key(TASK_ID) references T_TASKS(ID), 
+		foreign key(TAG_ID) references T_TAGS(ID)
+	);
+	`
+
+var sqlShares = `
+	create table if not exists T_SHARES (
+		ID integer not null primary key, 
+		TASK_ID integer not null, 
+		PROJECT_ID integer not null, 
+		ACCOUNT_ID integer not null, 
+		PERMISSION text not null, 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlShareIndex = `
+	create unique index if not exists IDX_SHARE ON T_SHARES (TASK_ID, PROJECT_ID, ACCOUNT_ID);
+	`
+
+var sqlTeams = `
+	create table if not exists T_TEAMS (
+		ID integer not null primary key, 
+		NAME text not null
+	);
+	`
+
+var sqlTeamMembers = `
+	create table if not exists T_TEAM_MEMBERS (
+		TEAM_ID integer not null, 
+		ACCOUNT_ID integer not null, 
+		ROLE text not null, 
+		primary key(TEAM_ID, ACCOUNT_ID), 
+		foreign key(TEAM_ID) references T_TEAMS(ID), 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlAssignments = `
+	create table if not exists T_ASSIGNMENTS (
+		ID integer not null primary key, 
+		TASK_ID integer not null, 
+		ASSIGNEE_ID integer not null, 
+		ASSIGNED_BY integer not null, 
+		ASSIGNED integer not null, 
+		foreign key(TASK_ID) references T_TASKS(ID), 
+		foreign key(ASSIGNED_BY) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlComments = `
+	create table if not exists T_COMMENTS (
+		ID integer not null primary key, 
+		TASK_ID integer not null, 
+		ACCOUNT_ID integer not null, 
+		CREATED integer not null, 
+		LAST_UPDATED integer not null, 
+		BODY text not null, 
+		foreign key(TASK_ID) references T_TASKS(ID), 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlCommentMentions = `
+	create table if not exists T_COMMENT_MENTIONS (
+		COMMENT_ID integer not null, 
+		ACCOUNT_ID integer not null, 
+		primary key(COMMENT_ID, ACCOUNT_ID), 
+		foreign key(COMMENT_ID) references T_COMMENTS(ID), 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlAttachments = `
+	create table if not exists T_ATTACHMENTS (
+		ID integer not null primary key, 
+		TASK_ID integer not null, 
+		ACCOUNT_ID integer not null, 
+		NAME text not null, 
+		CONTENT_TYPE text not null, 
+		SIZE integer not null, 
+		CREATED integer not null, 
+		BLOB_KEY text not null, 
+		foreign key(TASK_ID) references T_TASKS(ID), 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+var sqlHistory = `
+	create table if not exists T_HISTORY (
+		ID integer not null primary key, 
+		ENTITY text not null, 
+		ENTITY_ID integer not null, 
+		ACTION text not null, 
+		ACCOUNT_ID integer not null, 
+		CHANGED integer not null, 
+		FIELD text not null, 
+		OLD_VALUE text not null, 
+		NEW_VALUE text not null
+	);
+	`
+
+var sqlHistoryIndex = `
+	create index if not exists IDX_HISTORY_ENTITY ON T_HISTORY (ENTITY, ENTITY_ID);
+	`
+
+var sqlUndo = `
+	create table if not exists T_UNDO (
+		ID integer not null primary key, 
+		ACCOUNT_ID integer not null, 
+		CREATED integer not null, 
+		CHANGES text not null, 
+		foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID)
+	);
+	`
+
+// settings of the data itself, like the priority scale its tasks were last brought into.
+var sqlSettings = `
+	create table if not exists T_SETTINGS (
+		NAME text not null primary key, 
+		VALUE text not null
+	);
+	`
+
+// the full-text index only holds the task text, keyed by the task id.
+// It keeps its own copy of the text, so that "insert or replace" on T_TASKS cannot leave stale entries behind.
+var sqlTaskSearch = `
+	create virtual table if not exists T_TASK_SEARCH using fts5(TASK);
+	`
+
+var sqlTaskSearchTriggers = `
+	create trigger if not exists TRG_TASK_SEARCH_INSERT after insert on T_TASKS begin
+		delete from T_TASK_SEARCH where rowid = new.ID;
+		insert into T_TASK_SEARCH (rowid, TASK) values (new.ID, new.TASK);
+	end;
+	create trigger if not exists TRG_TASK_SEARCH_UPDATE after update of TASK on T_TASKS begin
+		update T_TASK_SEARCH set TASK = new.TASK where rowid = new.ID;
+	end;
+	create trigger if not exists TRG_TASK_SEARCH_DELETE after delete on T_TASKS begin
+		delete from T_TASK_SEARCH where rowid = old.ID;
+	end;
+	`
+
+// sqlSchema creates all tables and indexes that do not exist yet, tables before the indexes on them.
+var sqlSchema = []string{
+	sqlAccounts,
+	sqlAccountIndex,
+	sqlTasks,
+	sqlProjects,
+	sqlViews,
+	sqlTaskImports,
+	sqlFeedTokens,
+	sqlTags,
+	sqlTagIndex,
+	sqlTaskTags,
+	sqlShares,
+	sqlShareIndex,
+	sqlTeams,
+	sqlTeamMembers,
+	sqlAssignments,
+	sqlComments,
+	sqlCommentMentions,
+	sqlAttachments,
+	sqlHistory,
+	sqlHistoryIndex,
+	sqlUndo,
+	sqlSettings,
+}
+
+// sqlColumns are the columns added to tables after they had been created by an earlier version.
+// Rows are scanned in the order of their columns, so these are in the same order as in the create statements.
+var sqlColumns = []struct {
+	Table      string
+	Column     string
+	Definition string
+}{
+	{"T_ACCOUNTS", "DELETED", "integer not null default 0"},
+	{"T_ACCOUNTS", "VERSION", "integer not null default 1"},
+	{"T_TASKS", "PROJECT_ID", "integer not null default 0"},
+	{"T_TASKS", "ASSIGNEE_ID", "integer not null default 0"},
+	{"T_TASKS", "STATUS", "text not null default 'Open'"},
+	{"T_TASKS", "DELETED", "integer not null default 0"},
+	{"T_TASKS", "VERSION", "integer not null default 1"},
+	{"T_TASKS", "RANK", "text not null default ''"},
+	{"T_PROJECTS", "TEAM_ID", "integer not null default 0"},
+}
+
+// a list of tasks is either a project, or all tasks of an account without a project.
+// The parameters are the project id twice, followed by the account id.
+const taskListWhere = "DELETED = 0 and PROJECT_ID = ? and (? > 0 or ACCOUNT_ID = ?)"
+
+// returned if an entity is only to be changed in a given version, but its stored version differs
+var ErrVersionMismatch = errors.New("Precondition Failed")
+
 var database = "./data/tasks.db"
 
 func connect() (*sql.DB, error) {
 	return sql.Open("sqlite3", database)
 }
 
+// transaction runs fn within a single transaction, which is committed if fn succeeds and rolled back otherwise.
+// Mutations write their history within the same transaction, so that either all of it is stored or nothing.
+func transaction(fn func(tx *sql.Tx) error) error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// placeholders returns a list of n bind parameters, to be used within "in (...)"
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
 func SetDatabase(db string) {
 	database = db
 }
 
 func SetupDatabase() {
 	os.Remove(database)
+	if err := MigrateDatabase(); err != nil {
+		log.Fatal(err)
+	}
+
+	// full-text search needs SQLite to be built with FTS5, everything else works without it
+	if err := SetupSearchIndex(); err != nil {
+		log.Printf("Full-text search is not available: [%v]", err)
+	}
+}
+
+// MigrateDatabase brings a database of an earlier version up to date, and creates a new database from scratch.
+// Missing tables and indexes are created and missing columns are added with their defaults, everything else is kept,
+// so it is safe to run on every start.
+func MigrateDatabase() error {
 	db, err := connect()
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 	defer db.Close()
 
-	if _, err := db.Exec(sqlAccounts); err != nil {
-		log.Fatal(err)
+	tx, err := db.Begin()
+	if err != nil {
+		return err
 	}
-	if _, err := db.Exec(sqlAccountIndex); err != nil {
-		log.Fatal(err)
+
+	for _, query := range sqlSchema {
+		if _, err := tx.Exec(query); err != nil {
+			tx.Rollback()
+			return err
+		}
 	}
-	if _, err := db.Exec(sqlTasks); err != nil {
-		log.Fatal(err)
+
+	for _, c := range sqlColumns {
+		var n int
+		if err := tx.QueryRow("select count(*) from pragma_table_info(?) where NAME = ?", c.Table, c.Column).Scan(&n); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+		if _, err := tx.Exec("alter table " + c.Table + " add column " + c.Column + " " + c.Definition); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetupSearchIndex creates the full-text index of the tasks if necessary, and rebuilds it from all existing tasks.
+func SetupSearchIndex() error {
+	searchAvailable = false
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, query := range []string{
+		sqlTaskSearch,
+		sqlTaskSearchTriggers,
+		"delete from T_TASK_SEARCH",
+		"insert into T_TASK_SEARCH (rowid, TASK) select ID, TASK from T_TASKS",
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
+
+	searchAvailable = true
+	return nil
 }
 
 func SetupAdmin() (Account, string) {
@@ -67,7 +430,7 @@ func SetupAdmin() (Account, string) {
 		log.Fatal(err)
 	}
 	password := "password"
-	a := Account{-1, "Admin", "admin@admin", HashPassword(*salt, password), *salt, "Admin", 0}
+	a := Account{-1, "Admin", "admin@admin", HashPassword(*salt, password), *salt, "Admin", 0, 0, 0}
 	if err := a.Save(); err != nil {
 		log.Fatal(err)
 	}
@@ -76,10 +439,10 @@ func SetupAdmin() (Account, string) {
 
 func SetupSampleTasks() {
 	tasks := Tasks{
-		{-1, 1, 1234567890, 1234567895, 3, "Buy food!"},
-		{-1, 1, 1234567891, 1234567895, 1, "Get some sleep..."},
-		{-1, 1, 1234567892, 1234567895, 4, "Buy xmas presents!"},
-		{-1, 1, 1234567893, 1234567895, 3, "Buy water!"},
+		{-1, 1, 1234567890, 1234567895, 3, "Buy food!", 0, 0, "Open", 0, 0, ""},
+		{-1, 1, 1234567891, 1234567895, 1, "Get some sleep...", 0, 0, "Open", 0, 0, ""},
+		{-1, 1, 1234567892, 1234567895, 4, "Buy xmas presents!", 0, 0, "Open", 0, 0, ""},
+		{-1, 1, 1234567893, 1234567895, 3, "Buy water!", 0, 0, "Open", 0, 0, ""},
 	}
 	if err := tasks.Save(); err != nil {
 		log.Fatal(err)
@@ -90,7 +453,7 @@ func scanTasks(rows *sql.Rows) (*Tasks, error) {
 	ts := Tasks{}
 	for rows.Next() {
 		var t Task
-		if err := rows.Scan(&t.Id, &t.AccountId, &t.Created, &t.LastUpdated, &t.Priority, &t.Task); err != nil {
+		if err := rows.Scan(&t.Id, &t.AccountId, &t.Created, &t.LastUpdated, &t.Priority, &t.Task, &t.ProjectId, &t.AssigneeId, &t.Status, &t.Deleted, &t.Version, &t.Rank); err != nil {
 			return nil, err
 		}
 		ts = append(ts, t)
@@ -102,7 +465,116 @@ func scanAccounts(rows *sql.Rows) (*Accounts, error) {
 	as := Accounts{}
 	for rows.Next() {
 		var a Account
-		if err := rows.Scan(&a.Id, &a.Name, &a.Email, &a.Password, &a.Salt, &a.Role, &a.LastAuth); err != nil {
+		if err := rows.Scan(&a.Id, &a.Name, &a.Email, &a.Password, &a.Salt, &a.Role, &a.LastAuth, &a.Deleted, &a.Version); err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	return &as, nil
+}
+
+func scanProjects(rows *sql.Rows) (*Projects, error) {
+	ps := Projects{}
+	for rows.Next() {
+		var p Project
+		if err := rows.Scan(&p.Id, &p.AccountId, &p.Name, &p.SortBy, &p.SortOrder, &p.Archived, &p.TeamId); err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return &ps, nil
+}
+
+func scanViews(rows *sql.Rows) (*Views, error) {
+	vs := Views{}
+	for rows.Next() {
+		var v View
+		if err := rows.Scan(&v.Id, &v.AccountId, &v.Name, &v.Filter, &v.Sort, &v.Shared); err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+	}
+	return &vs, nil
+}
+
+func scanTags(rows *sql.Rows) (*Tags, error) {
+	ts := Tags{}
+	for rows.Next() {
+		var t Tag
+		if err := rows.Scan(&t.Id, &t.AccountId, &t.Name); err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return &ts, nil
+}
+
+func scanShares(rows *sql.Rows) (*Shares, error) {
+	ss := Shares{}
+	for rows.Next() {
+		var s Share
+		if err := rows.Scan(&s.Id, &s.TaskId, &s.ProjectId, &s.AccountId, &s.Permission); err != nil {
+			return nil, err
+		}
+		ss = append(ss, s)
+	}
+	return &ss, nil
+}
+
+func scanTeams(rows *sql.Rows) (*Teams, error) {
+	ts := Teams{}
+	for rows.Next() {
+		var t Team
+		if err := rows.Scan(&t.Id, &t.Name); err != nil {
+			return nil, err
+		}
+		ts = append(ts, t)
+	}
+	return &ts, nil
+}
+
+func scanMembers(rows *sql.Rows) (*Members, error) {
+	ms := Members{}
+	for rows.Next() {
+		var m Member
+		if err := rows.Scan(&m.TeamId, &m.AccountId, &m.Role); err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return &ms, nil
+}
+
+func scanAssignments(rows *sql.Rows) (*Assignments, error) {
+	as := Assignments{}
+	for rows.Next() {
+		var a Assignment
+		if err := rows.Scan(&a.Id, &a.TaskId, &a.AssigneeId, &a.AssignedBy, &a.Assigned); err != nil {
+			return nil, err
+		}
+		as = append(as, a)
+	}
+	return &as, nil
+}
+
+func scanComments(rows *sql.Rows) (*Comments, error) {
+	cs := Comments{}
+	for rows.Next() {
+		var c Comment
+		if err := rows.Scan(&c.Id, &c.TaskId, &c.AccountId, &c.Created, &c.LastUpdated, &c.Body); err != nil {
+			return nil, err
+		}
+		c.Mentions = []int{}
+		cs = append(cs, c)
+	}
+	return &cs, nil
+}
+
+func scanAttachments(rows *sql.Rows) (*Attachments, error) {
+	as := Attachments{}
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.Id, &a.TaskId, &a.AccountId, &a.Name, &a.ContentType, &a.Size, &a.Created, &a.BlobKey); err != nil {
 			return nil, err
 		}
 		as = append(as, a)
@@ -110,139 +582,2696 @@ func scanAccounts(rows *sql.Rows) (*Accounts, error) {
 	return &as, nil
 }
 
-func GetAllTasks() (*Tasks, error) {
-	db, err := connect()
+func scanChanges(rows *sql.Rows) (*Changes, error) {
+	cs := Changes{}
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.Id, &c.Entity, &c.EntityId, &c.Action, &c.AccountId, &c.Changed, &c.Field, &c.OldValue, &c.NewValue); err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+	}
+	return &cs, nil
+}
+
+func scanUndoSteps(rows *sql.Rows) (*UndoSteps, error) {
+	us := UndoSteps{}
+	for rows.Next() {
+		var u UndoStep
+		var changes string
+		if err := rows.Scan(&u.Id, &u.AccountId, &u.Created, &changes); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(changes), &u.Changes); err != nil {
+			return nil, err
+		}
+		us = append(us, u)
+	}
+	return &us, nil
+}
+
+func GetAllTasks() (*Tasks, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select * from T_TASKS where DELETED = 0 order by PRIORITY desc, LAST_UPDATED asc, CREATED asc")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+const allTasksFrom = "from T_TASKS T where T.DELETED = 0 "
+
+// GetAllTasksByFilter returns the tasks of all accounts matching the filter, a nil filter matches all tasks.
+// They are sorted by the keys if the database can do so, see compileOrder, and only the rows of the page are read, see compilePage.
+func GetAllTasksByFilter(filter FilterNode, keys SortKeys, page Page) (*Tasks, error) {
+	return queryFilteredTasks(allTasksFrom, nil, filter, keys, page)
+}
+
+// CountAllTasksByFilter counts the tasks of all accounts matching the filter.
+func CountAllTasksByFilter(filter FilterNode) (int, error) {
+	return countFilteredTasks(allTasksFrom, nil, filter)
+}
+
+// CountTasksPerAccount counts the tasks matching the filter for every account owning any of them, ordered by account id.
+func CountTasksPerAccount(filter FilterNode) (*AccountTaskCounts, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	where, args := compileFilter(filter)
+	rows, err := db.Query(`select T.ACCOUNT_ID, coalesce(A.NAME, ''), count(*) 
+		from T_TASKS T left join T_ACCOUNTS A on A.ID = T.ACCOUNT_ID 
+		where T.DELETED = 0 and (`+where+`) 
+		group by T.ACCOUNT_ID order by T.ACCOUNT_ID asc`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := AccountTaskCounts{}
+	for rows.Next() {
+		var c AccountTaskCount
+		if err := rows.Scan(&c.AccountId, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return &counts, nil
+}
+
+// StreamTasksByAccountId calls fn for every task of the account, in the order of their ids.
+// The tasks are read one by one, so that exports do not need to hold all of them in memory.
+func StreamTasksByAccountId(id int, fn func(t *Task) error) error {
+	return streamTasks("select * from T_TASKS where ACCOUNT_ID = ? and DELETED = 0 order by ID asc", fn, id)
+}
+
+// StreamAllTasks calls fn for every task of all accounts, in the order of their ids.
+func StreamAllTasks(fn func(t *Task) error) error {
+	return streamTasks("select * from T_TASKS where DELETED = 0 order by ID asc", fn)
+}
+
+// streamTasks stops at the first error returned by fn, and returns it.
+func streamTasks(query string, fn func(t *Task) error, args ...interface{}) error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t Task
+		if err := rows.Scan(&t.Id, &t.AccountId, &t.Created, &t.LastUpdated, &t.Priority, &t.Task, &t.ProjectId, &t.AssigneeId, &t.Status, &t.Deleted, &t.Version, &t.Rank); err != nil {
+			return err
+		}
+		if err := fn(&t); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// queryTask returns the first task found by the query.
+func queryTask(query string, id int) (*Task, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var t Task
+	if err := stmt.QueryRow(id).Scan(&t.Id, &t.AccountId, &t.Created, &t.LastUpdated, &t.Priority, &t.Task, &t.ProjectId, &t.AssigneeId, &t.Status, &t.Deleted, &t.Version, &t.Rank); err != nil {
+		return nil, err
+	} else {
+		return &t, nil
+	}
+}
+
+// GetTaskById returns the task, unless it is in the trash.
+func GetTaskById(id int) (*Task, error) {
+	return queryTask("select * from T_TASKS where ID = ? and DELETED = 0", id)
+}
+
+// GetTaskList returns all tasks in the same list as the given task, in manual order.
+func GetTaskList(t *Task) (*Tasks, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// tasks that have never been ranked come last, oldest first
+	rows, err := db.Query("select * from T_TASKS where "+taskListWhere+" order by ID", t.ProjectId, t.ProjectId, t.AccountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.SortByRank("ASC"), nil
+}
+
+// GetTrashedTaskById only returns the task if it is in the trash.
+func GetTrashedTaskById(id int) (*Task, error) {
+	return queryTask("select * from T_TASKS where ID = ? and DELETED > 0", id)
+}
+
+func GetTasksByAccountId(id int) (*Tasks, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// tasks of archived projects are not part of the accounts task list anymore
+	stmt, err := db.Prepare(`
+		select T.* from T_TASKS T 
+		left join T_PROJECTS P on P.ID = T.PROJECT_ID 
+		where T.ACCOUNT_ID = ? and T.DELETED = 0 and (P.ARCHIVED is null or P.ARCHIVED = 0) 
+		order by T.PRIORITY desc, T.LAST_UPDATED asc, T.CREATED asc`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+// GetVisibleTasksByAccountId returns the accounts own tasks, together with all tasks
+// that have been shared with it directly or through a project, belong to a project of one of its teams, or are assigned to it.
+func GetVisibleTasksByAccountId(id int) (*Tasks, error) {
+	return GetVisibleTasksByAccountIdAndFilter(id, nil, nil, Page{})
+}
+
+// tasks of archived projects are not part of the accounts task list anymore
+const visibleTasksFrom = `
+	from T_TASKS T 
+	left join T_PROJECTS P on P.ID = T.PROJECT_ID 
+	where (T.ACCOUNT_ID = ? 
+		or T.ASSIGNEE_ID = ? 
+		or T.ID in (select TASK_ID from T_SHARES where ACCOUNT_ID = ? and TASK_ID > 0) 
+		or T.PROJECT_ID in (select PROJECT_ID from T_SHARES where ACCOUNT_ID = ? and PROJECT_ID > 0) 
+		or P.TEAM_ID in (select TEAM_ID from T_TEAM_MEMBERS where ACCOUNT_ID = ? and TEAM_ID > 0)) 
+	and T.DELETED = 0 and (P.ARCHIVED is null or P.ARCHIVED = 0) `
+
+// GetVisibleTasksByAccountIdAndFilter only returns the visible tasks matching the filter, a nil filter matches all tasks.
+// They are sorted by the keys if the database can do so, see compileOrder, and only the rows of the page are read, see compilePage.
+func GetVisibleTasksByAccountIdAndFilter(id int, filter FilterNode, keys SortKeys, page Page) (*Tasks, error) {
+	return queryFilteredTasks(visibleTasksFrom, []interface{}{id, id, id, id, id}, filter, keys, page)
+}
+
+// CountVisibleTasksByAccountIdAndFilter counts the visible tasks matching the filter.
+func CountVisibleTasksByAccountIdAndFilter(id int, filter FilterNode) (int, error) {
+	return countFilteredTasks(visibleTasksFrom, []interface{}{id, id, id, id, id}, filter)
+}
+
+func queryFilteredTasks(from string, args []interface{}, filter FilterNode, keys SortKeys, page Page) (*Tasks, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	where, filterArgs := compileFilter(filter)
+	pageWhere, pageArgs, order := compilePage(keys, taskSortFields, "T.PRIORITY desc, T.LAST_UPDATED asc, T.CREATED asc", "T.ID", page)
+	stmt, err := db.Prepare("select T.* " + from + " and (" + where + ") and " + pageWhere + " order by " + order)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(append(append(args, filterArgs...), pageArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func countFilteredTasks(from string, args []interface{}, filter FilterNode) (int, error) {
+	db, err := connect()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	where, filterArgs := compileFilter(filter)
+	var count int
+	if err := db.QueryRow("select count(*) "+from+" and ("+where+")", append(args, filterArgs...)...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// compileOrder turns sort keys into the terms of an order by clause, with the id column breaking ties.
+// Without keys, or if the database cannot sort by all of them, the default order is used,
+// and sorting is left to the Go sorters of the keys.
+func compileOrder(keys SortKeys, fields map[string]sortField, defaultOrder string, id string) string {
+	if len(keys) == 0 || !keys.InSQL(fields) {
+		return defaultOrder
+	}
+	terms := []string{}
+	for _, key := range keys {
+		terms = append(terms, fields[key.Field].Column+" "+strings.ToLower(key.Order()))
+	}
+	return strings.Join(append(terms, id+" asc"), ", ")
+}
+
+// compilePage turns the cursor of a page into a condition on the sort keys and the id column, and returns it with the order to read the page.
+// The rows are read in the direction of the cursor, with one row more than the limit to tell whether there are more rows.
+// Without a cursor and limit, or if the database cannot sort by all keys, all rows are read in the order of compileOrder.
+func compilePage(keys SortKeys, fields map[string]sortField, defaultOrder string, id string, page Page) (string, []interface{}, string) {
+	if (page.Cursor == nil && page.Limit == 0) || len(keys) == 0 || !keys.InSQL(fields) {
+		return "1 = 1", nil, compileOrder(keys, fields, defaultOrder, id)
+	}
+	backwards := page.Cursor != nil && page.Cursor.Before
+
+	// rows after (a, b, id) are those with a greater a, or an equal a and a greater b, or equal a and b and a greater id
+	where, args := "1 = 1", []interface{}{}
+	if page.Cursor != nil {
+		conditions := []string{}
+		equal, equalArgs := "", []interface{}{}
+		for i, key := range keys {
+			column := fields[key.Field].Column
+			op := ">"
+			if key.Desc != backwards {
+				op = "<"
+			}
+			conditions = append(conditions, "("+equal+column+" "+op+" ?)")
+			args = append(append(args, equalArgs...), page.Cursor.Values[i])
+			equal += column + " = ? and "
+			equalArgs = append(equalArgs, page.Cursor.Values[i])
+		}
+		op := ">"
+		if backwards {
+			op = "<"
+		}
+		conditions = append(conditions, "("+equal+id+" "+op+" ?)")
+		args = append(append(args, equalArgs...), page.Cursor.Id)
+		where = "(" + strings.Join(conditions, " or ") + ")"
+	}
+
+	terms := []string{}
+	for _, key := range keys {
+		order := "asc"
+		if key.Desc != backwards {
+			order = "desc"
+		}
+		terms = append(terms, fields[key.Field].Column+" "+order)
+	}
+	if backwards {
+		terms = append(terms, id+" desc")
+	} else {
+		terms = append(terms, id+" asc")
+	}
+	order := strings.Join(terms, ", ")
+	if page.Limit > 0 {
+		order += " limit " + strconv.Itoa(page.Limit+1)
+	}
+	return where, args, order
+}
+
+// compileFilter turns a filter into the condition of a where clause on T_TASKS T, and its parameters.
+// Only whitelisted column names and operators end up in the condition, all values are passed as parameters.
+func compileFilter(filter FilterNode) (string, []interface{}) {
+	switch n := filter.(type) {
+	case *FilterLogical:
+		left, leftArgs := compileFilter(n.Left)
+		right, rightArgs := compileFilter(n.Right)
+		op := "and"
+		if n.Op == "OR" {
+			op = "or"
+		}
+		return "(" + left + " " + op + " " + right + ")", append(leftArgs, rightArgs...)
+	case *FilterNot:
+		expr, args := compileFilter(n.Expr)
+		return "not " + expr, args
+	case *FilterComparison:
+		column := "T." + filterFields[n.Field].Column
+		switch n.Op {
+		case "~":
+			pattern := strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(n.Value.(string))
+			return column + " like ? escape '\\'", []interface{}{"%" + pattern + "%"}
+		case "=", "!=", "<", "<=", ">", ">=":
+			return column + " " + n.Op + " ?", []interface{}{n.Value}
+		}
+	}
+	return "1 = 1", nil
+}
+
+// SearchTasks returns the tasks visible to the account that match the full-text query, best matches first.
+// The query supports the FTS5 syntax of phrases ("buy food"), prefixes (buy*) and boolean operators (AND, OR, NOT).
+func SearchTasks(id int, query string, limit int) (*SearchResults, error) {
+	if !searchAvailable {
+		return nil, ErrSearchUnavailable
+	}
+
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// the same tasks as in GetVisibleTasksByAccountId
+	rows, err := db.Query(`
+		select T.*, snippet(T_TASK_SEARCH, 0, ?, ?, '...', 16), bm25(T_TASK_SEARCH) from T_TASK_SEARCH S 
+		join T_TASKS T on T.ID = S.rowid 
+		left join T_PROJECTS P on P.ID = T.PROJECT_ID 
+		where T_TASK_SEARCH match ? 
+		and (T.ACCOUNT_ID = ? 
+			or T.ASSIGNEE_ID = ? 
+			or T.ID in (select TASK_ID from T_SHARES where ACCOUNT_ID = ? and TASK_ID > 0) 
+			or T.PROJECT_ID in (select PROJECT_ID from T_SHARES where ACCOUNT_ID = ? and PROJECT_ID > 0) 
+			or P.TEAM_ID in (select TEAM_ID from T_TEAM_MEMBERS where ACCOUNT_ID = ? and TEAM_ID > 0)) 
+		and T.DELETED = 0 and (P.ARCHIVED is null or P.ARCHIVED = 0) 
+		order by bm25(T_TASK_SEARCH), T.ID 
+		limit ?`, highlightStart, highlightEnd, query, id, id, id, id, id, limit)
+	if err != nil {
+		return nil, searchError(err)
+	}
+	defer rows.Close()
+
+	rs := SearchResults{}
+	for rows.Next() {
+		var r SearchResult
+		t := &r.Task
+		if err := rows.Scan(&t.Id, &t.AccountId, &t.Created, &t.LastUpdated, &t.Priority, &t.Task, &t.ProjectId, &t.AssigneeId, &t.Status, &t.Deleted, &t.Version, &t.Rank, &r.Snippet, &r.Score); err != nil {
+			return nil, searchError(err)
+		}
+		// bm25 is lower for better matches, scores are easier to read the other way around
+		r.Score = -r.Score
+		rs = append(rs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, searchError(err)
+	}
+
+	return &rs, nil
+}
+
+// searchError tells syntax errors in the search query apart from other errors.
+func searchError(err error) error {
+	for _, prefix := range []string{"fts5:", "unterminated string", "no such column", "unknown special query"} {
+		if strings.HasPrefix(err.Error(), prefix) {
+			return ErrInvalidSearch
+		}
+	}
+	return err
+}
+
+func GetTasksByAssigneeId(id int) (*Tasks, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	// tasks of archived projects are not part of the assigned tasks either
+	stmt, err := db.Prepare(`
+		select T.* from T_TASKS T 
+		left join T_PROJECTS P on P.ID = T.PROJECT_ID 
+		where T.ASSIGNEE_ID = ? and T.DELETED = 0 and (P.ARCHIVED is null or P.ARCHIVED = 0) 
+		order by T.PRIORITY desc, T.LAST_UPDATED asc, T.CREATED asc`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+// GetTrashedTasksByAccountId returns all tasks of the account that are in the trash, most recently deleted first.
+func GetTrashedTasksByAccountId(id int) (*Tasks, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select * from T_TASKS where ACCOUNT_ID = ? and DELETED > 0 order by DELETED desc, ID asc", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func GetTasksByProjectId(id int) (*Tasks, error) {
+	return GetTasksByProjectIdAndFilter(id, nil, nil, Page{})
+}
+
+const projectTasksFrom = "from T_TASKS T where T.PROJECT_ID = ? and T.DELETED = 0 "
+
+// GetTasksByProjectIdAndFilter only returns the tasks of the project matching the filter, a nil filter matches all tasks.
+// They are sorted by the keys if the database can do so, see compileOrder, and only the rows of the page are read, see compilePage.
+func GetTasksByProjectIdAndFilter(id int, filter FilterNode, keys SortKeys, page Page) (*Tasks, error) {
+	return queryFilteredTasks(projectTasksFrom, []interface{}{id}, filter, keys, page)
+}
+
+// CountTasksByProjectIdAndFilter counts the tasks of the project matching the filter.
+func CountTasksByProjectIdAndFilter(id int, filter FilterNode) (int, error) {
+	return countFilteredTasks(projectTasksFrom, []interface{}{id}, filter)
+}
+
+func GetProjectById(id int) (*Project, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_PROJECTS where ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var p Project
+	if err := stmt.QueryRow(id).Scan(&p.Id, &p.AccountId, &p.Name, &p.SortBy, &p.SortOrder, &p.Archived, &p.TeamId); err != nil {
+		return nil, err
+	} else {
+		return &p, nil
+	}
+}
+
+func GetProjectsByAccountId(id int) (*Projects, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_PROJECTS where ACCOUNT_ID = ? order by ID asc")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ps, err := scanProjects(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+func GetViewById(id int) (*View, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_VIEWS where ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var v View
+	if err := stmt.QueryRow(id).Scan(&v.Id, &v.AccountId, &v.Name, &v.Filter, &v.Sort, &v.Shared); err != nil {
+		return nil, err
+	} else {
+		return &v, nil
+	}
+}
+
+// GetViewsByAccountId returns the accounts own views, together with all shared views.
+func GetViewsByAccountId(id int) (*Views, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_VIEWS where ACCOUNT_ID = ? or SHARED > 0 order by SHARED desc, NAME asc, ID asc")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vs, err := scanViews(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return vs, nil
+}
+
+// GetFeedTokenByHash returns the feed token with the hash, see HashFeedToken.
+func GetFeedTokenByHash(hash string) (*FeedToken, error) {
+	return queryFeedToken("select * from T_FEED_TOKENS where TOKEN_HASH = ?", hash)
+}
+
+func GetFeedTokenByAccountId(id int) (*FeedToken, error) {
+	return queryFeedToken("select * from T_FEED_TOKENS where ACCOUNT_ID = ?", id)
+}
+
+func queryFeedToken(query string, args ...interface{}) (*FeedToken, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var f FeedToken
+	if err := db.QueryRow(query, args...).Scan(&f.AccountId, &f.TokenHash, &f.Created); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func GetTagById(id int) (*Tag, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_TAGS where ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var t Tag
+	if err := stmt.QueryRow(id).Scan(&t.Id, &t.AccountId, &t.Name); err != nil {
+		return nil, err
+	} else {
+		return &t, nil
+	}
+}
+
+func GetTagByName(accountId int, name string) (*Tag, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_TAGS where ACCOUNT_ID = ? and NAME = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var t Tag
+	if err := stmt.QueryRow(accountId, name).Scan(&t.Id, &t.AccountId, &t.Name); err != nil {
+		return nil, err
+	} else {
+		return &t, nil
+	}
+}
+
+func GetTagsByAccountId(id int) (*Tags, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_TAGS where ACCOUNT_ID = ? order by NAME asc")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTags(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func GetTagsByTaskId(id int) (*Tags, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select G.* from T_TAGS G join T_TASK_TAGS TT on TT.TAG_ID = G.ID where TT.TASK_ID = ? order by G.NAME asc")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTags(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func GetTagCloud(accountId int) (*TagCloud, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare(`
+		select G.ID, G.NAME, count(TT.TASK_ID) from T_TAGS G 
+		left join T_TASK_TAGS TT on TT.TAG_ID = G.ID 
+			and TT.TASK_ID not in (select ID from T_TASKS where DELETED > 0) 
+		where G.ACCOUNT_ID = ? 
+		group by G.ID, G.NAME 
+		order by G.NAME asc`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tc := TagCloud{}
+	for rows.Next() {
+		var c TagCount
+		if err := rows.Scan(&c.Id, &c.Name, &c.Count); err != nil {
+			return nil, err
+		}
+		tc = append(tc, c)
+	}
+
+	return &tc, nil
+}
+
+// CountTaskTags returns for each tagged task how many of the given tag names it carries.
+func CountTaskTags(names []string) (map[int]int, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+
+	rows, err := db.Query(`
+		select TT.TASK_ID, count(distinct lower(G.NAME)) from T_TASK_TAGS TT 
+		join T_TAGS G on G.ID = TT.TAG_ID 
+		where G.NAME in (`+placeholders(len(names))+`) 
+		group by TT.TASK_ID`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[int]int)
+	for rows.Next() {
+		var taskId, count int
+		if err := rows.Scan(&taskId, &count); err != nil {
+			return nil, err
+		}
+		counts[taskId] = count
+	}
+
+	return counts, nil
+}
+
+func GetShareById(id int) (*Share, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_SHARES where ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var s Share
+	if err := stmt.QueryRow(id).Scan(&s.Id, &s.TaskId, &s.ProjectId, &s.AccountId, &s.Permission); err != nil {
+		return nil, err
+	} else {
+		return &s, nil
+	}
+}
+
+func queryShares(query string, args ...interface{}) (*Shares, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ss, err := scanShares(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ss, nil
+}
+
+func GetSharesByTaskId(id int) (*Shares, error) {
+	return queryShares("select * from T_SHARES where TASK_ID = ? order by ID asc", id)
+}
+
+func GetSharesByProjectId(id int) (*Shares, error) {
+	return queryShares("select * from T_SHARES where PROJECT_ID = ? order by ID asc", id)
+}
+
+// GetSharesByAccountId returns all shares the account has received.
+func GetSharesByAccountId(id int) (*Shares, error) {
+	return queryShares("select * from T_SHARES where ACCOUNT_ID = ? order by ID asc", id)
+}
+
+// GetTaskPermission returns the highest permission level the account got on the task,
+// either by the task itself or by its project being shared with the account,
+// or by being a member of the team owning its project. Assignees can always view their task.
+func GetTaskPermission(task *Task, accountId int) (int, error) {
+	ss, err := queryShares(`
+		select * from T_SHARES 
+		where ACCOUNT_ID = ? and ((TASK_ID = ? and TASK_ID > 0) or (PROJECT_ID = ? and PROJECT_ID > 0))`,
+		accountId, task.Id, task.ProjectId)
+	if err != nil {
+		return PermissionNone, err
+	}
+
+	ms, err := queryMembers(`
+		select M.* from T_TEAM_MEMBERS M 
+		join T_PROJECTS P on P.TEAM_ID = M.TEAM_ID 
+		where P.ID = ? and M.ACCOUNT_ID = ?`,
+		task.ProjectId, accountId)
+	if err != nil {
+		return PermissionNone, err
+	}
+
+	level := maxPermission(ss.Level(), ms.Level())
+	if task.AssigneeId == accountId {
+		level = maxPermission(level, PermissionView)
+	}
+	return level, nil
+}
+
+func GetProjectPermission(project *Project, accountId int) (int, error) {
+	ss, err := queryShares("select * from T_SHARES where ACCOUNT_ID = ? and PROJECT_ID = ? and PROJECT_ID > 0", accountId, project.Id)
+	if err != nil {
+		return PermissionNone, err
+	}
+
+	ms, err := queryMembers("select * from T_TEAM_MEMBERS where TEAM_ID = ? and ACCOUNT_ID = ?", project.TeamId, accountId)
+	if err != nil {
+		return PermissionNone, err
+	}
+
+	return maxPermission(ss.Level(), ms.Level()), nil
+}
+
+// GetVisibleProjectsByAccountId returns the accounts own projects, together with all projects shared with it
+// or owned by one of its teams.
+func GetVisibleProjectsByAccountId(id int) (*Projects, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare(`
+		select * from T_PROJECTS 
+		where ACCOUNT_ID = ? 
+		or ID in (select PROJECT_ID from T_SHARES where ACCOUNT_ID = ? and PROJECT_ID > 0) 
+		or TEAM_ID in (select TEAM_ID from T_TEAM_MEMBERS where ACCOUNT_ID = ? and TEAM_ID > 0) 
+		order by ID asc`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id, id, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ps, err := scanProjects(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+// GetTasksByTeamId returns all tasks of the projects owned by the team, except for archived projects.
+func GetTasksByTeamId(id int) (*Tasks, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare(`
+		select T.* from T_TASKS T 
+		join T_PROJECTS P on P.ID = T.PROJECT_ID 
+		where P.TEAM_ID = ? and P.ARCHIVED = 0 and T.DELETED = 0 
+		order by T.PRIORITY desc, T.LAST_UPDATED asc, T.CREATED asc`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTasks(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func GetProjectsByTeamId(id int) (*Projects, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_PROJECTS where TEAM_ID = ? order by ID asc")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ps, err := scanProjects(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps, nil
+}
+
+func GetTeamById(id int) (*Team, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_TEAMS where ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var t Team
+	if err := stmt.QueryRow(id).Scan(&t.Id, &t.Name); err != nil {
+		return nil, err
+	} else {
+		return &t, nil
+	}
+}
+
+// GetTeamsByAccountId returns all teams the account is a member of.
+func GetTeamsByAccountId(id int) (*Teams, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select T.* from T_TEAMS T join T_TEAM_MEMBERS M on M.TEAM_ID = T.ID where M.ACCOUNT_ID = ? order by T.ID asc")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.Query(id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ts, err := scanTeams(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts, nil
+}
+
+func queryMembers(query string, args ...interface{}) (*Members, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ms, err := scanMembers(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return ms, nil
+}
+
+func GetMembersByTeamId(id int) (*Members, error) {
+	return queryMembers("select * from T_TEAM_MEMBERS where TEAM_ID = ? order by ACCOUNT_ID asc", id)
+}
+
+// GetAssignmentsByTaskId returns the assignment history of a task, oldest first.
+func GetAssignmentsByTaskId(id int) (*Assignments, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select * from T_ASSIGNMENTS where TASK_ID = ? order by ASSIGNED asc, ID asc", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as, err := scanAssignments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
+
+// queryComments returns the comments found by the query, together with their mentions.
+func queryComments(query string, args ...interface{}) (*Comments, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cs, err := scanComments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, err := db.Prepare("select ACCOUNT_ID from T_COMMENT_MENTIONS where COMMENT_ID = ? order by ACCOUNT_ID asc")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	for i := range *cs {
+		mentionRows, err := stmt.Query((*cs)[i].Id)
+		if err != nil {
+			return nil, err
+		}
+		for mentionRows.Next() {
+			var accountId int
+			if err := mentionRows.Scan(&accountId); err != nil {
+				mentionRows.Close()
+				return nil, err
+			}
+			(*cs)[i].Mentions = append((*cs)[i].Mentions, accountId)
+		}
+		mentionRows.Close()
+	}
+
+	return cs, nil
+}
+
+func GetCommentById(id int) (*Comment, error) {
+	cs, err := queryComments("select * from T_COMMENTS where ID = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(*cs) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &(*cs)[0], nil
+}
+
+// GetCommentsByTaskId returns the comment thread of a task, oldest first.
+func GetCommentsByTaskId(id int) (*Comments, error) {
+	return queryComments("select * from T_COMMENTS where TASK_ID = ? order by CREATED asc, ID asc", id)
+}
+
+func GetAttachmentById(id int) (*Attachment, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_ATTACHMENTS where ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var a Attachment
+	if err := stmt.QueryRow(id).Scan(&a.Id, &a.TaskId, &a.AccountId, &a.Name, &a.ContentType, &a.Size, &a.Created, &a.BlobKey); err != nil {
+		return nil, err
+	} else {
+		return &a, nil
+	}
+}
+
+func GetAttachmentsByTaskId(id int) (*Attachments, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select * from T_ATTACHMENTS where TASK_ID = ? order by CREATED asc, ID asc", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as, err := scanAttachments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
+
+func queryChanges(query string, args ...interface{}) (*Changes, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cs, err := scanChanges(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs, nil
+}
+
+// GetChangesByEntity returns the history of a single task or account, oldest first.
+func GetChangesByEntity(entity string, id int) (*Changes, error) {
+	return queryChanges("select * from T_HISTORY where ENTITY = ? and ENTITY_ID = ? order by CHANGED asc, ID asc", entity, id)
+}
+
+// GetChanges returns the audit log, restricted by the given filter, oldest first.
+func GetChanges(filter AuditFilter) (*Changes, error) {
+	where := []string{"1 = 1"}
+	args := []interface{}{}
+	if filter.AccountId > 0 {
+		where = append(where, "ACCOUNT_ID = ?")
+		args = append(args, filter.AccountId)
+	}
+	if filter.Entity != "" {
+		where = append(where, "ENTITY = ?")
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityId > 0 {
+		where = append(where, "ENTITY_ID = ?")
+		args = append(args, filter.EntityId)
+	}
+	if filter.From > 0 {
+		where = append(where, "CHANGED >= ?")
+		args = append(args, filter.From)
+	}
+	if filter.To > 0 {
+		where = append(where, "CHANGED <= ?")
+		args = append(args, filter.To)
+	}
+
+	return queryChanges("select * from T_HISTORY where "+strings.Join(where, " and ")+" order by CHANGED asc, ID asc", args...)
+}
+
+// GetUndoStepsByAccountId returns the undo stack of an account, most recent step first.
+func GetUndoStepsByAccountId(id int) (*UndoSteps, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select * from T_UNDO where ACCOUNT_ID = ? order by ID desc", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	us, err := scanUndoSteps(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return us, nil
+}
+
+func GetMember(teamId int, accountId int) (*Member, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_TEAM_MEMBERS where TEAM_ID = ? and ACCOUNT_ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var m Member
+	if err := stmt.QueryRow(teamId, accountId).Scan(&m.TeamId, &m.AccountId, &m.Role); err != nil {
+		return nil, err
+	} else {
+		return &m, nil
+	}
+}
+
+func GetAllAccounts() (*Accounts, error) {
+	return GetSortedAccounts(nil, Page{})
+}
+
+// GetSortedAccounts returns all accounts sorted by the keys if the database can do so, see compileOrder,
+// and only reads the rows of the page, see compilePage.
+func GetSortedAccounts(keys SortKeys, page Page) (*Accounts, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	where, args, order := compilePage(keys, accountSortFields, "ID asc", "ID", page)
+	rows, err := db.Query("select * from T_ACCOUNTS where DELETED = 0 and "+where+" order by "+order, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as, err := scanAccounts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
+
+// queryAccount returns the first account found by the query.
+func queryAccount(query string, id int) (*Account, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var a Account
+	if err := stmt.QueryRow(id).Scan(&a.Id, &a.Name, &a.Email, &a.Password, &a.Salt, &a.Role, &a.LastAuth, &a.Deleted, &a.Version); err != nil {
+		return nil, err
+	} else {
+		return &a, nil
+	}
+}
+
+// CountAccounts returns the number of accounts that are not in the trash.
+func CountAccounts() (int, error) {
+	db, err := connect()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRow("select count(*) from T_ACCOUNTS where DELETED = 0").Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetAccountById returns the account, unless it is in the trash.
+func GetAccountById(id int) (*Account, error) {
+	return queryAccount("select * from T_ACCOUNTS where ID = ? and DELETED = 0", id)
+}
+
+// GetTrashedAccountById only returns the account if it is in the trash.
+func GetTrashedAccountById(id int) (*Account, error) {
+	return queryAccount("select * from T_ACCOUNTS where ID = ? and DELETED > 0", id)
+}
+
+func GetTrashedAccounts() (*Accounts, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query("select * from T_ACCOUNTS where DELETED > 0 order by DELETED desc, ID asc")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as, err := scanAccounts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
+
+func GetAccountByEmail(email string) (*Account, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("select * from T_ACCOUNTS where EMAIL = ? and DELETED = 0")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	var a Account
+	if err := stmt.QueryRow(email).Scan(&a.Id, &a.Name, &a.Email, &a.Password, &a.Salt, &a.Role, &a.LastAuth, &a.Deleted, &a.Version); err != nil {
+		return nil, err
+	} else {
+		return &a, nil
+	}
+}
+
+// GetAccountsByNames returns all accounts with one of the given names, ignoring case.
+func GetAccountsByNames(names []string) (*Accounts, error) {
+	if len(names) == 0 {
+		return &Accounts{}, nil
+	}
+
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = strings.ToLower(name)
+	}
+	rows, err := db.Query("select * from T_ACCOUNTS where lower(NAME) in ("+placeholders(len(names))+") and DELETED = 0 order by ID asc", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	as, err := scanAccounts(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return as, nil
+}
+
+func (ts Tasks) Save() error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := ts.saveTx(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (ts Tasks) saveTx(tx *sql.Tx) error {
+	// every save increments the version of a task
+	stmt, err := tx.Prepare(`insert or replace into T_TASKS (ID, ACCOUNT_ID, CREATED, LAST_UPDATED, PRIORITY, TASK, PROJECT_ID, ASSIGNEE_ID, STATUS, DELETED, VERSION, RANK) 
+		values (?,?,?,?,?,?,?,?,?,?, coalesce((select VERSION from T_TASKS where ID = ?), 0) + 1, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, t := range ts {
+		var result sql.Result
+		if t.Id < 1 {
+			// new tasks are put at the end of their list
+			if t.Rank == "" {
+				var last string
+				if err := tx.QueryRow("select coalesce(max(RANK), '') from T_TASKS where "+taskListWhere, t.ProjectId, t.ProjectId, t.AccountId).Scan(&last); err != nil {
+					return err
+				}
+				t.Rank = RankBetween(last, "")
+			}
+			result, err = stmt.Exec(nil, t.AccountId, t.Created, t.LastUpdated, t.Priority, t.Task, t.ProjectId, t.AssigneeId, t.Status, t.Deleted, nil, t.Rank)
+		} else {
+			result, err = stmt.Exec(t.Id, t.AccountId, t.Created, t.LastUpdated, t.Priority, t.Task, t.ProjectId, t.AssigneeId, t.Status, t.Deleted, t.Id, t.Rank)
+		}
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		t.Id = int(id)
+
+		if err := tx.QueryRow("select VERSION from T_TASKS where ID = ?", t.Id).Scan(&t.Version); err != nil {
+			return err
+		}
+
+		ts[i] = t
+	}
+
+	return nil
+}
+
+// GetImportedTasks returns the tasks the account has imported with an external id, by their external ids.
+// Tasks that have been deleted or handed over to another account since are left out, so that importing them again creates them anew.
+func GetImportedTasks(accountId int) (map[string]Task, error) {
+	db, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`select I.EXTERNAL_ID, T.* from T_TASK_IMPORTS I 
+		join T_TASKS T on T.ID = I.TASK_ID 
+		where I.ACCOUNT_ID = ? and T.ACCOUNT_ID = I.ACCOUNT_ID and T.DELETED = 0`, accountId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := map[string]Task{}
+	for rows.Next() {
+		var externalId string
+		var t Task
+		if err := rows.Scan(&externalId, &t.Id, &t.AccountId, &t.Created, &t.LastUpdated, &t.Priority, &t.Task, &t.ProjectId, &t.AssigneeId, &t.Status, &t.Deleted, &t.Version, &t.Rank); err != nil {
+			return nil, err
+		}
+		tasks[externalId] = t
+	}
+	return tasks, nil
+}
+
+// ImportTasks saves the imported tasks of the account in batches, all within a single transaction.
+// Tasks with an external id are remembered, so that importing them again updates them instead of creating duplicates.
+func ImportTasks(accountId int, ts Tasks, externalIds []string) error {
+	return transaction(func(tx *sql.Tx) error {
+		return importTasksTx(tx, accountId, ts, externalIds)
+	})
+}
+
+func importTasksTx(tx *sql.Tx, accountId int, ts Tasks, externalIds []string) error {
+	for start := 0; start < len(ts); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(ts) {
+			end = len(ts)
+		}
+		if err := ts[start:end].saveTx(tx); err != nil {
+			return err
+		}
+	}
+
+	for i, t := range ts {
+		if externalIds[i] == "" {
+			continue
+		}
+		if _, err := tx.Exec("insert or replace into T_TASK_IMPORTS (ACCOUNT_ID, EXTERNAL_ID, TASK_ID) values (?,?,?)", accountId, externalIds[i], t.Id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Task) Save() error {
+	return transaction(t.saveTx)
+}
+
+func (t *Task) saveTx(tx *sql.Tx) error {
+	tasks := Tasks{*t}
+	if err := tasks.saveTx(tx); err != nil {
+		return err
+	}
+
+	t.Id = tasks[0].Id
+	t.Version = tasks[0].Version
+	t.Rank = tasks[0].Rank
+	return nil
+}
+
+// SaveRanks only stores the ranks of the tasks, which moves each of them by touching a single row.
+func (ts Tasks) SaveRanks() error {
+	return transaction(ts.saveRanksTx)
+}
+
+func (ts Tasks) saveRanksTx(tx *sql.Tx) error {
+	stmt, err := tx.Prepare("update T_TASKS set RANK = ?, VERSION = VERSION + 1 where ID = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, t := range ts {
+		if _, err := stmt.Exec(t.Rank, t.Id); err != nil {
+			return err
+		}
+		if err := tx.QueryRow("select VERSION from T_TASKS where ID = ?", t.Id).Scan(&t.Version); err != nil {
+			return err
+		}
+		ts[i] = t
+	}
+
+	return nil
+}
+
+// SaveIfMatch only saves the task if its stored version still is the given one, otherwise ErrVersionMismatch is returned.
+// A version of 0 matches any version, as long as the task exists.
+func (t *Task) SaveIfMatch(version int) error {
+	return transaction(func(tx *sql.Tx) error {
+		return t.saveIfMatchTx(tx, version)
+	})
+}
+
+func (t *Task) saveIfMatchTx(tx *sql.Tx, version int) error {
+	result, err := tx.Exec(`update T_TASKS set ACCOUNT_ID = ?, CREATED = ?, LAST_UPDATED = ?, PRIORITY = ?, TASK = ?, PROJECT_ID = ?, ASSIGNEE_ID = ?, STATUS = ?, DELETED = ?, RANK = ?, 
+		VERSION = VERSION + 1 where ID = ? and (? = 0 or VERSION = ?)`,
+		t.AccountId, t.Created, t.LastUpdated, t.Priority, t.Task, t.ProjectId, t.AssigneeId, t.Status, t.Deleted, t.Rank, t.Id, version, version)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersionMatched(result); err != nil {
+		return err
+	}
+
+	return tx.QueryRow("select VERSION from T_TASKS where ID = ?", t.Id).Scan(&t.Version)
+}
+
+// saveVersionTx saves the task with SaveIfMatch semantics, a negative version saves it unconditionally.
+func (t *Task) saveVersionTx(tx *sql.Tx, version int) error {
+	if version < 0 {
+		return t.saveTx(tx)
+	}
+	return t.saveIfMatchTx(tx, version)
+}
+
+// checkVersionMatched returns ErrVersionMismatch if a conditional update did not find its row in the expected version.
+func checkVersionMatched(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrVersionMismatch
+	}
+	return nil
+}
+
+func (ts Tasks) Delete() error {
+	var blobKeys []string
+	err := transaction(func(tx *sql.Tx) error {
+		var err error
+		blobKeys, err = ts.deleteTx(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return deleteBlobs(blobKeys)
+}
+
+// deleteTx deletes the tasks together with everything that belongs to them, and returns the keys of the blobs of their attachments.
+// The blobs are not part of the database, they are only to be removed by deleteBlobs after the transaction has been committed.
+func (ts Tasks) deleteTx(tx *sql.Tx) ([]string, error) {
+	stmt, err := tx.Prepare("delete from T_TASKS where ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+
+	tagStmt, err := tx.Prepare("delete from T_TASK_TAGS where TASK_ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer tagStmt.Close()
+
+	shareStmt, err := tx.Prepare("delete from T_SHARES where TASK_ID = ? and TASK_ID > 0")
+	if err != nil {
+		return nil, err
+	}
+	defer shareStmt.Close()
+
+	assignmentStmt, err := tx.Prepare("delete from T_ASSIGNMENTS where TASK_ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer assignmentStmt.Close()
+
+	mentionStmt, err := tx.Prepare("delete from T_COMMENT_MENTIONS where COMMENT_ID in (select ID from T_COMMENTS where TASK_ID = ?)")
+	if err != nil {
+		return nil, err
+	}
+	defer mentionStmt.Close()
+
+	commentStmt, err := tx.Prepare("delete from T_COMMENTS where TASK_ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer commentStmt.Close()
+
+	attachmentStmt, err := tx.Prepare("delete from T_ATTACHMENTS where TASK_ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer attachmentStmt.Close()
+
+	importStmt, err := tx.Prepare("delete from T_TASK_IMPORTS where TASK_ID = ?")
+	if err != nil {
+		return nil, err
+	}
+	defer importStmt.Close()
+
+	blobKeys := []string{}
+	for _, t := range ts {
+		rows, err := tx.Query("select BLOB_KEY from T_ATTACHMENTS where TASK_ID = ?", t.Id)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key string
+			if err := rows.Scan(&key); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			blobKeys = append(blobKeys, key)
+		}
+		rows.Close()
+	}
+
+	for i, t := range ts {
+		if _, err := tagStmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		if _, err := shareStmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		if _, err := assignmentStmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		if _, err := mentionStmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		if _, err := commentStmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		if _, err := attachmentStmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		if _, err := importStmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		if _, err := stmt.Exec(t.Id); err != nil {
+			return nil, err
+		}
+		t.Id = -1
+		ts[i] = t
+	}
+
+	return blobKeys, nil
+}
+
+func deleteBlobs(keys []string) error {
+	for _, key := range keys {
+		if err := blobStore.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *Task) Delete() error {
+	tasks := Tasks{*t}
+	if err := tasks.Delete(); err != nil {
+		return err
+	}
+
+	t.Id = -1
+	return nil
+}
+
+func (t *Task) deleteTx(tx *sql.Tx) ([]string, error) {
+	tasks := Tasks{*t}
+	blobKeys, err := tasks.deleteTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Id = -1
+	return blobKeys, nil
+}
+
+// Assign changes the assignee of the task and records who made the change, both within one transaction.
+func (t *Task) Assign(assigneeId int, assignedBy int) (*Assignment, error) {
+	var a *Assignment
+	err := transaction(func(tx *sql.Tx) error {
+		var err error
+		a, err = t.assignTx(tx, assigneeId, assignedBy)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (t *Task) assignTx(tx *sql.Tx, assigneeId int, assignedBy int) (*Assignment, error) {
+	if _, err := tx.Exec("update T_TASKS set ASSIGNEE_ID = ?, VERSION = VERSION + 1 where ID = ?", assigneeId, t.Id); err != nil {
+		return nil, err
+	}
+
+	var version int
+	if err := tx.QueryRow("select VERSION from T_TASKS where ID = ?", t.Id).Scan(&version); err != nil {
+		return nil, err
+	}
+
+	a := Assignment{-1, t.Id, assigneeId, assignedBy, int(time.Now().Unix())}
+	result, err := tx.Exec("insert into T_ASSIGNMENTS (TASK_ID, ASSIGNEE_ID, ASSIGNED_BY, ASSIGNED) values (?,?,?,?)",
+		a.TaskId, a.AssigneeId, a.AssignedBy, a.Assigned)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	a.Id = int(id)
+
+	t.AssigneeId = assigneeId
+	t.Version = version
+	return &a, nil
+}
+
+// Save stores the comment together with its mentions, replacing all previous mentions.
+func (c *Comment) Save() error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	var result sql.Result
+	if c.Id < 1 {
+		result, err = tx.Exec("insert into T_COMMENTS (TASK_ID, ACCOUNT_ID, CREATED, LAST_UPDATED, BODY) values (?,?,?,?,?)",
+			c.TaskId, c.AccountId, c.Created, c.LastUpdated, c.Body)
+	} else {
+		result, err = tx.Exec("update T_COMMENTS set TASK_ID = ?, ACCOUNT_ID = ?, CREATED = ?, LAST_UPDATED = ?, BODY = ? where ID = ?",
+			c.TaskId, c.AccountId, c.Created, c.LastUpdated, c.Body, c.Id)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	id := int64(c.Id)
+	if c.Id < 1 {
+		id, err = result.LastInsertId()
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("delete from T_COMMENT_MENTIONS where COMMENT_ID = ?", id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, accountId := range c.Mentions {
+		if _, err := tx.Exec("insert or ignore into T_COMMENT_MENTIONS (COMMENT_ID, ACCOUNT_ID) values (?,?)", id, accountId); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	c.Id = int(id)
+	return nil
+}
+
+func (c *Comment) Delete() error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("delete from T_COMMENT_MENTIONS where COMMENT_ID = ?", c.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_COMMENTS where ID = ?", c.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	c.Id = -1
+	return nil
+}
+
+func (a *Attachment) Save() error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("insert or replace into T_ATTACHMENTS (ID, TASK_ID, ACCOUNT_ID, NAME, CONTENT_TYPE, SIZE, CREATED, BLOB_KEY) values (?,?,?,?,?,?,?,?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var result sql.Result
+	if a.Id < 1 {
+		result, err = stmt.Exec(nil, a.TaskId, a.AccountId, a.Name, a.ContentType, a.Size, a.Created, a.BlobKey)
+	} else {
+		result, err = stmt.Exec(a.Id, a.TaskId, a.AccountId, a.Name, a.ContentType, a.Size, a.Created, a.BlobKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.Id = int(id)
+
+	return nil
+}
+
+// Delete removes the attachment from the db, and its blob from the BlobStore.
+func (a *Attachment) Delete() error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("delete from T_ATTACHMENTS where ID = ?", a.Id); err != nil {
+		return err
+	}
+	if err := blobStore.Delete(a.BlobKey); err != nil {
+		return err
+	}
+
+	a.Id = -1
+	return nil
+}
+
+// Save appends the changes to the history, there is no way to update or delete them afterwards.
+func (cs Changes) Save() error {
+	return transaction(cs.saveTx)
+}
+
+// saveTx appends the changes within the transaction of the mutation they record.
+func (cs Changes) saveTx(tx *sql.Tx) error {
+	if len(cs) == 0 {
+		return nil
+	}
+
+	stmt, err := tx.Prepare("insert into T_HISTORY (ENTITY, ENTITY_ID, ACTION, ACCOUNT_ID, CHANGED, FIELD, OLD_VALUE, NEW_VALUE) values (?,?,?,?,?,?,?,?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, c := range cs {
+		result, err := stmt.Exec(c.Entity, c.EntityId, c.Action, c.AccountId, c.Changed, c.Field, c.OldValue, c.NewValue)
+		if err != nil {
+			return err
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		c.Id = int(id)
+
+		cs[i] = c
+	}
+
+	return nil
+}
+
+// Trash moves the tasks to the trash, by marking them as deleted at the given timestamp.
+// A timestamp of 0 restores them again.
+func (ts Tasks) Trash(deleted int) error {
+	return transaction(func(tx *sql.Tx) error {
+		return ts.trashTx(tx, deleted, 0)
+	})
+}
+
+// trashTx sets the deleted timestamp of all tasks, those with a version other than 0 only if their stored version matches.
+func (ts Tasks) trashTx(tx *sql.Tx, deleted int, version int) error {
+	stmt, err := tx.Prepare("update T_TASKS set DELETED = ?, VERSION = VERSION + 1 where ID = ? and (? = 0 or VERSION = ?)")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i, t := range ts {
+		result, err := stmt.Exec(deleted, t.Id, version, version)
+		if err != nil {
+			return err
+		}
+		if version > 0 {
+			if err := checkVersionMatched(result); err != nil {
+				return err
+			}
+		}
+		if err := tx.QueryRow("select VERSION from T_TASKS where ID = ?", t.Id).Scan(&t.Version); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		t.Deleted = deleted
+		ts[i] = t
+	}
+
+	return nil
+}
+
+// SaveAndTrash saves and trashes the given tasks within a single transaction, so either all of the changes are made or none.
+func SaveAndTrash(saves Tasks, trashes Tasks) error {
+	return transaction(func(tx *sql.Tx) error {
+		return saveAndTrashTx(tx, saves, trashes)
+	})
+}
+
+func saveAndTrashTx(tx *sql.Tx, saves Tasks, trashes Tasks) error {
+	if err := saves.saveTx(tx); err != nil {
+		return err
+	}
+	return trashes.trashTx(tx, int(time.Now().Unix()), 0)
+}
+
+func (t *Task) Trash() error {
+	return t.TrashIfMatch(0)
+}
+
+// TrashIfMatch only moves the task to the trash if its stored version still is the given one,
+// otherwise ErrVersionMismatch is returned.
+func (t *Task) TrashIfMatch(version int) error {
+	return transaction(func(tx *sql.Tx) error {
+		return t.trashTx(tx, int(time.Now().Unix()), version)
+	})
+}
+
+func (t *Task) Restore() error {
+	return transaction(func(tx *sql.Tx) error {
+		return t.trashTx(tx, 0, 0)
+	})
+}
+
+// trashTx moves the task to the trash, or restores it with a deleted timestamp of 0, see Tasks.trashTx.
+func (t *Task) trashTx(tx *sql.Tx, deleted int, version int) error {
+	tasks := Tasks{*t}
+	if err := tasks.trashTx(tx, deleted, version); err != nil {
+		return err
+	}
+
+	t.Deleted = tasks[0].Deleted
+	t.Version = tasks[0].Version
+	return nil
+}
+
+// trash sets the deleted timestamp of the account, with a version other than 0 only if its stored version matches.
+func (a *Account) trash(deleted int, version int) error {
+	return transaction(func(tx *sql.Tx) error {
+		return a.trashTx(tx, deleted, version)
+	})
+}
+
+func (a *Account) trashTx(tx *sql.Tx, deleted int, version int) error {
+	result, err := tx.Exec("update T_ACCOUNTS set DELETED = ?, VERSION = VERSION + 1 where ID = ? and (? = 0 or VERSION = ?)", deleted, a.Id, version, version)
+	if err != nil {
+		return err
+	}
+	if version > 0 {
+		if err := checkVersionMatched(result); err != nil {
+			return err
+		}
+	}
+	if err := tx.QueryRow("select VERSION from T_ACCOUNTS where ID = ?", a.Id).Scan(&a.Version); err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	// a feed does not need the account to sign in, so its token is gone for good
+	if deleted > 0 {
+		if _, err := tx.Exec("delete from T_FEED_TOKENS where ACCOUNT_ID = ?", a.Id); err != nil {
+			return err
+		}
+	}
+
+	a.Deleted = deleted
+	return nil
+}
+
+// Trash moves the account to the trash, it cannot authenticate anymore until it gets restored.
+func (a *Account) Trash() error {
+	return a.trash(int(time.Now().Unix()), 0)
+}
+
+// TrashIfMatch only moves the account to the trash if its stored version still is the given one,
+// otherwise ErrVersionMismatch is returned.
+func (a *Account) TrashIfMatch(version int) error {
+	return a.trash(int(time.Now().Unix()), version)
+}
+
+func (a *Account) Restore() error {
+	return a.trash(0, 0)
+}
+
+// PurgeTrash permanently deletes all tasks and accounts that have been moved to the trash before the given timestamp.
+// The deletions are recorded in the history without an actor.
+func PurgeTrash(before int) (int, error) {
+	now := int(time.Now().Unix())
+	n := 0
+	var blobKeys []string
+	// the items are selected within the transaction, so that one restored in the meantime is not purged anyway
+	err := transaction(func(tx *sql.Tx) error {
+		rows, err := tx.Query("select * from T_TASKS where DELETED > 0 and DELETED < ?", before)
+		if err != nil {
+			return err
+		}
+		ts, err := scanTasks(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		rows, err = tx.Query("select * from T_ACCOUNTS where DELETED > 0 and DELETED < ?", before)
+		if err != nil {
+			return err
+		}
+		as, err := scanAccounts(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+
+		changes := Changes{}
+		for _, t := range *ts {
+			changes = append(changes, DiffChanges(EntityTask, t.Id, ActionPurge, 0, now, t, Task{})...)
+		}
+		for _, a := range *as {
+			changes = append(changes, DiffChanges(EntityAccount, a.Id, ActionPurge, 0, now, a, Account{})...)
+		}
+
+		if blobKeys, err = ts.deleteTx(tx); err != nil {
+			return err
+		}
+		for _, a := range *as {
+			keys, err := a.deleteTx(tx)
+			if err != nil {
+				return err
+			}
+			blobKeys = append(blobKeys, keys...)
+		}
+		n = len(*ts) + len(*as)
+		return changes.saveTx(tx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := deleteBlobs(blobKeys); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// the priority scale the tasks were last brought into, as "min..max"
+const settingPriorityScale = "PRIORITY_SCALE"
+
+// returned if tasks have priorities outside of the configured priority scale, and these are not to be normalized
+var ErrPrioritiesOutOfScale = errors.New("Priorities outside of the priority scale")
+
+// MigratePriorities brings the tasks into the configured priority scale, once for every scale they are used with.
+// Unless normalize is set, it does not change any priority, but returns ErrPrioritiesOutOfScale along with the number of tasks outside of the scale.
+// It returns the number of tasks that had to be changed.
+func MigratePriorities(normalize bool) (int, error) {
+	scale := GetPriorityScale()
+	value := strconv.Itoa(scale.Min) + ".." + strconv.Itoa(scale.Max)
+	n := 0
+	err := transaction(func(tx *sql.Tx) error {
+		var recorded string
+		err := tx.QueryRow("select VALUE from T_SETTINGS where NAME = ?", settingPriorityScale).Scan(&recorded)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if recorded == value {
+			return nil
+		}
+
+		if err := tx.QueryRow("select count(*) from T_TASKS where PRIORITY < ? or PRIORITY > ?", scale.Min, scale.Max).Scan(&n); err != nil {
+			return err
+		}
+		if n > 0 && !normalize {
+			return ErrPrioritiesOutOfScale
+		}
+		if n, err = normalizePrioritiesTx(tx); err != nil {
+			return err
+		}
+		_, err = tx.Exec("insert or replace into T_SETTINGS (NAME, VALUE) values (?, ?)", settingPriorityScale, value)
+		return err
+	})
+	if err == ErrPrioritiesOutOfScale {
+		return n, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// NormalizePriorities moves all priorities outside of the priority scale to its nearest end, and records these changes.
+// It returns the number of tasks that had to be changed.
+func NormalizePriorities() (int, error) {
+	n := 0
+	err := transaction(func(tx *sql.Tx) (err error) {
+		n, err = normalizePrioritiesTx(tx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func normalizePrioritiesTx(tx *sql.Tx) (int, error) {
+	scale := GetPriorityScale()
+	rows, err := tx.Query("select * from T_TASKS where PRIORITY < ? or PRIORITY > ?", scale.Min, scale.Max)
+	if err != nil {
+		return 0, err
+	}
+	ts, err := scanTasks(rows)
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	now := int(time.Now().Unix())
+	changes := Changes{}
+	for _, t := range *ts {
+		old := t
+		t.Priority = t.Priority.Normalize()
+		if _, err := tx.Exec("update T_TASKS set PRIORITY = ?, VERSION = VERSION + 1 where ID = ?", t.Priority, t.Id); err != nil {
+			return 0, err
+		}
+		changes = append(changes, DiffChanges(EntityTask, t.Id, ActionUpdate, 0, now, old, t)...)
+	}
+	if err := changes.saveTx(tx); err != nil {
+		return 0, err
+	}
+
+	return len(*ts), nil
+}
+
+// Save pushes the step onto the undo stack of its account, dropping the oldest steps beyond the undo depth.
+func (u *UndoStep) Save() error {
+	return transaction(u.saveTx)
+}
+
+// saveTx pushes the step within the transaction of the mutation it undoes.
+func (u *UndoStep) saveTx(tx *sql.Tx) error {
+	changes, err := json.Marshal(u.Changes)
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("insert into T_UNDO (ACCOUNT_ID, CREATED, CHANGES) values (?,?,?)", u.AccountId, u.Created, string(changes))
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		delete from T_UNDO where ACCOUNT_ID = ? and ID not in 
+		(select ID from T_UNDO where ACCOUNT_ID = ? order by ID desc limit ?)`,
+		u.AccountId, u.AccountId, undoDepth); err != nil {
+		return err
+	}
+
+	u.Id = int(id)
+	return nil
+}
+
+// Undo writes back the state of all tasks before the step, in reverse order, and removes the step from the undo stack.
+// Tasks created by the step are moved to the trash. If any task has been changed since the step,
+// nothing is written and ErrUndoConflict is returned. Otherwise the tasks in their restored state are returned.
+func (u *UndoStep) Undo() (Tasks, error) {
+	var restored Tasks
+	err := transaction(func(tx *sql.Tx) error {
+		var err error
+		restored, err = u.undoTx(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+func (u *UndoStep) undoTx(tx *sql.Tx) (Tasks, error) {
+	restored := Tasks{}
+	now := int(time.Now().Unix())
+	for i := len(u.Changes) - 1; i >= 0; i-- {
+		change := u.Changes[i]
+
+		id := 0
+		if change.After != nil {
+			id = change.After.Id
+		} else if change.Before != nil {
+			id = change.Before.Id
+		}
+
+		var current *Task
+		rows, err := tx.Query("select * from T_TASKS where ID = ?", id)
+		if err != nil {
+			return nil, err
+		}
+		ts, err := scanTasks(rows)
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+		if len(*ts) > 0 {
+			current = &(*ts)[0]
+		}
+
+		if change.conflicts(current) {
+			return nil, ErrUndoConflict
+		}
+
+		// undoing is a change as well, so versions keep on increasing
+		var t Task
+		if change.Before == nil {
+			t = *change.After
+			t.Deleted = now
+			t.Version = current.Version + 1
+			_, err = tx.Exec("update T_TASKS set DELETED = ?, VERSION = ? where ID = ?", t.Deleted, t.Version, t.Id)
+		} else {
+			t = *change.Before
+			t.Version = current.Version + 1
+			_, err = tx.Exec("insert or replace into T_TASKS (ID, ACCOUNT_ID, CREATED, LAST_UPDATED, PRIORITY, TASK, PROJECT_ID, ASSIGNEE_ID, STATUS, DELETED, VERSION, RANK) values (?,?,?,?,?,?,?,?,?,?,?,?)",
+				t.Id, t.AccountId, t.Created, t.LastUpdated, t.Priority, t.Task, t.ProjectId, t.AssigneeId, t.Status, t.Deleted, t.Version, t.Rank)
+		}
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, t)
+	}
+
+	if _, err := tx.Exec("delete from T_UNDO where ID = ?", u.Id); err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+func (a *Account) Save() error {
+	return transaction(a.saveTx)
+}
+
+func (a *Account) saveTx(tx *sql.Tx) error {
+	// every save increments the version of an account
+	stmt, err := tx.Prepare(`insert or replace into T_ACCOUNTS (ID, NAME, EMAIL, PASSWORD, SALT, ROLE, LAST_AUTH, DELETED, VERSION) 
+		values (?,?,?,?,?,?,?,?, coalesce((select VERSION from T_ACCOUNTS where ID = ?), 0) + 1)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	var result sql.Result
+	if a.Id < 1 {
+		result, err = stmt.Exec(nil, a.Name, a.Email, a.Password, a.Salt, a.Role, a.LastAuth, a.Deleted, nil)
+	} else {
+		result, err = stmt.Exec(a.Id, a.Name, a.Email, a.Password, a.Salt, a.Role, a.LastAuth, a.Deleted, a.Id)
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	a.Id = int(id)
+
+	return tx.QueryRow("select VERSION from T_ACCOUNTS where ID = ?", a.Id).Scan(&a.Version)
+}
+
+// SaveIfMatch only saves the account if its stored version still is the given one, otherwise ErrVersionMismatch is returned.
+// A version of 0 matches any version, as long as the account exists.
+func (a *Account) SaveIfMatch(version int) error {
+	return transaction(func(tx *sql.Tx) error {
+		return a.saveIfMatchTx(tx, version)
+	})
+}
+
+func (a *Account) saveIfMatchTx(tx *sql.Tx, version int) error {
+	result, err := tx.Exec(`update T_ACCOUNTS set NAME = ?, EMAIL = ?, PASSWORD = ?, SALT = ?, ROLE = ?, LAST_AUTH = ?, DELETED = ?, 
+		VERSION = VERSION + 1 where ID = ? and (? = 0 or VERSION = ?)`,
+		a.Name, a.Email, a.Password, a.Salt, a.Role, a.LastAuth, a.Deleted, a.Id, version, version)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersionMatched(result); err != nil {
+		return err
+	}
+
+	return tx.QueryRow("select VERSION from T_ACCOUNTS where ID = ?", a.Id).Scan(&a.Version)
+}
+
+// saveVersionTx saves the account with SaveIfMatch semantics, a negative version saves it unconditionally.
+func (a *Account) saveVersionTx(tx *sql.Tx, version int) error {
+	if version < 0 {
+		return a.saveTx(tx)
+	}
+	return a.saveIfMatchTx(tx, version)
+}
+
+func (a *Account) Delete() error {
+	var blobKeys []string
+	err := transaction(func(tx *sql.Tx) error {
+		var err error
+		blobKeys, err = a.deleteTx(tx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return deleteBlobs(blobKeys)
+}
+
+// sqlAccountData deletes everything an account leaves behind besides its tasks, each statement takes the account id.
+// Account ids can be given to new accounts again, which must not inherit any of it.
+var sqlAccountData = []string{
+	"delete from T_COMMENT_MENTIONS where ACCOUNT_ID = ? or COMMENT_ID in (select ID from T_COMMENTS where ACCOUNT_ID = ?)",
+	"delete from T_COMMENTS where ACCOUNT_ID = ?",
+	"delete from T_ATTACHMENTS where ACCOUNT_ID = ?",
+	"update T_TASKS set PROJECT_ID = 0, VERSION = VERSION + 1 where PROJECT_ID in (select ID from T_PROJECTS where ACCOUNT_ID = ?)",
+	"delete from T_SHARES where ACCOUNT_ID = ? or (PROJECT_ID > 0 and PROJECT_ID in (select ID from T_PROJECTS where ACCOUNT_ID = ?))",
+	"delete from T_PROJECTS where ACCOUNT_ID = ?",
+	"delete from T_TASK_TAGS where TAG_ID in (select ID from T_TAGS where ACCOUNT_ID = ?)",
+	"delete from T_TAGS where ACCOUNT_ID = ?",
+	"delete from T_VIEWS where ACCOUNT_ID = ?",
+	"delete from T_TASK_IMPORTS where ACCOUNT_ID = ?",
+	"delete from T_FEED_TOKENS where ACCOUNT_ID = ?",
+	"delete from T_TEAM_MEMBERS where ACCOUNT_ID = ?",
+	"delete from T_UNDO where ACCOUNT_ID = ?",
+	"update T_TASKS set ASSIGNEE_ID = 0, VERSION = VERSION + 1 where ASSIGNEE_ID = ?",
+	"delete from T_ACCOUNTS where ID = ?",
+}
+
+// deleteTx deletes the account together with its tasks and everything else that belongs to it,
+// and returns the keys of the blobs of its attachments, see Tasks.deleteTx.
+func (a *Account) deleteTx(tx *sql.Tx) ([]string, error) {
+	rows, err := tx.Query("select * from T_TASKS where ACCOUNT_ID = ?", a.Id)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := scanTasks(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	blobKeys, err := ts.deleteTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	// attachments of the account to tasks of other accounts
+	rows, err = tx.Query("select BLOB_KEY from T_ATTACHMENTS where ACCOUNT_ID = ?", a.Id)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		blobKeys = append(blobKeys, key)
+	}
+	rows.Close()
+
+	for _, query := range sqlAccountData {
+		args := []interface{}{}
+		for i := strings.Count(query, "?"); i > 0; i-- {
+			args = append(args, a.Id)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return nil, err
+		}
+	}
+	a.Id = -1
+
+	return blobKeys, nil
+}
+
+func (p *Project) Save() error {
+	return transaction(p.saveTx)
+}
+
+func (p *Project) saveTx(tx *sql.Tx) error {
+	stmt, err := tx.Prepare("insert or replace into T_PROJECTS (ID, ACCOUNT_ID, NAME, SORT_BY, SORT_ORDER, ARCHIVED, TEAM_ID) values (?,?,?,?,?,?,?)")
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer db.Close()
+	defer stmt.Close()
 
-	rows, err := db.Query("select * from T_TASKS order by PRIORITY desc, LAST_UPDATED asc, CREATED asc")
+	var result sql.Result
+	if p.Id < 1 {
+		result, err = stmt.Exec(nil, p.AccountId, p.Name, p.SortBy, p.SortOrder, p.Archived, p.TeamId)
+	} else {
+		result, err = stmt.Exec(p.Id, p.AccountId, p.Name, p.SortBy, p.SortOrder, p.Archived, p.TeamId)
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	ts, err := scanTasks(rows)
+	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	p.Id = int(id)
 
-	return ts, nil
+	return nil
 }
 
-func GetTaskById(id int) (*Task, error) {
+// Delete removes the project, its tasks are kept but do not belong to any project anymore.
+func (p *Project) Delete() error {
 	db, err := connect()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer db.Close()
 
-	stmt, err := db.Prepare("select * from T_TASKS where ID = ?")
+	tx, err := db.Begin()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer stmt.Close()
 
-	var t Task
-	if err := stmt.QueryRow(id).Scan(&t.Id, &t.AccountId, &t.Created, &t.LastUpdated, &t.Priority, &t.Task); err != nil {
-		return nil, err
-	} else {
-		return &t, nil
+	if _, err := tx.Exec("update T_TASKS set PROJECT_ID = 0, VERSION = VERSION + 1 where PROJECT_ID = ?", p.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_SHARES where PROJECT_ID = ? and PROJECT_ID > 0", p.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_PROJECTS where ID = ?", p.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
 	}
+	p.Id = -1
+
+	return nil
 }
 
-func GetTasksByAccountId(id int) (*Tasks, error) {
+func (v *View) Save() error {
 	db, err := connect()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer db.Close()
 
-	stmt, err := db.Prepare("select * from T_TASKS where ACCOUNT_ID = ? order by PRIORITY desc, LAST_UPDATED asc, CREATED asc")
+	stmt, err := db.Prepare("insert or replace into T_VIEWS (ID, ACCOUNT_ID, NAME, FILTER, SORT, SHARED) values (?,?,?,?,?,?)")
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer stmt.Close()
 
-	rows, err := stmt.Query(id)
+	var result sql.Result
+	if v.Id < 1 {
+		result, err = stmt.Exec(nil, v.AccountId, v.Name, v.Filter, v.Sort, v.Shared)
+	} else {
+		result, err = stmt.Exec(v.Id, v.AccountId, v.Name, v.Filter, v.Sort, v.Shared)
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	ts, err := scanTasks(rows)
+	id, err := result.LastInsertId()
 	if err != nil {
-		return nil, err
+		return err
 	}
+	v.Id = int(id)
 
-	return ts, nil
+	return nil
 }
 
-func GetAllAccounts() (*Accounts, error) {
+func (v *View) Delete() error {
 	db, err := connect()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer db.Close()
 
-	rows, err := db.Query("select * from T_ACCOUNTS order by ID asc")
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	as, err := scanAccounts(rows)
-	if err != nil {
-		return nil, err
+	if _, err := db.Exec("delete from T_VIEWS where ID = ?", v.Id); err != nil {
+		return err
 	}
+	v.Id = -1
 
-	return as, nil
+	return nil
 }
 
-func GetAccountById(id int) (*Account, error) {
+// Save replaces the previous feed token of the account, which stops working right away.
+func (f *FeedToken) Save() error {
 	db, err := connect()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer db.Close()
 
-	stmt, err := db.Prepare("select * from T_ACCOUNTS where ID = ?")
+	if _, err := db.Exec("insert or replace into T_FEED_TOKENS (ACCOUNT_ID, TOKEN_HASH, CREATED) values (?,?,?)", f.AccountId, f.TokenHash, f.Created); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (f *FeedToken) Delete() error {
+	db, err := connect()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer stmt.Close()
+	defer db.Close()
 
-	var a Account
-	if err := stmt.QueryRow(id).Scan(&a.Id, &a.Name, &a.Email, &a.Password, &a.Salt, &a.Role, &a.LastAuth); err != nil {
-		return nil, err
-	} else {
-		return &a, nil
+	if _, err := db.Exec("delete from T_FEED_TOKENS where ACCOUNT_ID = ?", f.AccountId); err != nil {
+		return err
 	}
+	return nil
 }
 
-func GetAccountByEmail(email string) (*Account, error) {
+// Save does not use "insert or replace", since that would silently replace
+// another tag of the account with the same name.
+func (t *Tag) Save() error {
 	db, err := connect()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer db.Close()
 
-	stmt, err := db.Prepare("select * from T_ACCOUNTS where EMAIL = ?")
+	if t.Id > 0 {
+		if _, err := db.Exec("update T_TAGS set ACCOUNT_ID = ?, NAME = ? where ID = ?", t.AccountId, t.Name, t.Id); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	result, err := db.Exec("insert into T_TAGS (ID, ACCOUNT_ID, NAME) values (?,?,?)", nil, t.AccountId, t.Name)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer stmt.Close()
 
-	var a Account
-	if err := stmt.QueryRow(email).Scan(&a.Id, &a.Name, &a.Email, &a.Password, &a.Salt, &a.Role, &a.LastAuth); err != nil {
-		return nil, err
-	} else {
-		return &a, nil
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
 	}
+	t.Id = int(id)
+
+	return nil
 }
 
-func (ts Tasks) Save() error {
+// Delete removes the tag from all its tasks before deleting it.
+func (t *Tag) Delete() error {
 	db, err := connect()
 	if err != nil {
 		return err
@@ -254,112 +3283,181 @@ func (ts Tasks) Save() error {
 		return err
 	}
 
-	stmt, err := tx.Prepare("insert or replace into T_TASKS (ID, ACCOUNT_ID, CREATED, LAST_UPDATED, PRIORITY, TASK) values (?,?,?,?,?,?)")
+	if _, err := tx.Exec("delete from T_TASK_TAGS where TAG_ID = ?", t.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_TAGS where ID = ?", t.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
+	t.Id = -1
 
-	for i, t := range ts {
-		var result sql.Result
-		if t.Id < 1 {
-			result, err = stmt.Exec(nil, t.AccountId, t.Created, t.LastUpdated, t.Priority, t.Task)
-		} else {
-			result, err = stmt.Exec(t.Id, t.AccountId, t.Created, t.LastUpdated, t.Priority, t.Task)
-		}
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		id, err := result.LastInsertId()
-		if err != nil {
-			return err
-		}
-		t.Id = int(id)
+// MergeInto moves all tasks of the tag over to the other tag, and deletes the tag afterwards.
+func (t *Tag) MergeInto(other *Tag) error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
 
-		ts[i] = t
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("insert or ignore into T_TASK_TAGS (TASK_ID, TAG_ID) select TASK_ID, ? from T_TASK_TAGS where TAG_ID = ?", other.Id, t.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_TASK_TAGS where TAG_ID = ?", t.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_TAGS where ID = ?", t.Id); err != nil {
+		tx.Rollback()
+		return err
 	}
 
 	err = tx.Commit()
 	if err != nil {
 		return err
 	}
+	t.Id = -1
 
 	return nil
 }
 
-func (t *Task) Save() error {
-	tasks := Tasks{*t}
-	if err := tasks.Save(); err != nil {
+func (t *Task) AddTag(tag *Tag) error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("insert or ignore into T_TASK_TAGS (TASK_ID, TAG_ID) values (?,?)", t.Id, tag.Id); err != nil {
 		return err
 	}
 
-	t.Id = tasks[0].Id
 	return nil
 }
 
-func (ts Tasks) Delete() error {
+func (t *Task) RemoveTag(tag *Tag) error {
 	db, err := connect()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	tx, err := db.Begin()
+	if _, err := db.Exec("delete from T_TASK_TAGS where TASK_ID = ? and TAG_ID = ?", t.Id, tag.Id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// setTagsTx replaces the tags of the task by those of the given names, tags the account does not have yet are created.
+func (t *Task) setTagsTx(tx *sql.Tx, names []string) error {
+	args := []interface{}{t.Id, t.AccountId}
+	for _, name := range names {
+		args = append(args, name)
+	}
+	if _, err := tx.Exec("delete from T_TASK_TAGS where TASK_ID = ? and TAG_ID not in (select ID from T_TAGS where ACCOUNT_ID = ? and NAME in ("+placeholders(len(names))+"))", args...); err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := tx.Exec("insert or ignore into T_TAGS (ID, ACCOUNT_ID, NAME) values (?,?,?)", nil, t.AccountId, name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("insert or ignore into T_TASK_TAGS (TASK_ID, TAG_ID) select ?, ID from T_TAGS where ACCOUNT_ID = ? and NAME = ?", t.Id, t.AccountId, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Save replaces any previous share of the same task or project with the same account.
+func (s *Share) Save() error {
+	db, err := connect()
 	if err != nil {
 		return err
 	}
+	defer db.Close()
 
-	stmt, err := tx.Prepare("delete from T_TASKS where ID = ?")
+	stmt, err := db.Prepare("insert or replace into T_SHARES (ID, TASK_ID, PROJECT_ID, ACCOUNT_ID, PERMISSION) values (?,?,?,?,?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	for i, t := range ts {
-		if _, err := stmt.Exec(t.Id); err != nil {
-			return err
-		}
-		t.Id = -1
-		ts[i] = t
+	var result sql.Result
+	if s.Id < 1 {
+		result, err = stmt.Exec(nil, s.TaskId, s.ProjectId, s.AccountId, s.Permission)
+	} else {
+		result, err = stmt.Exec(s.Id, s.TaskId, s.ProjectId, s.AccountId, s.Permission)
+	}
+	if err != nil {
+		return err
 	}
 
-	err = tx.Commit()
+	id, err := result.LastInsertId()
 	if err != nil {
 		return err
 	}
+	s.Id = int(id)
 
 	return nil
 }
 
-func (t *Task) Delete() error {
-	tasks := Tasks{*t}
-	if err := tasks.Delete(); err != nil {
+func (s *Share) Delete() error {
+	db, err := connect()
+	if err != nil {
 		return err
 	}
+	defer db.Close()
+
+	stmt, err := db.Prepare("delete from T_SHARES where ID = ?")
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.Exec(s.Id); err != nil {
+		return err
+	}
+	s.Id = -1
 
-	t.Id = -1
 	return nil
 }
 
-func (a *Account) Save() error {
+func (t *Team) Save() error {
 	db, err := connect()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	stmt, err := db.Prepare("insert or replace into T_ACCOUNTS (ID, NAME, EMAIL, PASSWORD, SALT, ROLE, LAST_AUTH) values (?,?,?,?,?,?,?)")
+	stmt, err := db.Prepare("insert or replace into T_TEAMS (ID, NAME) values (?,?)")
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
 	var result sql.Result
-	if a.Id < 1 {
-		result, err = stmt.Exec(nil, a.Name, a.Email, a.Password, a.Salt, a.Role, a.LastAuth)
+	if t.Id < 1 {
+		result, err = stmt.Exec(nil, t.Name)
 	} else {
-		result, err = stmt.Exec(a.Id, a.Name, a.Email, a.Password, a.Salt, a.Role, a.LastAuth)
+		result, err = stmt.Exec(t.Id, t.Name)
 	}
 	if err != nil {
 		return err
@@ -369,28 +3467,71 @@ func (a *Account) Save() error {
 	if err != nil {
 		return err
 	}
-	a.Id = int(id)
+	t.Id = int(id)
 
 	return nil
 }
 
-func (a *Account) Delete() error {
+// Delete removes the team and its members. Projects of the team are kept,
+// and belong only to the account that created them afterwards.
+func (t *Team) Delete() error {
 	db, err := connect()
 	if err != nil {
 		return err
 	}
 	defer db.Close()
 
-	stmt, err := db.Prepare("delete from T_ACCOUNTS where ID = ?")
+	tx, err := db.Begin()
 	if err != nil {
 		return err
 	}
-	defer stmt.Close()
 
-	if _, err := stmt.Exec(a.Id); err != nil {
+	if _, err := tx.Exec("update T_PROJECTS set TEAM_ID = 0 where TEAM_ID = ?", t.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_TEAM_MEMBERS where TEAM_ID = ?", t.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if _, err := tx.Exec("delete from T_TEAMS where ID = ?", t.Id); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	t.Id = -1
+
+	return nil
+}
+
+func (m *Member) Save() error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("insert or replace into T_TEAM_MEMBERS (TEAM_ID, ACCOUNT_ID, ROLE) values (?,?,?)", m.TeamId, m.AccountId, m.Role); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (m *Member) Delete() error {
+	db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("delete from T_TEAM_MEMBERS where TEAM_ID = ? and ACCOUNT_ID = ?", m.TeamId, m.AccountId); err != nil {
 		return err
 	}
-	a.Id = -1
 
 	return nil
 }
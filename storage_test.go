@@ -2,33 +2,37 @@ package main
 
 import "testing"
 import "os"
+import "strings"
+import "fmt"
+import "errors"
+import "database/sql"
 
 func _storage_setup(t *testing.T) {
 	SetDatabase("./data/tasks_test.db")
 	SetupDatabase()
 
-	a1 := Account{-1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890}
+	a1 := Account{Id: -1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1}
 	if err := a1.Save(); err != nil {
 		t.Fatal(err)
 	}
 	if a1.Id != 1 {
 		t.Fatalf("Account ID after calling Save() is not correct. Got [%v], expected [%v]", a1.Id, 1)
 	}
-	a2 := Account{-1, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891}
+	a2 := Account{Id: -1, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1}
 	if err := a2.Save(); err != nil {
 		t.Fatal(err)
 	}
 	if a2.Id != 2 {
 		t.Fatalf("Account ID after calling Save() is not correct. Got [%v], expected [%v]", a2.Id, 2)
 	}
-	a3 := Account{-1, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892}
+	a3 := Account{Id: -1, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1}
 	if err := a3.Save(); err != nil {
 		t.Fatal(err)
 	}
 	if a3.Id != 3 {
 		t.Fatalf("Account ID after calling Save() is not correct. Got [%v], expected [%v]", a3.Id, 3)
 	}
-	a4 := Account{21, "Sonny", "sonny@sunny", "abcd", "999", "None", 1234567895}
+	a4 := Account{Id: 21, Name: "Sonny", Email: "sonny@sunny", Password: "abcd", Salt: "999", Role: "None", LastAuth: 1234567895, Version: 1}
 	if err := a4.Save(); err != nil {
 		t.Fatal(err)
 	}
@@ -49,11 +53,11 @@ func _storage_setup(t *testing.T) {
 	}
 
 	ts := Tasks{
-		{-1, 1, 1234567890, 1234567895, 3, "Buy food!"},
-		{-1, 2, 1234567891, 1234567895, 1, "Get some sleep..."},
-		{-1, 2, 1234567892, 1234567895, 4, "Buy xmas presents!"},
-		{-1, 1, 1234567893, 1234567895, 3, "Buy water!"},
-		{-1, 3, 1234567890, 1234567895, 5, "ALARM!"},
+		{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: -1, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"},
+		{Id: -1, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "Buy xmas presents!", Status: "Open", Version: 1, Rank: "k"},
+		{Id: -1, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "Buy water!", Status: "Open", Version: 1, Rank: "k"},
+		{Id: -1, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "ALARM!", Status: "Open", Version: 1, Rank: "V"},
 	}
 	if err := ts.Save(); err != nil {
 		t.Fatal(err)
@@ -62,7 +66,7 @@ func _storage_setup(t *testing.T) {
 		t.Fatalf("#3 Task ID after calling Save() is not correct. Got [%v], expected [%v]", ts[2].Id, 3)
 	}
 
-	task := Task{-1, 1, 1234567890, 1234567895, 2, "Watch TV.."}
+	task := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 2, Task: "Watch TV..", Status: "Open", Version: 1, Rank: "s"}
 	if err := task.Save(); err != nil {
 		t.Fatal(err)
 	}
@@ -88,12 +92,12 @@ func Test_storage_GetAllTasks(t *testing.T) {
 
 	// GetAllTasks sorts by Priority by default
 	expectedTasks := Tasks{
-		{5, 3, 1234567890, 1234567895, 5, "ALARM!"},
-		{3, 2, 1234567892, 1234567895, 4, "Buy xmas presents!"},
-		{1, 1, 1234567890, 1234567895, 3, "Buy food!"},
-		{4, 1, 1234567893, 1234567895, 3, "Buy water!"},
-		{6, 1, 1234567890, 1234567895, 2, "Watch TV.."},
-		{2, 2, 1234567891, 1234567895, 1, "Get some sleep..."},
+		{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "ALARM!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "Buy xmas presents!", Status: "Open", Version: 1, Rank: "k"},
+		{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "Buy water!", Status: "Open", Version: 1, Rank: "k"},
+		{Id: 6, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 2, Task: "Watch TV..", Status: "Open", Version: 1, Rank: "s"},
+		{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"},
 	}
 	for i, tk := range *tasks {
 		if tk != expectedTasks[i] {
@@ -109,7 +113,7 @@ func Test_storage_GetTaskById(t *testing.T) {
 		t.Error(err)
 	}
 
-	expectedTask := Task{2, 2, 1234567891, 1234567895, 1, "Get some sleep..."}
+	expectedTask := Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"}
 	if *task != expectedTask {
 		t.Errorf("Task is not as expected: [%v], instead of [%v]", task, expectedTask)
 		return
@@ -132,8 +136,8 @@ func Test_storage_GetTasksByAccountId(t *testing.T) {
 
 	// GetTasksByAccountId sorts by Priority by default
 	expectedTasks := Tasks{
-		{3, 2, 1234567892, 1234567895, 4, "Buy xmas presents!"},
-		{2, 2, 1234567891, 1234567895, 1, "Get some sleep..."},
+		{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "Buy xmas presents!", Status: "Open", Version: 1, Rank: "k"},
+		{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"},
 	}
 	for i, tk := range *tasks {
 		if tk != expectedTasks[i] {
@@ -151,11 +155,1648 @@ func Test_storage_GetTasksByAccountId(t *testing.T) {
 	}
 }
 
+func Test_storage_Projects(t *testing.T) {
+	p := Project{-1, 2, "Sleep", "Created", "ASC", 0, 0}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if p.Id != 1 {
+		t.Errorf("Project ID after calling Save() is not correct. Got [%v], expected [%v]", p.Id, 1)
+	}
+
+	project, err := GetProjectById(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if *project != p {
+		t.Errorf("Project is not as expected: [%v], instead of [%v]", project, p)
+	}
+
+	task := Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", ProjectId: 1, Status: "Open", Version: 1, Rank: "V"}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+	}
+
+	tasks, err := GetTasksByProjectId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tasks) != 1 || (*tasks)[0] != task {
+		t.Errorf("Tasks are not as expected: [%v], instead of [%v]", tasks, Tasks{task})
+	}
+
+	projects, err := GetProjectsByAccountId(2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*projects) != 1 || (*projects)[0] != p {
+		t.Errorf("Projects are not as expected: [%v], instead of [%v]", projects, Projects{p})
+	}
+
+	// tasks of archived projects are not returned by GetTasksByAccountId
+	p.Archived = 1234567899
+	if err := p.Save(); err != nil {
+		t.Error(err)
+	}
+	tasks, err = GetTasksByAccountId(2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tasks) != 1 {
+		t.Errorf("Amount of Tasks for archived project is not correct. Got [%v], expected [%v]", len(*tasks), 1)
+	}
+
+	// deleting a project keeps its tasks
+	if err := p.Delete(); err != nil {
+		t.Error(err)
+	}
+	if p.Id != -1 {
+		t.Errorf("Project ID after calling Delete() is not correct. Got [%v], expected [%v]", p.Id, -1)
+	}
+	task2, err := GetTaskById(2)
+	if err != nil {
+		t.Error(err)
+	}
+	if task2.ProjectId != 0 {
+		t.Errorf("Task should not belong to a project anymore: [%v]", task2)
+	}
+	if _, err := GetProjectById(1); err == nil {
+		t.Error("Expected sql error!")
+	}
+}
+
+func Test_storage_Tags(t *testing.T) {
+	home := Tag{-1, 1, "home"}
+	if err := home.Save(); err != nil {
+		t.Fatal(err)
+	}
+	errands := Tag{-1, 1, "Errands"}
+	if err := errands.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if errands.Id != 2 {
+		t.Errorf("Tag ID after calling Save() is not correct. Got [%v], expected [%v]", errands.Id, 2)
+	}
+	duplicate := Tag{-1, 1, "HOME"}
+	if err := duplicate.Save(); err == nil {
+		t.Error("Expected sql error for duplicate tag name!")
+	}
+
+	tag, err := GetTagByName(1, "errands") // tag names are case insensitive
+	if err != nil {
+		t.Error(err)
+	}
+	if *tag != errands {
+		t.Errorf("Tag is not as expected: [%v], instead of [%v]", tag, errands)
+	}
+
+	task1 := Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"}
+	task4 := Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "Buy water!", Status: "Open", Version: 1, Rank: "k"}
+	for _, tg := range []*Tag{&home, &errands} {
+		if err := task1.AddTag(tg); err != nil {
+			t.Error(err)
+		}
+	}
+	if err := task4.AddTag(&errands); err != nil {
+		t.Error(err)
+	}
+	if err := task4.AddTag(&errands); err != nil { // tagging twice does not fail
+		t.Error(err)
+	}
+
+	tags, err := GetTagsByTaskId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tags) != 2 || (*tags)[0] != errands || (*tags)[1] != home {
+		t.Errorf("Tags are not as expected: [%v]", tags)
+	}
+
+	counts, err := CountTaskTags([]string{"HOME", "errands"})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(counts) != 2 || counts[1] != 2 || counts[4] != 1 {
+		t.Errorf("CountTaskTags() is not as expected: [%v]", counts)
+	}
+
+	cloud, err := GetTagCloud(1)
+	if err != nil {
+		t.Error(err)
+	}
+	expectedCloud := TagCloud{{2, "Errands", 2}, {1, "home", 1}}
+	for i, c := range *cloud {
+		if c != expectedCloud[i] {
+			t.Errorf("TagCloud is not as expected: [%v], instead of [%v]", cloud, expectedCloud)
+			return
+		}
+	}
+
+	if err := home.MergeInto(&errands); err != nil {
+		t.Error(err)
+	}
+	if home.Id != -1 {
+		t.Errorf("Tag ID after calling MergeInto() is not correct. Got [%v], expected [%v]", home.Id, -1)
+	}
+	tags, err = GetTagsByTaskId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tags) != 1 || (*tags)[0] != errands {
+		t.Errorf("Tags after MergeInto() are not as expected: [%v]", tags)
+	}
+
+	if err := task4.RemoveTag(&errands); err != nil {
+		t.Error(err)
+	}
+	if err := errands.Delete(); err != nil {
+		t.Error(err)
+	}
+	tags, err = GetTagsByAccountId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tags) != 0 {
+		t.Errorf("Tags should be empty, instead of [%v]", tags)
+	}
+	counts, err = CountTaskTags([]string{"errands"})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(counts) != 0 {
+		t.Errorf("CountTaskTags() should be empty after Delete(), instead of [%v]", counts)
+	}
+}
+
+func Test_storage_Shares(t *testing.T) {
+	p := Project{-1, 1, "Groceries", "", "ASC", 0, 0}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	task := Task{Id: -1, AccountId: 1, Created: 1234567899, LastUpdated: 1234567899, Priority: 1, Task: "Milk", ProjectId: p.Id, Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s1 := Share{-1, 3, 0, 1, "View"}
+	if err := s1.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s2 := Share{-1, 4, 0, 3, "Edit"}
+	if err := s2.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s3 := Share{-1, 0, p.Id, 3, "Manage"}
+	if err := s3.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	share, err := GetShareById(s2.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if *share != s2 {
+		t.Errorf("Share is not as expected: [%v], instead of [%v]", share, s2)
+	}
+
+	shares, err := GetSharesByAccountId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*shares) != 2 || (*shares)[0] != s2 || (*shares)[1] != s3 {
+		t.Errorf("Shares are not as expected: [%v]", shares)
+	}
+
+	// sharing again replaces the previous share
+	s4 := Share{-1, 4, 0, 3, "View"}
+	if err := s4.Save(); err != nil {
+		t.Fatal(err)
+	}
+	shares, err = GetSharesByTaskId(4)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*shares) != 1 || (*shares)[0] != s4 {
+		t.Errorf("Shares are not as expected: [%v]", shares)
+	}
+
+	level, err := GetTaskPermission(&Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "Buy water!", Status: "Open", Version: 1, Rank: "k"}, 3)
+	if err != nil {
+		t.Error(err)
+	}
+	if level != PermissionView {
+		t.Errorf("Task permission is not as expected: [%v], instead of [%v]", level, PermissionView)
+	}
+	level, err = GetTaskPermission(&task, 3) // shared through its project
+	if err != nil {
+		t.Error(err)
+	}
+	if level != PermissionManage {
+		t.Errorf("Task permission is not as expected: [%v], instead of [%v]", level, PermissionManage)
+	}
+	level, err = GetProjectPermission(&p, 2)
+	if err != nil {
+		t.Error(err)
+	}
+	if level != PermissionNone {
+		t.Errorf("Project permission is not as expected: [%v], instead of [%v]", level, PermissionNone)
+	}
+
+	tasks, err := GetVisibleTasksByAccountId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	expectedTasks := Tasks{
+		{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "ALARM!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "Buy water!", Status: "Open", Version: 1, Rank: "k"},
+		task,
+	}
+	if len(*tasks) != len(expectedTasks) {
+		t.Errorf("Tasks are not as expected: [%v], instead of [%v]", tasks, expectedTasks)
+	}
+	for i, tk := range *tasks {
+		if tk != expectedTasks[i] {
+			t.Errorf("Tasks are not as expected: [%v], instead of [%v]", tasks, expectedTasks)
+			return
+		}
+	}
+
+	projects, err := GetVisibleProjectsByAccountId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*projects) != 1 || (*projects)[0] != p {
+		t.Errorf("Projects are not as expected: [%v]", projects)
+	}
+
+	// deleting shared items removes their shares
+	if err := task.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := p.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := s1.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := s4.Delete(); err != nil {
+		t.Error(err)
+	}
+	shares, err = GetSharesByAccountId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*shares) != 0 {
+		t.Errorf("Shares should be empty, instead of [%v]", shares)
+	}
+}
+
+func Test_storage_Teams(t *testing.T) {
+	team := Team{-1, "Release"}
+	if err := team.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if team.Id != 1 {
+		t.Errorf("Team ID after calling Save() is not correct. Got [%v], expected [%v]", team.Id, 1)
+	}
+	for _, m := range (Members{{team.Id, 1, "Owner"}, {team.Id, 3, "Viewer"}}) {
+		if err := m.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := Project{-1, 1, "Release 1.0", "", "ASC", 0, team.Id}
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	task := Task{Id: -1, AccountId: 1, Created: 1234567899, LastUpdated: 1234567899, Priority: 1, Task: "Tag release", ProjectId: p.Id, Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	teams, err := GetTeamsByAccountId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*teams) != 1 || (*teams)[0] != team {
+		t.Errorf("Teams are not as expected: [%v]", teams)
+	}
+
+	member, err := GetMember(team.Id, 3)
+	if err != nil {
+		t.Error(err)
+	}
+	if member.Role != "Viewer" {
+		t.Errorf("Member is not as expected: [%v]", member)
+	}
+
+	level, err := GetTaskPermission(&task, 3)
+	if err != nil {
+		t.Error(err)
+	}
+	if level != PermissionView {
+		t.Errorf("Task permission is not as expected: [%v], instead of [%v]", level, PermissionView)
+	}
+	level, err = GetProjectPermission(&p, 2) // not a member
+	if err != nil {
+		t.Error(err)
+	}
+	if level != PermissionNone {
+		t.Errorf("Project permission is not as expected: [%v], instead of [%v]", level, PermissionNone)
+	}
+
+	tasks, err := GetTasksByTeamId(team.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tasks) != 1 || (*tasks)[0] != task {
+		t.Errorf("Tasks are not as expected: [%v]", tasks)
+	}
+	projects, err := GetProjectsByTeamId(team.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*projects) != 1 || (*projects)[0] != p {
+		t.Errorf("Projects are not as expected: [%v]", projects)
+	}
+
+	// members see the tasks and projects of their team, without owning them
+	contains := func(ts *Tasks, id int) bool {
+		for _, t := range *ts {
+			if t.Id == id {
+				return true
+			}
+		}
+		return false
+	}
+	tasks, err = GetVisibleTasksByAccountId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	if !contains(tasks, task.Id) {
+		t.Errorf("Visible tasks of a team member should contain [%v]: [%v]", task, tasks)
+	}
+	projects, err = GetVisibleProjectsByAccountId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*projects) != 1 || (*projects)[0] != p {
+		t.Errorf("Visible projects of a team member are not as expected: [%v]", projects)
+	}
+	if searchAvailable {
+		results, err := SearchTasks(3, "release", 10)
+		if err != nil || len(*results) != 1 || (*results)[0].Task.Id != task.Id {
+			t.Errorf("SearchTasks() of a team member returned [%v], [%v]", results, err)
+		}
+	}
+	tasks, err = GetVisibleTasksByAccountId(2) // not a member
+	if err != nil {
+		t.Error(err)
+	}
+	if contains(tasks, task.Id) {
+		t.Errorf("Visible tasks of a non-member should not contain [%v]", task)
+	}
+
+	if err := member.Delete(); err != nil {
+		t.Error(err)
+	}
+	members, err := GetMembersByTeamId(team.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*members) != 1 || (*members)[0].AccountId != 1 {
+		t.Errorf("Members are not as expected: [%v]", members)
+	}
+
+	// deleting a team keeps its projects
+	if err := team.Delete(); err != nil {
+		t.Error(err)
+	}
+	project, err := GetProjectById(p.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if project.TeamId != 0 {
+		t.Errorf("Project should not belong to a team anymore: [%v]", project)
+	}
+	members, err = GetMembersByTeamId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*members) != 0 {
+		t.Errorf("Members should be empty, instead of [%v]", members)
+	}
+
+	if err := task.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := p.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_Assignments(t *testing.T) {
+	task := Task{Id: -1, AccountId: 1, Created: 1234567899, LastUpdated: 1234567899, Priority: 1, Task: "Review release notes", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	assignment, err := task.Assign(2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.AssigneeId != 2 || assignment.TaskId != task.Id || assignment.AssignedBy != 1 {
+		t.Errorf("Assign() returned [%v] for task [%v]", assignment, task)
+	}
+	if _, err := task.Assign(3, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := GetTasksByAssigneeId(3)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tasks) != 1 || (*tasks)[0] != task {
+		t.Errorf("Assigned tasks are not as expected: [%v], instead of [%v]", tasks, task)
+	}
+	tasks, err = GetTasksByAssigneeId(2)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tasks) != 0 {
+		t.Errorf("Assigned tasks should be empty, instead of [%v]", tasks)
+	}
+
+	level, err := GetTaskPermission(&task, 3)
+	if err != nil {
+		t.Error(err)
+	}
+	if level != PermissionView {
+		t.Errorf("Task permission of assignee is not as expected: [%v], instead of [%v]", level, PermissionView)
+	}
+
+	assignments, err := GetAssignmentsByTaskId(task.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*assignments) != 2 ||
+		(*assignments)[0].AssigneeId != 2 || (*assignments)[0].AssignedBy != 1 ||
+		(*assignments)[1].AssigneeId != 3 || (*assignments)[1].AssignedBy != 2 {
+		t.Errorf("Assignments are not as expected: [%v]", assignments)
+	}
+
+	if err := task.Delete(); err != nil {
+		t.Error(err)
+	}
+	assignments, err = GetAssignmentsByTaskId(assignment.TaskId)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*assignments) != 0 {
+		t.Errorf("Assignments should be deleted together with their task, instead of [%v]", assignments)
+	}
+}
+
+func Test_storage_Comments(t *testing.T) {
+	task := Task{Id: -1, AccountId: 1, Created: 1234567899, LastUpdated: 1234567899, Priority: 1, Task: "Discuss release", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, err := GetAccountsByNames([]string{"CLUDE", "ozzie", "nobody"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*accounts) != 2 || (*accounts)[0].Id != 2 || (*accounts)[1].Id != 3 {
+		t.Errorf("Accounts are not as expected: [%v]", accounts)
+	}
+
+	c1 := Comment{-1, task.Id, 1, 1234567890, 1234567890, "**Ready** to ship, @Clude?", []int{2}}
+	c2 := Comment{-1, task.Id, 2, 1234567891, 1234567891, "Yes", []int{}}
+	if err := c1.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c2.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	c1.Body = "**Ready** to ship, @Clude and @ozzie?"
+	c1.Mentions = []int{2, 3}
+	if err := c1.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	comment, err := GetCommentById(c1.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.Body != c1.Body || len(comment.Mentions) != 2 || comment.Mentions[0] != 2 || comment.Mentions[1] != 3 {
+		t.Errorf("Comment is not as expected: [%v], instead of [%v]", comment, c1)
+	}
+
+	comments, err := GetCommentsByTaskId(task.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*comments) != 2 || (*comments)[0].Id != c1.Id || (*comments)[1].Id != c2.Id || len((*comments)[1].Mentions) != 0 {
+		t.Errorf("Comments are not as expected: [%v]", comments)
+	}
+
+	if err := c2.Delete(); err != nil {
+		t.Error(err)
+	}
+	comments, err = GetCommentsByTaskId(task.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*comments) != 1 {
+		t.Errorf("Comments after Delete() are not as expected: [%v]", comments)
+	}
+
+	if err := task.Delete(); err != nil {
+		t.Error(err)
+	}
+	if _, err := GetCommentById(c1.Id); err == nil {
+		t.Errorf("Comment [%v] should be deleted together with its task", c1)
+	}
+}
+
+func Test_storage_Attachments(t *testing.T) {
+	store := NewMemoryBlobStore()
+	SetBlobStore(store)
+	defer SetBlobStore(NewLocalBlobStore("./data/attachments"))
+
+	task := Task{Id: -1, AccountId: 1, Created: 1234567899, LastUpdated: 1234567899, Priority: 1, Task: "Fix layout", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	a1 := Attachment{-1, task.Id, 1, "screenshot.png", "image/png", 8, 1234567890, "key1"}
+	a2 := Attachment{-1, task.Id, 2, "debug.log", "text/plain; charset=utf-8", 5, 1234567891, "key2"}
+	for _, a := range []*Attachment{&a1, &a2} {
+		if err := store.Put(a.BlobKey, strings.NewReader("data")); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	attachment, err := GetAttachmentById(a1.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if *attachment != a1 {
+		t.Errorf("Attachment is not as expected: [%v], instead of [%v]", attachment, a1)
+	}
+
+	if err := a1.Delete(); err != nil {
+		t.Error(err)
+	}
+	attachments, err := GetAttachmentsByTaskId(task.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*attachments) != 1 || (*attachments)[0] != a2 {
+		t.Errorf("Attachments are not as expected: [%v]", attachments)
+	}
+	if store.Len() != 1 {
+		t.Errorf("Blob of deleted attachment should be removed, [%v] blobs left", store.Len())
+	}
+
+	// deleting a task removes all of its attachments
+	if err := task.Delete(); err != nil {
+		t.Error(err)
+	}
+	if _, err := GetAttachmentById(a2.Id); err == nil {
+		t.Errorf("Attachment [%v] should be deleted together with its task", a2)
+	}
+	if store.Len() != 0 {
+		t.Errorf("Blobs should be removed together with their task, [%v] blobs left", store.Len())
+	}
+}
+
+func Test_storage_History(t *testing.T) {
+	changes := Changes{
+		{-1, EntityTask, 99, ActionCreate, 1, 1234567890, "Task", "", "Audit me"},
+		{-1, EntityTask, 99, ActionUpdate, 2, 1234567895, "Priority", "1", "2"},
+		{-1, EntityAccount, 2, ActionUpdate, 1, 1234567899, "Name", "Clude", "Cluderzky"},
+	}
+	if err := changes.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if changes[0].Id < 1 || changes[2].Id <= changes[1].Id {
+		t.Errorf("Change IDs after calling Save() are not correct: [%v]", changes)
+	}
+
+	history, err := GetChangesByEntity(EntityTask, 99)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*history) != 2 || (*history)[0] != changes[0] || (*history)[1] != changes[1] {
+		t.Errorf("History is not as expected: [%v]", history)
+	}
+
+	var tests = []struct {
+		filter   AuditFilter
+		expected []int
+	}{
+		{AuditFilter{}, []int{0, 1, 2}},
+		{AuditFilter{AccountId: 1}, []int{0, 2}},
+		{AuditFilter{Entity: EntityAccount}, []int{2}},
+		{AuditFilter{Entity: EntityTask, EntityId: 99, AccountId: 2}, []int{1}},
+		{AuditFilter{From: 1234567891, To: 1234567898}, []int{1}},
+	}
+	for _, test := range tests {
+		log, err := GetChanges(test.filter)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if len(*log) != len(test.expected) {
+			t.Errorf("Audit log for [%v] is not as expected: [%v]", test.filter, log)
+			continue
+		}
+		for i, c := range *log {
+			if c != changes[test.expected[i]] {
+				t.Errorf("Audit log for [%v] is not as expected: [%v]", test.filter, log)
+				break
+			}
+		}
+	}
+}
+
+func Test_storage_Transaction(t *testing.T) {
+	steps, err := GetUndoStepsByAccountId(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the history and undo step of a change are rolled back together with the change
+	task := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Roll me back", Status: "Open", Version: 1}
+	err = transaction(func(tx *sql.Tx) error {
+		if err := task.saveTx(tx); err != nil {
+			return err
+		}
+		if err := DiffChanges(EntityTask, task.Id, ActionCreate, 1, 1234567890, Task{}, task).saveTx(tx); err != nil {
+			return err
+		}
+		step := UndoStep{-1, 1, 1234567890, []TaskChange{{nil, &task}}}
+		if err := step.saveTx(tx); err != nil {
+			return err
+		}
+		return task.saveIfMatchTx(tx, task.Version+1)
+	})
+	if err != ErrVersionMismatch {
+		t.Fatalf("transaction() should fail with [%v], but returned [%v]", ErrVersionMismatch, err)
+	}
+	if _, err := GetTaskById(task.Id); err == nil {
+		t.Errorf("Task should not have been saved: [%v]", task)
+	}
+	history, err := GetChangesByEntity(EntityTask, task.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*history) != 0 {
+		t.Errorf("History should not have been saved: [%v]", history)
+	}
+	after, err := GetUndoStepsByAccountId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*after) != len(*steps) {
+		t.Errorf("Undo step should not have been saved: [%v]", after)
+	}
+}
+
+func Test_storage_Trash(t *testing.T) {
+	t1 := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Old trash", Status: "Open", Version: 1}
+	t2 := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Fresh trash", Status: "Open", Version: 1}
+	if err := (&Tasks{t1, t2}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	tasks, err := GetTasksByAccountId(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := len(*tasks)
+	t1, t2 = (*tasks)[before-2], (*tasks)[before-1]
+
+	if err := (Tasks{t1}).Trash(1000); err != nil {
+		t.Fatal(err)
+	}
+	if err := t2.Trash(); err != nil {
+		t.Fatal(err)
+	}
+	if !t2.IsDeleted() {
+		t.Errorf("Task should be marked as deleted after Trash(): [%v]", t2)
+	}
+
+	if _, err := GetTaskById(t2.Id); err == nil {
+		t.Errorf("GetTaskById() should not return tasks in the trash")
+	}
+	trashed, err := GetTrashedTaskById(t2.Id)
+	if err != nil {
+		t.Error(err)
+	}
+	if trashed.Task != "Fresh trash" {
+		t.Errorf("Trashed task is not as expected: [%v]", trashed)
+	}
+	tasks, err = GetTasksByAccountId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tasks) != before-2 {
+		t.Errorf("GetTasksByAccountId() should not return tasks in the trash: [%v]", tasks)
+	}
+	tasks, err = GetTrashedTasksByAccountId(1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tasks) != 2 || (*tasks)[0].Id != t2.Id {
+		t.Errorf("Trashed tasks are not as expected: [%v]", tasks)
+	}
+
+	a := Account{Id: -1, Name: "Trashy", Email: "trashy@trash", Password: "abcd", Salt: "123", Role: "User", LastAuth: 0, Version: 1}
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Trash(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetAccountById(a.Id); err == nil {
+		t.Errorf("GetAccountById() should not return accounts in the trash")
+	}
+	if err := a.Restore(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetAccountById(a.Id); err != nil {
+		t.Errorf("GetAccountById() after Restore() returned [%v]", err)
+	}
+	if err := a.trash(1000, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// only items trashed before the given timestamp are purged
+	purged, err := PurgeTrash(2000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 2 {
+		t.Errorf("PurgeTrash() purged [%v] items, instead of [%v]", purged, 2)
+	}
+	if _, err := GetTrashedTaskById(t1.Id); err == nil {
+		t.Errorf("Task [%v] should have been purged", t1)
+	}
+	if _, err := GetTrashedAccountById(a.Id); err == nil {
+		t.Errorf("Account [%v] should have been purged", a)
+	}
+	changes, err := GetChanges(AuditFilter{Entity: EntityTask, EntityId: t1.Id})
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*changes) == 0 || (*changes)[0].Action != ActionPurge {
+		t.Errorf("Purging should be recorded in the history: [%v]", changes)
+	}
+
+	if err := t2.Restore(); err != nil {
+		t.Error(err)
+	}
+	if err := t2.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_PurgeAccount(t *testing.T) {
+	a := Account{Id: -1, Name: "Purgy", Email: "purgy@purge", Password: "abcd", Salt: "123", Role: "User", LastAuth: 0, Version: 1}
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+	id := a.Id
+	project := Project{-1, id, "Purged", "", "ASC", 0, 0}
+	if err := project.Save(); err != nil {
+		t.Fatal(err)
+	}
+	own := Task{Id: -1, AccountId: id, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Purged task", ProjectId: project.Id, Status: "Open", Version: 1}
+	other := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Shared task", AssigneeId: id, Status: "Open", Version: 1}
+	if err := (&own).Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&other).Save(); err != nil {
+		t.Fatal(err)
+	}
+	tag := Tag{-1, id, "purged"}
+	if err := tag.Save(); err != nil {
+		t.Fatal(err)
+	}
+	view := View{-1, id, "Purged", "", "", 0}
+	if err := view.Save(); err != nil {
+		t.Fatal(err)
+	}
+	share := Share{-1, other.Id, 0, id, "Edit"}
+	if err := share.Save(); err != nil {
+		t.Fatal(err)
+	}
+	team := Team{-1, "Purgers"}
+	if err := team.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Member{team.Id, id, "Owner"}).Save(); err != nil {
+		t.Fatal(err)
+	}
+	step := UndoStep{-1, id, 1234567890, []TaskChange{{nil, &own}}}
+	if err := step.Save(); err != nil {
+		t.Fatal(err)
+	}
+	token, _, err := NewFeedToken(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := token.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the feed token does not even survive the trash
+	if err := a.Trash(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetFeedTokenByAccountId(id); err == nil {
+		t.Error("Feed token should have been deleted along with trashing the account")
+	}
+	if err := token.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := a.Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the id of the purged account is given to the next new account, which must not inherit anything
+	b := Account{Id: -1, Name: "Heir", Email: "heir@purge", Password: "abcd", Salt: "123", Role: "User", LastAuth: 0, Version: 1}
+	if err := b.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if b.Id != id {
+		t.Fatalf("New account got id [%v], instead of the purged id [%v]", b.Id, id)
+	}
+	if tasks, err := GetTasksByAccountId(id); err != nil || len(*tasks) != 0 {
+		t.Errorf("New account inherited tasks: [%v], [%v]", tasks, err)
+	}
+	if projects, err := GetProjectsByAccountId(id); err != nil || len(*projects) != 0 {
+		t.Errorf("New account inherited projects: [%v], [%v]", projects, err)
+	}
+	if tags, err := GetTagsByAccountId(id); err != nil || len(*tags) != 0 {
+		t.Errorf("New account inherited tags: [%v], [%v]", tags, err)
+	}
+	if views, err := GetViewsByAccountId(id); err != nil || len(*views) != 0 {
+		t.Errorf("New account inherited views: [%v], [%v]", views, err)
+	}
+	if shares, err := GetSharesByAccountId(id); err != nil || len(*shares) != 0 {
+		t.Errorf("New account inherited shares: [%v], [%v]", shares, err)
+	}
+	if teams, err := GetTeamsByAccountId(id); err != nil || len(*teams) != 0 {
+		t.Errorf("New account inherited teams: [%v], [%v]", teams, err)
+	}
+	if steps, err := GetUndoStepsByAccountId(id); err != nil || len(*steps) != 0 {
+		t.Errorf("New account inherited undo steps: [%v], [%v]", steps, err)
+	}
+	if _, err := GetFeedTokenByAccountId(id); err == nil {
+		t.Error("New account inherited the feed token")
+	}
+	if task, err := GetTaskById(other.Id); err != nil || task.AssigneeId != 0 {
+		t.Errorf("New account inherited the assignment: [%v], [%v]", task, err)
+	}
+	if _, err := GetTaskById(own.Id); err == nil {
+		t.Error("Task of the purged account should have been deleted")
+	}
+
+	if err := b.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := (Tasks{other}).Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := team.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_Undo(t *testing.T) {
+	task := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Undo me", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+	old := task
+	task.Priority = 5
+	task.Task = "Overwritten"
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	step := UndoStep{-1, 1, 1234567890, []TaskChange{{&old, &task}}}
+	if err := step.Save(); err != nil {
+		t.Fatal(err)
+	}
+	steps, err := GetUndoStepsByAccountId(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*steps) == 0 || (*steps)[0].Id != step.Id || *(*steps)[0].Changes[0].Before != old {
+		t.Errorf("Undo steps are not as expected: [%v]", steps)
+	}
+
+	// undoing is a change as well, and increments the version
+	expected := old
+	expected.Version = task.Version + 1
+	restored, err := step.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(restored) != 1 || restored[0] != expected {
+		t.Errorf("Undo() returned [%v], instead of [%v]", restored, expected)
+	}
+	current, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *current != expected {
+		t.Errorf("Task after Undo() is [%v], instead of [%v]", current, expected)
+	}
+	steps, err = GetUndoStepsByAccountId(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*steps) > 0 && (*steps)[0].Id == step.Id {
+		t.Errorf("Undo() should remove the step from the undo stack")
+	}
+
+	// a later change by someone else must not be overwritten
+	created := UndoStep{-1, 1, 1234567890, []TaskChange{{nil, &old}}}
+	if err := created.Save(); err != nil {
+		t.Fatal(err)
+	}
+	current.Priority = 3
+	if err := current.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := created.Undo(); err != ErrUndoConflict {
+		t.Errorf("Undo() should fail with [%v], but returned [%v]", ErrUndoConflict, err)
+	}
+	if _, err := GetTaskById(task.Id); err != nil {
+		t.Errorf("Task should not be trashed by a conflicting Undo(): [%v]", err)
+	}
+
+	// undoing a creation moves the task to the trash
+	created.Changes[0].After = current
+	restored, err = created.Undo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !restored[0].IsDeleted() {
+		t.Errorf("Undo() of a created task should trash it: [%v]", restored)
+	}
+	if _, err := GetTrashedTaskById(task.Id); err != nil {
+		t.Error(err)
+	}
+
+	// only the most recent steps are kept
+	SetUndoDepth(2)
+	defer SetUndoDepth(20)
+	for i := 0; i < 3; i++ {
+		step := UndoStep{-1, 1, 1234567890 + i, []TaskChange{{&old, &task}}}
+		if err := step.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	steps, err = GetUndoStepsByAccountId(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*steps) != 2 || (*steps)[0].Created != 1234567892 || (*steps)[1].Created != 1234567891 {
+		t.Errorf("Undo stack is not as expected: [%v]", steps)
+	}
+}
+
+func Test_storage_Versions(t *testing.T) {
+	task := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Versioned", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if task.Version != 1 {
+		t.Errorf("Version of a new task is [%v], instead of [%v]", task.Version, 1)
+	}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if task.Version != 2 {
+		t.Errorf("Version after Save() is [%v], instead of [%v]", task.Version, 2)
+	}
+
+	task.Priority = 3
+	if err := task.SaveIfMatch(1); err != ErrVersionMismatch {
+		t.Errorf("SaveIfMatch() with an old version should fail with [%v], but returned [%v]", ErrVersionMismatch, err)
+	}
+	stored, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Priority != 1 || stored.Version != 2 {
+		t.Errorf("Task should not have been saved: [%v]", stored)
+	}
+	if err := task.SaveIfMatch(2); err != nil {
+		t.Error(err)
+	}
+	if task.Version != 3 {
+		t.Errorf("Version after SaveIfMatch() is [%v], instead of [%v]", task.Version, 3)
+	}
+	if err := task.SaveIfMatch(0); err != nil {
+		t.Error(err)
+	}
+
+	if err := task.TrashIfMatch(3); err != ErrVersionMismatch {
+		t.Errorf("TrashIfMatch() with an old version should fail with [%v], but returned [%v]", ErrVersionMismatch, err)
+	}
+	if err := task.TrashIfMatch(4); err != nil {
+		t.Error(err)
+	}
+	if task.Version != 5 || !task.IsDeleted() {
+		t.Errorf("Task after TrashIfMatch() is not as expected: [%v]", task)
+	}
+	if err := task.Restore(); err != nil {
+		t.Error(err)
+	}
+
+	missing := Task{Id: 9999, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Missing", Status: "Open", Version: 1}
+	if err := missing.SaveIfMatch(0); err != ErrVersionMismatch {
+		t.Errorf("SaveIfMatch() of a missing task should fail with [%v], but returned [%v]", ErrVersionMismatch, err)
+	}
+
+	a := Account{Id: -1, Name: "Versioned", Email: "versioned@version", Password: "abcd", Salt: "123", Role: "User", LastAuth: 0, Version: 1}
+	if err := a.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.SaveIfMatch(2); err != ErrVersionMismatch {
+		t.Errorf("SaveIfMatch() with another version should fail with [%v], but returned [%v]", ErrVersionMismatch, err)
+	}
+	if err := a.SaveIfMatch(1); err != nil || a.Version != 2 {
+		t.Errorf("SaveIfMatch() returned [%v] with version [%v]", err, a.Version)
+	}
+	if err := a.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := task.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_SaveAndTrash(t *testing.T) {
+	existing := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Bulk trash", Status: "Open", Version: 1}
+	if err := existing.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	saves := Tasks{{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 2, Task: "Bulk create", Status: "Open", Version: 1}}
+	trashes := Tasks{existing}
+	if err := SaveAndTrash(saves, trashes); err != nil {
+		t.Fatal(err)
+	}
+	if saves[0].Id < 1 || saves[0].Version != 1 {
+		t.Errorf("Saved task is not as expected: [%v]", saves[0])
+	}
+	if !trashes[0].IsDeleted() || trashes[0].Version != 2 {
+		t.Errorf("Trashed task is not as expected: [%v]", trashes[0])
+	}
+	if _, err := GetTrashedTaskById(existing.Id); err != nil {
+		t.Error(err)
+	}
+
+	if err := (Tasks{saves[0], trashes[0]}).Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_Ranks(t *testing.T) {
+	first := Task{Id: -1, AccountId: 4, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Ranked first", Status: "Open", Version: 1}
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+	second := Task{Id: -1, AccountId: 4, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Ranked second", Status: "Open", Version: 1}
+	if err := second.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if first.Rank == "" || second.Rank <= first.Rank {
+		t.Errorf("New tasks should be ranked at the end of their list: [%v], [%v]", first.Rank, second.Rank)
+	}
+
+	moved := Tasks{second}
+	moved[0].Rank = RankBetween("", first.Rank)
+	if err := moved.SaveRanks(); err != nil {
+		t.Error(err)
+	}
+	if moved[0].Version != 2 {
+		t.Errorf("SaveRanks() should increase the version: [%v]", moved[0])
+	}
+
+	list, err := GetTaskList(&first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := len(*list)
+	if n < 2 || (*list)[0].Id != second.Id || (*list)[n-1].Id != first.Id {
+		t.Errorf("GetTaskList() is not in manual order: [%v]", list)
+	}
+
+	if err := (Tasks{first, moved[0]}).Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_NormalizePriorities(t *testing.T) {
+	tasks := Tasks{
+		{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 9999, Task: "Too important", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: -5, Task: "Too unimportant", Status: "Open", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := NormalizePriorities()
+	if err != nil || n != 2 {
+		t.Errorf("NormalizePriorities() returned [%v], [%v]", n, err)
+	}
+
+	for i, expected := range []Priority{5, 1} {
+		task, err := GetTaskById(tasks[i].Id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if task.Priority != expected || task.Version != 2 {
+			t.Errorf("Task after NormalizePriorities() is not as expected: [%v]", task)
+		}
+	}
+
+	if n, err := NormalizePriorities(); err != nil || n != 0 {
+		t.Errorf("NormalizePriorities() should have nothing left to change, but returned [%v], [%v]", n, err)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_MigratePriorities(t *testing.T) {
+	defaultScale := GetPriorityScale()
+	defer SetPriorityScale(defaultScale)
+
+	// the default scale is recorded on the first start, without anything to change
+	if n, err := MigratePriorities(false); err != nil || n != 0 {
+		t.Errorf("MigratePriorities() returned [%v], [%v]", n, err)
+	}
+
+	task := Task{Id: -1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567890, Priority: 5, Task: "Urgent", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetPriorityScale(PriorityScale{1, 3, nil}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a narrower scale is refused as long as priorities are not to be normalized
+	if n, err := MigratePriorities(false); err != ErrPrioritiesOutOfScale || n < 1 {
+		t.Errorf("MigratePriorities() should refuse the narrower scale, but returned [%v], [%v]", n, err)
+	}
+	if stored, err := GetTaskById(task.Id); err != nil || stored.Priority != 5 || stored.Version != 1 {
+		t.Errorf("Task after refused MigratePriorities() should not have changed: [%v], [%v]", stored, err)
+	}
+
+	if n, err := MigratePriorities(true); err != nil || n < 1 {
+		t.Errorf("MigratePriorities() returned [%v], [%v]", n, err)
+	}
+	if stored, err := GetTaskById(task.Id); err != nil || stored.Priority != 3 || stored.Version != 2 {
+		t.Errorf("Task after MigratePriorities() is not as expected: [%v], [%v]", stored, err)
+	}
+
+	// the scale is only migrated once, even if priorities outside of it were stored since
+	task.Priority = 5
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := MigratePriorities(false); err != nil || n != 0 {
+		t.Errorf("MigratePriorities() should have been done with this scale, but returned [%v], [%v]", n, err)
+	}
+	if stored, err := GetTaskById(task.Id); err != nil || stored.Priority != 5 {
+		t.Errorf("Task after second MigratePriorities() should not have changed: [%v], [%v]", stored, err)
+	}
+
+	if err := (Tasks{task}).Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_SearchTasks(t *testing.T) {
+	if !searchAvailable {
+		if _, err := SearchTasks(2, "milk", 10); err != ErrSearchUnavailable {
+			t.Errorf("SearchTasks() without FTS5 should fail with [%v], but returned [%v]", ErrSearchUnavailable, err)
+		}
+		t.Skip("SQLite was built without FTS5")
+	}
+
+	tasks := Tasks{
+		{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Search the milky way", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Search for milk, milk and more milk", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 3, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Search milk secretly", Status: "Open", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		query    string
+		expected []int
+	}{
+		{"milk", []int{tasks[1].Id}},
+		{"milk*", []int{tasks[1].Id, tasks[0].Id}},
+		{`"milky way"`, []int{tasks[0].Id}},
+		{`"way milky"`, []int{}},
+		{"search NOT milk", []int{tasks[0].Id}},
+		{"milky OR secretly", []int{tasks[0].Id}}, // the task of AccountId 3 is not visible
+	}
+	for _, test := range tests {
+		results, err := SearchTasks(2, test.query, 10)
+		if err != nil {
+			t.Errorf("SearchTasks() for [%v] failed: [%v]", test.query, err)
+			continue
+		}
+		ids := []int{}
+		for _, r := range *results {
+			ids = append(ids, r.Task.Id)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(test.expected) {
+			t.Errorf("SearchTasks() for [%v] returned [%v], instead of [%v]", test.query, ids, test.expected)
+		}
+	}
+
+	results, err := SearchTasks(2, `"milky way"`, 10)
+	if err != nil || len(*results) != 1 || (*results)[0].Snippet != "Search the <mark>milky way</mark>" {
+		t.Errorf("SearchTasks() did not highlight the matching terms: [%v], [%v]", results, err)
+	}
+
+	if _, err := SearchTasks(2, `"milky`, 10); err != ErrInvalidSearch {
+		t.Errorf("SearchTasks() with an invalid query should fail with [%v], but returned [%v]", ErrInvalidSearch, err)
+	}
+
+	// the index follows changes of the tasks
+	tasks[0].Task = "Search the galaxy"
+	if err := tasks[0].Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tasks[1].Trash(); err != nil {
+		t.Fatal(err)
+	}
+	if results, err := SearchTasks(2, "milk*", 10); err != nil || len(*results) != 0 {
+		t.Errorf("SearchTasks() after changes returned [%v], [%v]", results, err)
+	}
+	if results, err := SearchTasks(2, "galaxy", 10); err != nil || len(*results) != 1 {
+		t.Errorf("SearchTasks() after changes returned [%v], [%v]", results, err)
+	}
+
+	// a rebuilt index finds the same tasks
+	if err := SetupSearchIndex(); err != nil {
+		t.Error(err)
+	}
+	if results, err := SearchTasks(2, "galaxy", 10); err != nil || len(*results) != 1 {
+		t.Errorf("SearchTasks() after rebuilding the index returned [%v], [%v]", results, err)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+	if results, err := SearchTasks(3, "secretly", 10); err != nil || len(*results) != 0 {
+		t.Errorf("SearchTasks() after deleting returned [%v], [%v]", results, err)
+	}
+}
+
+func Test_storage_FilterTasks(t *testing.T) {
+	tasks := Tasks{
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 5, Task: "Filter: buy 100% milk", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067300, LastUpdated: 1704067300, Priority: 2, Task: "Filter: buy bread", Status: "Done", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067300, LastUpdated: 1704067300, Priority: 4, Task: "Filter: sell 100 apples", Status: "Open", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		expr     string
+		expected []int
+	}{
+		{`text~"filter:" AND priority>=3`, []int{tasks[0].Id, tasks[2].Id}},
+		{`text~"filter:" AND created>2024-01-01`, []int{tasks[2].Id, tasks[1].Id}},
+		{`text~"FILTER: BUY"`, []int{tasks[0].Id, tasks[1].Id}},
+		{`text~"100%"`, []int{tasks[0].Id}},
+		{`text~"filter:" AND NOT status=Open`, []int{tasks[1].Id}},
+		{`text~"filter:" AND (priority=urgent OR priority=minor)`, []int{tasks[0].Id, tasks[1].Id}},
+		{`text~"filter:" AND account=1`, []int{}},
+	}
+	for _, test := range tests {
+		filter, err := ParseFilter(test.expr)
+		if err != nil {
+			t.Errorf("ParseFilter of [%v] failed: [%v]", test.expr, err)
+			continue
+		}
+		ts, err := GetVisibleTasksByAccountIdAndFilter(2, filter, nil, Page{})
+		if err != nil {
+			t.Errorf("GetVisibleTasksByAccountIdAndFilter() for [%v] failed: [%v]", test.expr, err)
+			continue
+		}
+		ids := []int{}
+		for _, task := range *ts {
+			ids = append(ids, task.Id)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(test.expected) {
+			t.Errorf("GetVisibleTasksByAccountIdAndFilter() for [%v] returned [%v], instead of [%v]", test.expr, ids, test.expected)
+		}
+	}
+
+	// the filter does not widen the visible tasks
+	filter, _ := ParseFilter(`account=1`)
+	if ts, err := GetVisibleTasksByAccountIdAndFilter(2, filter, nil, Page{}); err != nil || len(*ts) != 0 {
+		t.Errorf("GetVisibleTasksByAccountIdAndFilter() returned tasks of another account: [%v], [%v]", ts, err)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_PageTasks(t *testing.T) {
+	tasks := Tasks{
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 3, Task: "Paging: one", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 1, Task: "Paging: two", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 3, Task: "Paging: three", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 2, Task: "Paging: four", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 3, Task: "Paging: five", Status: "Open", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+	filter, _ := ParseFilter(`text~"paging:"`)
+	keys := SortKeys{{"priority", true}}
+	getPage := func(page Page) ([]int, PageResult) {
+		ts, err := GetVisibleTasksByAccountIdAndFilter(2, filter, keys, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts, result := ts.Page(keys, page, true)
+		ids := []int{}
+		for _, task := range *ts {
+			ids = append(ids, task.Id)
+		}
+		return ids, result
+	}
+
+	// pages of 2 tasks in descending priority and ascending ids
+	expected := [][]int{
+		{tasks[0].Id, tasks[2].Id},
+		{tasks[4].Id, tasks[3].Id},
+		{tasks[1].Id},
+	}
+	ids, result := getPage(Page{nil, 2})
+	if fmt.Sprint(ids) != fmt.Sprint(expected[0]) || result.Next == nil || result.Prev != nil {
+		t.Fatalf("First page is [%v], [%v] instead of [%v]", ids, result, expected[0])
+	}
+	ids, result = getPage(Page{result.Next, 2})
+	if fmt.Sprint(ids) != fmt.Sprint(expected[1]) || result.Next == nil || result.Prev == nil {
+		t.Fatalf("Second page is [%v], [%v] instead of [%v]", ids, result, expected[1])
+	}
+	prev := result.Prev
+	ids, result = getPage(Page{result.Next, 2})
+	if fmt.Sprint(ids) != fmt.Sprint(expected[2]) || result.Next != nil || result.Prev == nil {
+		t.Errorf("Last page is [%v], [%v] instead of [%v]", ids, result, expected[2])
+	}
+	ids, result = getPage(Page{prev, 2})
+	if fmt.Sprint(ids) != fmt.Sprint(expected[0]) || result.Next == nil || result.Prev != nil {
+		t.Errorf("Previous page is [%v], [%v] instead of [%v]", ids, result, expected[0])
+	}
+
+	if n, err := CountVisibleTasksByAccountIdAndFilter(2, filter); err != nil || n != 5 {
+		t.Errorf("CountVisibleTasksByAccountIdAndFilter() returned [%v], [%v]", n, err)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_Views(t *testing.T) {
+	own := View{-1, 2, "Urgent", "priority>=4", "-priority", 0}
+	shared := View{-1, 1, "Open", "status=Open", "", 1}
+	other := View{-1, 3, "Mine", "", "", 0}
+	for _, v := range []*View{&own, &shared, &other} {
+		if err := v.Save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	view, err := GetViewById(own.Id)
+	if err != nil || *view != own {
+		t.Errorf("View is not as expected: [%v], [%v] instead of [%v]", view, err, own)
+	}
+
+	// shared views come first, views of other accounts are not returned
+	views, err := GetViewsByAccountId(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*views) != 2 || (*views)[0] != shared || (*views)[1] != own {
+		t.Errorf("Views are not as expected: [%v]", views)
+	}
+
+	for _, v := range []*View{&own, &shared, &other} {
+		if err := v.Delete(); err != nil {
+			t.Error(err)
+		}
+	}
+	if own.Id != -1 {
+		t.Errorf("View ID after calling Delete() is not correct. Got [%v], expected [%v]", own.Id, -1)
+	}
+	if _, err := GetViewById(shared.Id); err == nil {
+		t.Error("View was not deleted")
+	}
+}
+
+func Test_storage_GetAllTasksByFilter(t *testing.T) {
+	tasks := Tasks{
+		{Id: -1, AccountId: 1, Created: 1704067100, LastUpdated: 1704067100, Priority: 5, Task: "All: audit logs", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067300, LastUpdated: 1704067300, Priority: 2, Task: "All: spam", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 3, Created: 1704067300, LastUpdated: 1704067300, Priority: 4, Task: "All: report", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 3, Created: 1704067400, LastUpdated: 1704067400, Priority: 1, Task: "All: more spam", Status: "Open", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+	text, _ := ParseFilter(`text~"all:"`)
+
+	// the tasks of all accounts are returned, not only the visible ones
+	ts, err := GetAllTasksByFilter(text, SortKeys{{"created", true}}, Page{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := []int{}
+	for _, task := range *ts {
+		ids = append(ids, task.Id)
+	}
+	expected := []int{tasks[3].Id, tasks[1].Id, tasks[2].Id, tasks[0].Id}
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("GetAllTasksByFilter() returned [%v], instead of [%v]", ids, expected)
+	}
+
+	accounts, _ := ParseFilter(`account=2 OR account=3`)
+	if n, err := CountAllTasksByFilter(AndFilters(text, accounts)); err != nil || n != 3 {
+		t.Errorf("CountAllTasksByFilter() returned [%v], [%v]", n, err)
+	}
+
+	counts, err := CountTasksPerAccount(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedCounts := AccountTaskCounts{{1, "JamesClonk", 1}, {2, "Clude", 1}, {3, "ozzie", 2}}
+	if fmt.Sprint(*counts) != fmt.Sprint(expectedCounts) {
+		t.Errorf("CountTasksPerAccount() returned [%v], instead of [%v]", *counts, expectedCounts)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_storage_StreamTasks(t *testing.T) {
+	all, err := GetAllTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	own, err := GetTasksByAccountId(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := []int{}
+	if err := StreamAllTasks(func(task *Task) error {
+		ids = append(ids, task.Id)
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+	if len(ids) != len(*all) {
+		t.Errorf("StreamAllTasks() streamed [%v] tasks, instead of [%v]", len(ids), len(*all))
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i-1] >= ids[i] {
+			t.Errorf("StreamAllTasks() did not stream in the order of ids: [%v]", ids)
+			break
+		}
+	}
+
+	count := 0
+	if err := StreamTasksByAccountId(2, func(task *Task) error {
+		if task.AccountId != 2 {
+			t.Errorf("StreamTasksByAccountId() streamed a task of another account: [%v]", task)
+		}
+		count++
+		return nil
+	}); err != nil {
+		t.Error(err)
+	}
+	if count < len(*own) {
+		t.Errorf("StreamTasksByAccountId() streamed [%v] tasks, expected at least [%v]", count, len(*own))
+	}
+
+	// errors of the callback end the stream
+	stop := errors.New("stop")
+	count = 0
+	if err := StreamAllTasks(func(task *Task) error {
+		count++
+		return stop
+	}); err != stop || count != 1 {
+		t.Errorf("StreamAllTasks() returned [%v] after [%v] tasks", err, count)
+	}
+}
+
+func Test_storage_ImportTasks(t *testing.T) {
+	// more tasks than fit into a single batch
+	tasks := Tasks{}
+	externalIds := []string{}
+	for i := 0; i < importBatchSize+20; i++ {
+		tasks = append(tasks, Task{Id: -1, AccountId: 3, Created: 1704067100, LastUpdated: 1704067100, Priority: 3, Task: fmt.Sprintf("Import: task %v", i), Status: "Open"})
+		externalIds = append(externalIds, "")
+	}
+	externalIds[0], externalIds[importBatchSize] = "first", "last"
+	if err := ImportTasks(3, tasks, externalIds); err != nil {
+		t.Fatal(err)
+	}
+	for _, task := range tasks {
+		if task.Id < 1 || task.Version != 1 || task.Rank == "" {
+			t.Errorf("Imported task is not as expected: [%v]", task)
+			break
+		}
+	}
+
+	imported, err := GetImportedTasks(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(imported) != 2 || imported["first"].Id != tasks[0].Id || imported["last"].Id != tasks[importBatchSize].Id {
+		t.Errorf("GetImportedTasks() returned [%v]", imported)
+	}
+	if imported, err := GetImportedTasks(2); err != nil || len(imported) != 0 {
+		t.Errorf("GetImportedTasks() of another account returned [%v], [%v]", imported, err)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+	if imported, err := GetImportedTasks(3); err != nil || len(imported) != 0 {
+		t.Errorf("GetImportedTasks() after deleting returned [%v], [%v]", imported, err)
+	}
+}
+
+func Test_storage_FeedTokens(t *testing.T) {
+	token, _, err := NewFeedToken(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := token.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := GetFeedTokenByHash(token.TokenHash); err != nil || *found != *token {
+		t.Errorf("GetFeedTokenByHash returned [%v], [%v]", found, err)
+	}
+
+	// a new token replaces the previous one
+	other, _, _ := NewFeedToken(2)
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetFeedTokenByHash(token.TokenHash); err == nil {
+		t.Error("GetFeedTokenByHash found a replaced token")
+	}
+	if found, err := GetFeedTokenByAccountId(2); err != nil || found.TokenHash != other.TokenHash {
+		t.Errorf("GetFeedTokenByAccountId returned [%v], [%v]", found, err)
+	}
+
+	if err := other.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetFeedTokenByAccountId(2); err == nil {
+		t.Error("GetFeedTokenByAccountId found a deleted token")
+	}
+}
+
 func Test_storage_DeleteTasks(t *testing.T) {
 	ts := Tasks{
-		{1, 1, 1234567890, 1234567895, 3, "Buy food!"},
-		{3, 2, 1234567891, 1234567895, 1, "Get some sleep..."},
-		{5, 2, 1234567892, 1234567895, 4, "Buy xmas presents!"},
+		{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 3, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 5, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "Buy xmas presents!", Status: "Open", Version: 1, Rank: "k"},
 	}
 	if err := ts.Delete(); err != nil {
 		t.Error(err)
@@ -173,8 +1814,8 @@ func Test_storage_DeleteTasks(t *testing.T) {
 	}
 
 	ts = Tasks{
-		{13, 1, 1234567890, 1234567895, 3, "Buy food!"},
-		{14, 2, 1234567891, 1234567895, 1, "Get some sleep..."},
+		{Id: 13, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 14, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"},
 	}
 	if err := ts.Delete(); err != nil {
 		t.Error(err)
@@ -187,7 +1828,7 @@ func Test_storage_DeleteTasks(t *testing.T) {
 		t.Errorf("Amount of Tasks in DB after calling Delete() is not correct. Got [%v], expected [%v]", len(*ts2), 3)
 	}
 
-	task := Task{6, 1, 1234567890, 1234567895, 2, "Watch TV.."}
+	task := Task{Id: 6, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 2, Task: "Watch TV..", Status: "Open", Version: 1, Rank: "s"}
 	if err := task.Delete(); err != nil {
 		t.Error(err)
 	}
@@ -212,10 +1853,10 @@ func Test_storage_GetAllAccounts(t *testing.T) {
 
 	// GetAllAccounts sorts by Id by default
 	expectedAccounts := Accounts{
-		Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
-		Account{4, "Sonny", "sonny@sunny", "abcd", "999", "None", 1234567895},
+		Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
+		Account{Id: 4, Name: "Sonny", Email: "sonny@sunny", Password: "abcd", Salt: "999", Role: "None", LastAuth: 1234567895, Version: 1},
 	}
 	for i, a := range *accounts {
 		if a != expectedAccounts[i] {
@@ -230,7 +1871,7 @@ func Test_storage_GetAccount(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	expectedAccount := Account{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891}
+	expectedAccount := Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1}
 	if *account != expectedAccount {
 		t.Errorf("Account is not as expected: [%v], instead of [%v]", account, expectedAccount)
 		return
@@ -248,7 +1889,7 @@ func Test_storage_GetAccount(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	expectedAccount = Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890}
+	expectedAccount = Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1}
 	if *account != expectedAccount {
 		t.Errorf("Account is not as expected: [%v], instead of [%v]", account, expectedAccount)
 		return
@@ -264,7 +1905,7 @@ func Test_storage_GetAccount(t *testing.T) {
 }
 
 func Test_storage_DeleteAccount(t *testing.T) {
-	a := Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892}
+	a := Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1}
 	if err := a.Delete(); err != nil {
 		t.Error(err)
 	}
@@ -280,7 +1921,7 @@ func Test_storage_DeleteAccount(t *testing.T) {
 		t.Errorf("Amount of Accounts in DB after calling Delete() is not correct. Got [%v], expected [%v]", len(*as), 3)
 	}
 
-	a = Account{5, "Sonny", "Sonny@Sunny", "abcd", "999", "None", 1234567897}
+	a = Account{Id: 5, Name: "Sonny", Email: "Sonny@Sunny", Password: "abcd", Salt: "999", Role: "None", LastAuth: 1234567897, Version: 1}
 	if err := a.Delete(); err != nil {
 		t.Error(err)
 	}
@@ -289,6 +1930,76 @@ func Test_storage_DeleteAccount(t *testing.T) {
 	}
 }
 
+func Test_storage_MigrateDatabase(t *testing.T) {
+	dir, err := os.MkdirTemp("", "migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	SetDatabase(dir + "/baseline.db")
+	defer SetDatabase("./data/tasks_test.db")
+
+	// the schema and data of the very first version
+	db, err := connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{
+		"create table T_ACCOUNTS (ID integer not null primary key, NAME text not null, EMAIL text not null, PASSWORD text not null, SALT text not null, ROLE text not null, LAST_AUTH integer not null)",
+		"create unique index if not exists IDX_ACCOUNT_EMAIL ON T_ACCOUNTS (EMAIL)",
+		"create table T_TASKS (ID integer not null primary key, ACCOUNT_ID integer not null, CREATED integer not null, LAST_UPDATED integer not null, PRIORITY integer not null, TASK text not null, foreign key(ACCOUNT_ID) references T_ACCOUNTS(ID))",
+		"insert into T_ACCOUNTS values (1, 'JamesClonk', 'JamesClonk@developer', 'abcd', '123', 'Admin', 1234567890)",
+		"insert into T_TASKS values (1, 1, 1234567890, 1234567895, 3, 'Buy food!')",
+	} {
+		if _, err := db.Exec(query); err != nil {
+			db.Close()
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	// migrating twice changes nothing the second time
+	for i := 0; i < 2; i++ {
+		if err := MigrateDatabase(); err != nil {
+			t.Fatalf("MigrateDatabase() #%v failed: [%v]", i+1, err)
+		}
+	}
+
+	task, err := GetTaskById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1}
+	if *task != expected {
+		t.Errorf("Task after migration is not as expected: [%v], instead of [%v]", task, expected)
+	}
+	account, err := GetAccountById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if account.Name != "JamesClonk" || account.Deleted != 0 || account.Version != 1 {
+		t.Errorf("Account after migration is not as expected: [%v]", account)
+	}
+
+	// the tables of later versions can be used right away
+	old := *task
+	task.Status = "Done"
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := DiffChanges(EntityTask, task.Id, ActionUpdate, 1, 1234567899, old, *task).Save(); err != nil {
+		t.Error(err)
+	}
+	step := UndoStep{-1, 1, 1234567899, []TaskChange{{&old, task}}}
+	if err := step.Save(); err != nil {
+		t.Error(err)
+	}
+	project := Project{-1, 1, "Migrated", "", "ASC", 0, 0}
+	if err := project.Save(); err != nil {
+		t.Error(err)
+	}
+}
+
 func Test_storage_cleanup(t *testing.T) {
 	_storage_cleanup()
 }
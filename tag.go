@@ -0,0 +1,133 @@
+package main
+
+import "sort"
+import "strings"
+import "errors"
+
+type Tag struct {
+	Id        int    `db:"ID"`
+	AccountId int    `db:"ACCOUNT_ID"`
+	Name      string `db:"NAME"`
+}
+
+type Tags []Tag
+
+type TagCount struct {
+	Id    int
+	Name  string
+	Count int
+}
+
+type TagCloud []TagCount
+
+type TagFilter struct {
+	Any  []string
+	All  []string
+	None []string
+}
+
+type tagSort struct {
+	tags Tags
+	by   func(t1, t2 *Tag) bool
+}
+
+func (t *tagSort) Len() int {
+	return len(t.tags)
+}
+
+func (t *tagSort) Swap(a, b int) {
+	t.tags[a], t.tags[b] = t.tags[b], t.tags[a]
+}
+
+func (t *tagSort) Less(a, b int) bool {
+	return t.by(&t.tags[a], &t.tags[b])
+}
+
+func (t Tags) sortBy(by func(t1, t2 *Tag) bool) *Tags {
+	ts := &tagSort{
+		tags: t,
+		by:   by,
+	}
+	sort.Stable(ts)
+	return &t
+}
+
+func (t *Tags) SortByName(order string) *Tags {
+	t.sortBy(func(t1, t2 *Tag) bool {
+		if order == "DESC" {
+			return strings.ToLower(t1.Name) > strings.ToLower(t2.Name)
+		}
+		return strings.ToLower(t1.Name) < strings.ToLower(t2.Name)
+	})
+	return t
+}
+
+// ParseTagName trims a tag name and checks if it can be used.
+// Commas are not allowed, since they separate tag names in the /tasks/ filters.
+func ParseTagName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Contains(name, ",") {
+		return "", errors.New("Invalid tag name")
+	}
+	return name, nil
+}
+
+// ParseTagNames splits a comma separated list of tag names, duplicates are removed.
+func ParseTagNames(names string) []string {
+	tags := []string{}
+	seen := make(map[string]bool)
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+		tags = append(tags, name)
+	}
+	return tags
+}
+
+func (f *TagFilter) IsEmpty() bool {
+	return len(f.Any) == 0 && len(f.All) == 0 && len(f.None) == 0
+}
+
+// FilterByTags returns only the tasks that have at least one of the Any tags,
+// all of the All tags and none of the None tags.
+func (t *Tasks) FilterByTags(filter TagFilter) (*Tasks, error) {
+	if filter.IsEmpty() {
+		return t, nil
+	}
+
+	var anyCounts, allCounts, noneCounts map[int]int
+	var err error
+	if len(filter.Any) > 0 {
+		if anyCounts, err = CountTaskTags(filter.Any); err != nil {
+			return nil, err
+		}
+	}
+	if len(filter.All) > 0 {
+		if allCounts, err = CountTaskTags(filter.All); err != nil {
+			return nil, err
+		}
+	}
+	if len(filter.None) > 0 {
+		if noneCounts, err = CountTaskTags(filter.None); err != nil {
+			return nil, err
+		}
+	}
+
+	ts := Tasks{}
+	for _, task := range *t {
+		if anyCounts != nil && anyCounts[task.Id] == 0 {
+			continue
+		}
+		if allCounts != nil && allCounts[task.Id] < len(filter.All) {
+			continue
+		}
+		if noneCounts != nil && noneCounts[task.Id] > 0 {
+			continue
+		}
+		ts = append(ts, task)
+	}
+	return &ts, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import "testing"
+
+func Test_tag_SortByName(t *testing.T) {
+	var ts1 = Tags{
+		Tag{1, 1, "b"},
+		Tag{2, 1, "C"},
+		Tag{3, 2, "a"},
+	}
+
+	var ts2 = Tags{
+		Tag{3, 2, "a"},
+		Tag{1, 1, "b"},
+		Tag{2, 1, "C"},
+	}
+
+	var ts3 = Tags{
+		Tag{2, 1, "C"},
+		Tag{1, 1, "b"},
+		Tag{3, 2, "a"},
+	}
+
+	ts1.SortByName("ASC")
+	for i, t1 := range ts1 {
+		if t1 != ts2[i] {
+			t.Errorf("SortByName ASC is not as expected: [%v], instead of [%v]", ts1, ts2)
+			return
+		}
+	}
+
+	ts1.SortByName("DESC")
+	for i, t1 := range ts1 {
+		if t1 != ts3[i] {
+			t.Errorf("SortByName DESC is not as expected: [%v], instead of [%v]", ts1, ts3)
+			return
+		}
+	}
+}
+
+func Test_tag_ParseTagName(t *testing.T) {
+	name, err := ParseTagName("  shopping ")
+	if err != nil {
+		t.Error(err)
+	}
+	if name != "shopping" {
+		t.Errorf("ParseTagName returned [%v], expected [%v]", name, "shopping")
+	}
+
+	for _, invalid := range []string{"", "   ", "a,b"} {
+		if _, err := ParseTagName(invalid); err == nil {
+			t.Errorf("ParseTagName should not accept [%v]", invalid)
+		}
+	}
+}
+
+func Test_tag_ParseTagNames(t *testing.T) {
+	names := ParseTagNames(" home,work,, Home ,errands")
+	expected := []string{"home", "work", "errands"}
+	if len(names) != len(expected) {
+		t.Errorf("ParseTagNames returned [%v], expected [%v]", names, expected)
+		return
+	}
+	for i, name := range names {
+		if name != expected[i] {
+			t.Errorf("ParseTagNames returned [%v], expected [%v]", names, expected)
+			return
+		}
+	}
+
+	if len(ParseTagNames("")) != 0 {
+		t.Error("ParseTagNames of an empty string should be empty")
+	}
+}
@@ -2,14 +2,23 @@ package main
 
 import "sort"
 import "strings"
+import "errors"
+
+var taskStatuses = []string{"Open", "InProgress", "Done"}
 
 type Task struct {
-	Id          int    `db:"ID"`
-	AccountId   int    `db:"ACCOUNT_ID"`
-	Created     int    `db:"CREATED"`
-	LastUpdated int    `db:"LAST_UPDATED"`
-	Priority    int    `db:"PRIORITY"`
-	Task        string `db:"TASK"`
+	Id          int      `db:"ID"`
+	AccountId   int      `db:"ACCOUNT_ID"`
+	Created     int      `db:"CREATED"`
+	LastUpdated int      `db:"LAST_UPDATED"`
+	Priority    Priority `db:"PRIORITY"`
+	Task        string   `db:"TASK"`
+	ProjectId   int      `db:"PROJECT_ID"`
+	AssigneeId  int      `db:"ASSIGNEE_ID"`
+	Status      string   `db:"STATUS"`
+	Deleted     int      `db:"DELETED"`
+	Version     int      `db:"VERSION"`
+	Rank        string   `db:"RANK"`
 }
 
 type Tasks []Task
@@ -36,7 +45,7 @@ func (t Tasks) sortBy(by func(t1, t2 *Task) bool) *Tasks {
 		tasks: t,
 		by:    by,
 	}
-	sort.Sort(ts)
+	sort.Stable(ts)
 	return &t
 }
 
@@ -89,3 +98,131 @@ func (t *Tasks) SortByTask(order string) *Tasks {
 	})
 	return t
 }
+
+// SortByRank sorts tasks into their manual order. Tasks that have never been ranked come last.
+// Tasks of the same rank keep their order, so that the less significant sort keys still apply to the unranked ones.
+func (t *Tasks) SortByRank(order string) *Tasks {
+	t.sortBy(func(t1, t2 *Task) bool {
+		if t1.Rank == t2.Rank {
+			return false
+		}
+		if t1.Rank == "" || t2.Rank == "" {
+			return t2.Rank == ""
+		}
+		if order == "DESC" {
+			return t1.Rank > t2.Rank
+		}
+		return t1.Rank < t2.Rank
+	})
+	return t
+}
+
+var taskSorters = map[string]func(t *Tasks, order string) *Tasks{
+	"AccountId":   (*Tasks).SortByAccountId,
+	"Created":     (*Tasks).SortByCreated,
+	"LastUpdated": (*Tasks).SortByLastUpdated,
+	"Priority":    (*Tasks).SortByPriority,
+	"Rank":        (*Tasks).SortByRank,
+	"Task":        (*Tasks).SortByTask,
+}
+
+func IsTaskSortField(field string) bool {
+	_, ok := taskSorters[field]
+	return ok
+}
+
+// taskSortFields are the fields of the sort parameter, text and rank are only sorted in Go.
+// SQLite lowercases only ASCII letters, and ranks that were never set come last in both directions.
+var taskSortFields = map[string]sortField{
+	"account":  {"AccountId", "T.ACCOUNT_ID", false, false},
+	"created":  {"Created", "T.CREATED", false, false},
+	"updated":  {"LastUpdated", "T.LAST_UPDATED", false, false},
+	"priority": {"Priority", "T.PRIORITY", false, false},
+	"text":     {"Task", "", true, false},
+	"rank":     {"Rank", "", false, true},
+}
+
+// the order of tasks without sort keys, which is the order of the database
+var defaultTaskSortKeys = SortKeys{{"priority", true}, {"updated", false}, {"created", false}}
+
+// taskSortKey returns the sort key of the Go sorter with the given name, as used by projects.
+func taskSortKey(sorter string, order string) (SortKey, bool) {
+	for name, field := range taskSortFields {
+		if field.Sorter == sorter {
+			return SortKey{name, order == "DESC"}, true
+		}
+	}
+	return SortKey{}, false
+}
+
+// sortValues returns the values of the sort keys of the task, as ints or strings.
+func (t *Task) sortValues(keys SortKeys) []interface{} {
+	values := []interface{}{}
+	for _, key := range keys {
+		switch key.Field {
+		case "account":
+			values = append(values, t.AccountId)
+		case "created":
+			values = append(values, t.Created)
+		case "updated":
+			values = append(values, t.LastUpdated)
+		case "priority":
+			values = append(values, int(t.Priority))
+		case "text":
+			values = append(values, t.Task)
+		case "rank":
+			values = append(values, t.Rank)
+		}
+	}
+	return values
+}
+
+// Page returns the tasks of the page in order, see pageRows.
+func (t Tasks) Page(keys SortKeys, page Page, inSQL bool) (*Tasks, PageResult) {
+	indexes, result := pageRows(len(t), func(i int) ([]interface{}, int) {
+		return t[i].sortValues(keys), t[i].Id
+	}, "tasks", keys, taskSortFields, page, inSQL)
+
+	ts := Tasks{}
+	for _, i := range indexes {
+		ts = append(ts, t[i])
+	}
+	return &ts, result
+}
+
+func (t *Tasks) SortByField(field string, order string) *Tasks {
+	if sorter, ok := taskSorters[field]; ok {
+		sorter(t, order)
+	}
+	return t
+}
+
+// SortByKeys sorts by all keys, the first key being the most significant one.
+// As each sort is stable, it sorts by the least significant key first. Tasks equal in all keys keep their order.
+func (t *Tasks) SortByKeys(keys SortKeys) *Tasks {
+	for i := len(keys) - 1; i >= 0; i-- {
+		t.SortByField(taskSortFields[keys[i].Field].Sorter, keys[i].Order())
+	}
+	return t
+}
+
+// ParseTaskStatus validates a task status, an empty status defaults to "Open".
+func ParseTaskStatus(status string) (string, error) {
+	if status == "" {
+		return taskStatuses[0], nil
+	}
+	for _, s := range taskStatuses {
+		if s == status {
+			return status, nil
+		}
+	}
+	return "", errors.New("Invalid status")
+}
+
+func (t *Task) IsAssigned() bool {
+	return t.AssigneeId > 0
+}
+
+func (t *Task) IsDeleted() bool {
+	return t.Deleted > 0
+}
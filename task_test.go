@@ -4,19 +4,19 @@ import "testing"
 
 func Test_task_SortBy(t *testing.T) {
 	var ts1 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	var ts2 = Tasks{
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
 	}
 
 	ts1.sortBy(func(t1, t2 *Task) bool {
@@ -33,27 +33,27 @@ func Test_task_SortBy(t *testing.T) {
 
 func Test_task_SortByAccountId(t *testing.T) {
 	var ts1 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	var ts2 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	var ts3 = Tasks{
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
 	}
 
 	ts1.SortByAccountId("ASC")
@@ -75,27 +75,27 @@ func Test_task_SortByAccountId(t *testing.T) {
 
 func Test_task_SortByCreated(t *testing.T) {
 	var ts1 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	var ts2 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
 	}
 
 	var ts3 = Tasks{
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	ts1.SortByCreated("ASC")
@@ -117,27 +117,27 @@ func Test_task_SortByCreated(t *testing.T) {
 
 func Test_task_SortByLastUpdated(t *testing.T) {
 	var ts1 = Tasks{
-		Task{1, 1, 1234567890, 1234567891, 3, "A"},
-		Task{2, 2, 1234567891, 1234567893, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567894, 4, "C!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{5, 3, 1234567890, 1234567892, 5, "E$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567891, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567893, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567894, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567892, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	var ts2 = Tasks{
-		Task{1, 1, 1234567890, 1234567891, 3, "A"},
-		Task{5, 3, 1234567890, 1234567892, 5, "E$"},
-		Task{2, 2, 1234567891, 1234567893, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567894, 4, "C!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567891, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567892, Priority: 5, Task: "E$", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567893, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567894, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
 	}
 
 	var ts3 = Tasks{
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{3, 2, 1234567892, 1234567894, 4, "C!"},
-		Task{2, 2, 1234567891, 1234567893, 1, "B..."},
-		Task{5, 3, 1234567890, 1234567892, 5, "E$"},
-		Task{1, 1, 1234567890, 1234567891, 3, "A"},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567894, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567893, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567892, Priority: 5, Task: "E$", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567891, Priority: 3, Task: "A", Status: "Open", Version: 1},
 	}
 
 	ts1.SortByLastUpdated("ASC")
@@ -159,27 +159,27 @@ func Test_task_SortByLastUpdated(t *testing.T) {
 
 func Test_task_SortByPriority(t *testing.T) {
 	var ts1 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	var ts2 = Tasks{
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
 	}
 
 	var ts3 = Tasks{
-		Task{5, 3, 1234567890, 1234567895, 5, "E$"},
-		Task{3, 2, 1234567892, 1234567895, 4, "C!"},
-		Task{1, 1, 1234567890, 1234567895, 3, "A"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{2, 2, 1234567891, 1234567895, 1, "B..."},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "E$", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
 	}
 
 	ts1.SortByPriority("ASC")
@@ -201,27 +201,27 @@ func Test_task_SortByPriority(t *testing.T) {
 
 func Test_task_SortByTask(t *testing.T) {
 	var ts1 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "a"},
-		Task{2, 2, 1234567891, 1234567895, 1, "C..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "b!"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{5, 3, 1234567890, 1234567895, 5, "e$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "C...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "b!", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "e$", Status: "Open", Version: 1},
 	}
 
 	var ts2 = Tasks{
-		Task{1, 1, 1234567890, 1234567895, 3, "a"},
-		Task{3, 2, 1234567892, 1234567895, 4, "b!"},
-		Task{2, 2, 1234567891, 1234567895, 1, "C..."},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{5, 3, 1234567890, 1234567895, 5, "e$"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "b!", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "C...", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "e$", Status: "Open", Version: 1},
 	}
 
 	var ts3 = Tasks{
-		Task{5, 3, 1234567890, 1234567895, 5, "e$"},
-		Task{4, 1, 1234567893, 1234567895, 3, "D?"},
-		Task{2, 2, 1234567891, 1234567895, 1, "C..."},
-		Task{3, 2, 1234567892, 1234567895, 4, "b!"},
-		Task{1, 1, 1234567890, 1234567895, 3, "a"},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "e$", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "D?", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "C...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "b!", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
 	}
 
 	ts1.SortByTask("ASC")
@@ -240,3 +240,149 @@ func Test_task_SortByTask(t *testing.T) {
 		}
 	}
 }
+
+func Test_task_SortByRank(t *testing.T) {
+	var ts1 = Tasks{
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "b", Status: "Open", Version: 1, Rank: "k"},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "c", Status: "Open", Version: 1, Rank: "V"},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "d", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "e", Status: "Open", Version: 1, Rank: "VV"},
+	}
+
+	var ts2 = Tasks{
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "c", Status: "Open", Version: 1, Rank: "V"},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "e", Status: "Open", Version: 1, Rank: "VV"},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "b", Status: "Open", Version: 1, Rank: "k"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "d", Status: "Open", Version: 1},
+	}
+
+	var ts3 = Tasks{
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "b", Status: "Open", Version: 1, Rank: "k"},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "e", Status: "Open", Version: 1, Rank: "VV"},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "c", Status: "Open", Version: 1, Rank: "V"},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "d", Status: "Open", Version: 1},
+	}
+
+	ts1.SortByRank("ASC")
+	for i, t1 := range ts1 {
+		if t1 != ts2[i] {
+			t.Errorf("SortByRank ASC is not as expected: [%v], instead of [%v]", ts1, ts2)
+			return
+		}
+	}
+
+	ts1.SortByRank("DESC")
+	for i, t1 := range ts1 {
+		if t1 != ts3[i] {
+			t.Errorf("SortByRank DESC is not as expected: [%v], instead of [%v]", ts1, ts3)
+			return
+		}
+	}
+
+	// unranked tasks are still sorted by the less significant keys
+	ts4 := Tasks{
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 1, Task: "low", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 1, Created: 1234567891, LastUpdated: 1234567895, Priority: 4, Task: "high", Status: "Open", Version: 1},
+	}
+	ts4.SortByKeys(SortKeys{{"rank", false}, {"priority", true}})
+	if ts4[0].Id != 2 || ts4[1].Id != 1 {
+		t.Errorf("SortByKeys rank,-priority of unranked tasks is not as expected: [%v]", ts4)
+	}
+}
+
+func Test_task_SortByKeys(t *testing.T) {
+	var ts1 = Tasks{
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "b", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 5, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "c", Status: "Open", Version: 1},
+	}
+
+	// equal tasks keep their order, so 3 stays before 4
+	var ts2 = Tasks{
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 5, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "c", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "b", Status: "Open", Version: 1},
+	}
+
+	var ts3 = Tasks{
+		Task{Id: 3, AccountId: 2, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "b", Status: "Open", Version: 1},
+		Task{Id: 5, AccountId: 3, Created: 1234567890, LastUpdated: 1234567895, Priority: 5, Task: "c", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 5, Task: "a", Status: "Open", Version: 1},
+		Task{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "a", Status: "Open", Version: 1},
+	}
+
+	ts1.SortByKeys(SortKeys{{"priority", true}, {"text", false}})
+	for i, t1 := range ts1 {
+		if t1 != ts2[i] {
+			t.Errorf("SortByKeys -priority,text is not as expected: [%v], instead of [%v]", ts1, ts2)
+			return
+		}
+	}
+
+	ts1.SortByKeys(SortKeys{{"created", false}, {"text", false}})
+	for i, t1 := range ts1 {
+		if t1 != ts3[i] {
+			t.Errorf("SortByKeys created,text is not as expected: [%v], instead of [%v]", ts1, ts3)
+			return
+		}
+	}
+}
+
+func Test_task_SortByField(t *testing.T) {
+	var ts1 = Tasks{
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+	}
+
+	var ts2 = Tasks{
+		Task{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "C!", Status: "Open", Version: 1},
+		Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "A", Status: "Open", Version: 1},
+		Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "B...", Status: "Open", Version: 1},
+	}
+
+	if !IsTaskSortField("Priority") || IsTaskSortField("Id") || IsTaskSortField("priority") {
+		t.Error("IsTaskSortField does not recognize sort fields as expected")
+	}
+
+	ts1.SortByField("Priority", "DESC")
+	for i, t1 := range ts1 {
+		if t1 != ts2[i] {
+			t.Errorf("SortByField Priority DESC is not as expected: [%v], instead of [%v]", ts1, ts2)
+			return
+		}
+	}
+
+	ts1.SortByField("Unknown", "ASC")
+	for i, t1 := range ts1 {
+		if t1 != ts2[i] {
+			t.Errorf("SortByField with unknown field should not change order: [%v], instead of [%v]", ts1, ts2)
+			return
+		}
+	}
+}
+
+func Test_task_ParseTaskStatus(t *testing.T) {
+	for status, expected := range map[string]string{"": "Open", "Open": "Open", "InProgress": "InProgress", "Done": "Done"} {
+		s, err := ParseTaskStatus(status)
+		if err != nil {
+			t.Error(err)
+		}
+		if s != expected {
+			t.Errorf("ParseTaskStatus of [%v] returned [%v], instead of [%v]", status, s, expected)
+		}
+	}
+	for _, status := range []string{"done", "Closed"} {
+		if _, err := ParseTaskStatus(status); err == nil {
+			t.Errorf("ParseTaskStatus should not accept [%v]", status)
+		}
+	}
+}
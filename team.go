@@ -0,0 +1,101 @@
+package main
+
+import "sort"
+import "strings"
+import "errors"
+
+// team roles and the permission level they grant on the projects and tasks of the team.
+// Owners can also manage the team itself and its members.
+var teamRoles = map[string]int{
+	"Owner":  PermissionManage,
+	"Member": PermissionEdit,
+	"Viewer": PermissionView,
+}
+
+// Team is a group of accounts that owns projects, and the tasks within them, collectively.
+type Team struct {
+	Id   int    `db:"ID"`
+	Name string `db:"NAME"`
+}
+
+type Teams []Team
+
+type Member struct {
+	TeamId    int    `db:"TEAM_ID"`
+	AccountId int    `db:"ACCOUNT_ID"`
+	Role      string `db:"ROLE"`
+}
+
+type Members []Member
+
+type teamSort struct {
+	teams Teams
+	by    func(t1, t2 *Team) bool
+}
+
+func (t *teamSort) Len() int {
+	return len(t.teams)
+}
+
+func (t *teamSort) Swap(a, b int) {
+	t.teams[a], t.teams[b] = t.teams[b], t.teams[a]
+}
+
+func (t *teamSort) Less(a, b int) bool {
+	return t.by(&t.teams[a], &t.teams[b])
+}
+
+func (t Teams) sortBy(by func(t1, t2 *Team) bool) *Teams {
+	ts := &teamSort{
+		teams: t,
+		by:    by,
+	}
+	sort.Stable(ts)
+	return &t
+}
+
+func (t *Teams) SortByName(order string) *Teams {
+	t.sortBy(func(t1, t2 *Team) bool {
+		if order == "DESC" {
+			return strings.ToLower(t1.Name) > strings.ToLower(t2.Name)
+		}
+		return strings.ToLower(t1.Name) < strings.ToLower(t2.Name)
+	})
+	return t
+}
+
+func ParseTeamRole(role string) (string, error) {
+	if _, ok := teamRoles[role]; !ok {
+		return "", errors.New("Invalid role")
+	}
+	return role, nil
+}
+
+// Level returns the permission level the members role grants.
+func (m *Member) Level() int {
+	return teamRoles[m.Role]
+}
+
+func (m *Member) IsOwner() bool {
+	return m.Role == "Owner"
+}
+
+// Level returns the highest permission level of all members.
+func (m Members) Level() int {
+	level := PermissionNone
+	for _, member := range m {
+		level = maxPermission(level, member.Level())
+	}
+	return level
+}
+
+// Owners returns how many of the members have the role "Owner".
+func (m Members) Owners() int {
+	owners := 0
+	for _, member := range m {
+		if member.IsOwner() {
+			owners++
+		}
+	}
+	return owners
+}
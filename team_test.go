@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+func Test_team_SortByName(t *testing.T) {
+	var ts1 = Teams{
+		Team{1, "b"},
+		Team{2, "C"},
+		Team{3, "a"},
+	}
+
+	var ts2 = Teams{
+		Team{3, "a"},
+		Team{1, "b"},
+		Team{2, "C"},
+	}
+
+	var ts3 = Teams{
+		Team{2, "C"},
+		Team{1, "b"},
+		Team{3, "a"},
+	}
+
+	ts1.SortByName("ASC")
+	for i, t1 := range ts1 {
+		if t1 != ts2[i] {
+			t.Errorf("SortByName ASC is not as expected: [%v], instead of [%v]", ts1, ts2)
+			return
+		}
+	}
+
+	ts1.SortByName("DESC")
+	for i, t1 := range ts1 {
+		if t1 != ts3[i] {
+			t.Errorf("SortByName DESC is not as expected: [%v], instead of [%v]", ts1, ts3)
+			return
+		}
+	}
+}
+
+func Test_team_ParseTeamRole(t *testing.T) {
+	for _, role := range []string{"Owner", "Member", "Viewer"} {
+		if _, err := ParseTeamRole(role); err != nil {
+			t.Error(err)
+		}
+	}
+	for _, role := range []string{"", "Admin", "owner"} {
+		if _, err := ParseTeamRole(role); err == nil {
+			t.Errorf("ParseTeamRole should not accept [%v]", role)
+		}
+	}
+}
+
+func Test_team_Members(t *testing.T) {
+	members := Members{
+		Member{1, 1, "Owner"},
+		Member{1, 2, "Member"},
+		Member{1, 3, "Viewer"},
+	}
+
+	expectedLevels := []int{PermissionManage, PermissionEdit, PermissionView}
+	for i, m := range members {
+		if m.Level() != expectedLevels[i] {
+			t.Errorf("Level of [%v] is [%v], expected [%v]", m, m.Level(), expectedLevels[i])
+		}
+	}
+
+	if members.Owners() != 1 {
+		t.Errorf("Owners of [%v] is [%v], expected [%v]", members, members.Owners(), 1)
+	}
+	if members.Level() != PermissionManage {
+		t.Errorf("Level of [%v] is [%v], expected [%v]", members, members.Level(), PermissionManage)
+	}
+	if members[1:].Level() != PermissionEdit {
+		t.Errorf("Level of [%v] is [%v], expected [%v]", members[1:], members[1:].Level(), PermissionEdit)
+	}
+}
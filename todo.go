@@ -1,25 +1,44 @@
 package main
 
 import "fmt"
+import "io"
+import "os"
+import "bytes"
 import "log"
+import "mime"
 import "flag"
 import "regexp"
 import "strconv"
 import "strings"
 import "time"
 import "errors"
+import "net/url"
 import "net/http"
+import "database/sql"
 import "encoding/json"
 
 var isLogging = true
-var validPath = regexp.MustCompile("^/(task|account)/([a-zA-Z0-9_]+)$")
+var validPath = regexp.MustCompile("^/(task|account|project|tag|team|view)/([a-zA-Z0-9_]+)$")
+var validSubPath = regexp.MustCompile("^/(task|project|team|views?)/([0-9]+)/([a-z]+)(/([0-9]+))?$")
+var validTrashPath = regexp.MustCompile("^/trash/(task|account)/([0-9]+)$")
+var validFeedPath = regexp.MustCompile("^/feed/([a-zA-Z0-9_=-]+)\\.ics$")
 
 type MethodHandler map[string]func(w http.ResponseWriter, r *http.Request, accountId int)
+type SubresourceHandler map[string]http.HandlerFunc
 
 var fileFlag = flag.String("database", "./data/tasks.db", "database file")
 var databaseFlag = flag.Bool("createDatabase", false, "will setup a new empty database")
 var adminFlag = flag.Bool("createAdmin", false, "will create a new admin account in the database")
 var taskFlag = flag.Bool("createTasks", false, "will create some sample tasks in the database")
+var exportFlag = flag.String("export", "", "will export the tasks to stdout, as json, csv, markdown or ical")
+var exportAccountFlag = flag.Int("exportAccount", 0, "will only export the tasks of this account")
+var importFlag = flag.String("import", "", "will import the tasks of a csv or json file")
+var importAccountFlag = flag.Int("importAccount", 0, "account to import the tasks into")
+var importColumnsFlag = flag.String("importColumns", "", "maps csv columns to task fields, like Title:Task,Prio:Priority")
+var importDryRunFlag = flag.Bool("importDryRun", false, "will only report what an import would do")
+var todoTxtFlag = flag.String("todotxt", "", "will sync a todo.txt file with the tasks of an account, in both directions")
+var todoTxtAccountFlag = flag.Int("todotxtAccount", 0, "account to sync the todo.txt file with")
+var normalizePrioritiesFlag = flag.Bool("normalizePriorities", false, "will move priorities outside of the priority scale to its nearest end")
 
 func main() {
 	// parse configfile first, then commandline options second..
@@ -31,8 +50,21 @@ func main() {
 	SetDatabase(cfg.DatabaseFile)
 	port := strconv.Itoa(cfg.Port)
 
+	if err := applyConfig(cfg); err != nil {
+		log.Fatal(err)
+	}
+
 	parseCommandline()
 
+	// the full-text index is rebuilt on every start, so that it also covers tasks from before it existed
+	if err := SetupSearchIndex(); err != nil {
+		log.Printf("Full-text search is not available: [%v]", err)
+	}
+
+	if cfg.TrashDays > 0 {
+		go purgeTrashPeriodically(time.Duration(cfg.TrashDays)*24*time.Hour, time.Hour)
+	}
+
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/client/", http.StatusFound)
 	})
@@ -40,14 +72,171 @@ func main() {
 	http.Handle("/client/", http.StripPrefix("/client/", http.FileServer(http.Dir("client/"))))
 
 	http.HandleFunc("/auth/", getAuth)
+	http.HandleFunc("/feed/", getFeed)
 	http.HandleFunc("/tasks/", authHandler(MethodHandler{
 		"GET": getTasks,
 	}))
-	http.HandleFunc("/task/", authHandler(MethodHandler{
+	http.HandleFunc("/tasks/assigned", authHandler(MethodHandler{
+		"GET": getAssignedTasks,
+	}))
+	http.HandleFunc("/tasks/bulk", authHandler(MethodHandler{
+		"POST": bulkTasks,
+	}))
+	http.HandleFunc("/tasks/all", authHandler(MethodHandler{
+		"GET": getAllTasks,
+	}))
+	http.HandleFunc("/tasks/all/counts", authHandler(MethodHandler{
+		"GET": getAllTaskCounts,
+	}))
+	http.HandleFunc("/tasks/export", authHandler(MethodHandler{
+		"GET": getTaskExport,
+	}))
+	http.HandleFunc("/tasks/import", authHandler(MethodHandler{
+		"POST": postTaskImport,
+	}))
+	http.HandleFunc("/tasks/todotxt", authHandler(MethodHandler{
+		"GET":  getTodoTxt,
+		"POST": postTodoTxt,
+	}))
+	http.HandleFunc("/tasks/feed", authHandler(MethodHandler{
+		"GET":    getFeedToken,
+		"POST":   addFeedToken,
+		"DELETE": deleteFeedToken,
+	}))
+	http.HandleFunc("/tasks/search", authHandler(MethodHandler{
+		"GET": searchTasks,
+	}))
+	http.HandleFunc("/priorities", authHandler(MethodHandler{
+		"GET": getPriorities,
+	}))
+	http.HandleFunc("/task/", subresourceHandler(authHandler(MethodHandler{
 		"GET":    getTask,
 		"POST":   addTask,
 		"PUT":    editTask,
+		"PATCH":  patchTask,
 		"DELETE": deleteTask,
+	}), SubresourceHandler{
+		"tags": authHandler(MethodHandler{
+			"GET":    getTaskTags,
+			"POST":   addTaskTag,
+			"DELETE": deleteTaskTag,
+		}),
+		"shares": authHandler(MethodHandler{
+			"GET":    getTaskShares,
+			"POST":   addTaskShare,
+			"DELETE": deleteTaskShare,
+		}),
+		"assignments": authHandler(MethodHandler{
+			"GET": getTaskAssignments,
+		}),
+		"status": authHandler(MethodHandler{
+			"PUT": editTaskStatus,
+		}),
+		"move": authHandler(MethodHandler{
+			"PUT": moveTask,
+		}),
+		"history": authHandler(MethodHandler{
+			"GET": getTaskHistory,
+		}),
+		"attachments": authHandler(MethodHandler{
+			"GET":    getTaskAttachments,
+			"POST":   addTaskAttachment,
+			"DELETE": deleteTaskAttachment,
+		}),
+		"comments": authHandler(MethodHandler{
+			"GET":    getTaskComments,
+			"POST":   addTaskComment,
+			"PUT":    editTaskComment,
+			"DELETE": deleteTaskComment,
+		}),
+	}))
+
+	http.HandleFunc("/projects/", authHandler(MethodHandler{
+		"GET": getProjects,
+	}))
+	http.HandleFunc("/project/", subresourceHandler(authHandler(MethodHandler{
+		"GET":    getProject,
+		"POST":   addProject,
+		"PUT":    editProject,
+		"DELETE": deleteProject,
+	}), SubresourceHandler{
+		"shares": authHandler(MethodHandler{
+			"GET":    getProjectShares,
+			"POST":   addProjectShare,
+			"DELETE": deleteProjectShare,
+		}),
+	}))
+
+	http.HandleFunc("/shares/", authHandler(MethodHandler{
+		"GET": getShares,
+	}))
+
+	http.HandleFunc("/teams/", authHandler(MethodHandler{
+		"GET": getTeams,
+	}))
+	http.HandleFunc("/team/", subresourceHandler(authHandler(MethodHandler{
+		"GET":    getTeam,
+		"POST":   addTeam,
+		"PUT":    editTeam,
+		"DELETE": deleteTeam,
+	}), SubresourceHandler{
+		"members": authHandler(MethodHandler{
+			"GET":    getTeamMembers,
+			"POST":   addTeamMember,
+			"DELETE": deleteTeamMember,
+		}),
+		"projects": authHandler(MethodHandler{
+			"GET": getTeamProjects,
+		}),
+		"tasks": authHandler(MethodHandler{
+			"GET": getTeamTasks,
+		}),
+	}))
+
+	http.HandleFunc("/views/", subresourceHandler(authHandler(MethodHandler{
+		"GET": getViews,
+	}), SubresourceHandler{
+		"tasks": authHandler(MethodHandler{
+			"GET": getViewTasks,
+		}),
+	}))
+	http.HandleFunc("/view/", subresourceHandler(authHandler(MethodHandler{
+		"GET":    getView,
+		"POST":   addView,
+		"PUT":    editView,
+		"DELETE": deleteView,
+	}), SubresourceHandler{
+		"tasks": authHandler(MethodHandler{
+			"GET": getViewTasks,
+		}),
+	}))
+
+	http.HandleFunc("/tags/", authHandler(MethodHandler{
+		"GET": getTags,
+	}))
+	http.HandleFunc("/tags/cloud", authHandler(MethodHandler{
+		"GET": getTagCloud,
+	}))
+	http.HandleFunc("/tag/", authHandler(MethodHandler{
+		"GET":    getTag,
+		"POST":   addTag,
+		"PUT":    editTag,
+		"DELETE": deleteTag,
+	}))
+
+	http.HandleFunc("/trash/", authHandler(MethodHandler{
+		"GET":    getTrash,
+		"POST":   restoreTrash,
+		"DELETE": purgeTrash,
+	}))
+
+	http.HandleFunc("/undo/", authHandler(MethodHandler{
+		"GET":  getUndo,
+		"POST": undo,
+	}))
+
+	http.HandleFunc("/audit/", authHandler(MethodHandler{
+		"GET": getAuditLog,
 	}))
 
 	http.HandleFunc("/accounts/", authHandler(MethodHandler{
@@ -57,6 +246,7 @@ func main() {
 		"GET":    getAccount,
 		"POST":   addAccount,
 		"PUT":    editAccount,
+		"PATCH":  patchAccount,
 		"DELETE": deleteAccount,
 	}))
 
@@ -75,6 +265,22 @@ func parseCommandline() {
 		SetupDatabase()
 	}
 
+	// databases of earlier versions are brought up to date before anything touches their data
+	if err := MigrateDatabase(); err != nil {
+		log.Fatal(err)
+	}
+
+	// tasks from before the priority scale was introduced or changed need to fit into it, which is only checked once per scale
+	if n, err := MigratePriorities(*normalizePrioritiesFlag); err == ErrPrioritiesOutOfScale {
+		scale := GetPriorityScale()
+		log.Fatalf("Priorities of %v tasks are outside of the priority scale %v to %v, "+
+			"either widen the scale or start once with -normalizePriorities to move them to its nearest end", n, scale.Min, scale.Max)
+	} else if err != nil {
+		log.Fatal(err)
+	} else if n > 0 {
+		log.Printf("Priorities of %v tasks normalized", n)
+	}
+
 	if *adminFlag {
 		account, password := SetupAdmin()
 		log.Printf("Admin account created: [%v], with password: [%v]", account, password)
@@ -83,6 +289,240 @@ func parseCommandline() {
 	if *taskFlag {
 		SetupSampleTasks()
 	}
+
+	if len(*exportFlag) > 0 {
+		if err := exportTasks(os.Stdout, *exportFlag, *exportAccountFlag); err != nil {
+			log.Fatal(err)
+		}
+		os.Exit(0)
+	}
+
+	if len(*importFlag) > 0 {
+		report, err := importFile(*importFlag, *importAccountFlag, *importColumnsFlag, *importDryRunFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		js, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(js))
+		if !report.Imported && !report.DryRun {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if len(*todoTxtFlag) > 0 {
+		report, err := syncTodoTxt(*todoTxtFlag, *todoTxtAccountFlag)
+		if err != nil {
+			log.Fatal(err)
+		}
+		js, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(string(js))
+		if len(report.Conflicts) > 0 || len(report.Failed) > 0 {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+}
+
+// importFile imports a csv or json file, depending on its extension, into the account.
+// Rows that fail are reported, but do not keep the other rows from being imported.
+func importFile(filename string, accountId int, columns string, dryRun bool) (*ImportReport, error) {
+	if _, err := GetAccountById(accountId); err != nil {
+		return nil, errors.New("Invalid account")
+	}
+	mapping, err := ParseColumnMapping(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	format := "csv"
+	if strings.HasSuffix(strings.ToLower(filename), ".json") {
+		format = "json"
+	}
+	rows, err := ParseImport(file, format, mapping)
+	if err != nil {
+		return nil, err
+	}
+	return importTasks(rows, accountId, dryRun, true)
+}
+
+// syncTodoTxt syncs a todo.txt file with the tasks of the account in both directions, see PlanTodoTxtSync.
+// The lines of the last sync are kept in a state file next to it, with the extension ".sync".
+// Conflicts and lines that cannot be turned into tasks are reported, and stay in the file as they are.
+func syncTodoTxt(filename string, accountId int) (*TodoTxtSyncReport, error) {
+	if _, err := GetAccountById(accountId); err != nil {
+		return nil, errors.New("Invalid account")
+	}
+
+	items := []TodoTxtItem{}
+	file, err := os.Open(filename)
+	if err == nil {
+		items, err = ParseTodoTxt(file)
+		file.Close()
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	state := map[int]string{}
+	js, err := os.ReadFile(filename + ".sync")
+	if err == nil {
+		err = json.Unmarshal(js, &state)
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	before, err := getTodoTxtTasks(accountId)
+	if err != nil {
+		return nil, err
+	}
+	tasks := map[int]TodoTxtItem{}
+	for i := range before {
+		tasks[before[i].Task.Id] = before[i].Item()
+	}
+	plan := PlanTodoTxtSync(items, tasks, state)
+	report := &TodoTxtSyncReport{Removed: plan.Removed, Failed: []ImportResult{}, Conflicts: plan.Conflicts}
+
+	// lines changed only in the file are imported, the tasks get their ids from it
+	changed, indexes := []TodoTxtItem{}, []int{}
+	for i, line := range plan.Lines {
+		if line.Action != "" {
+			changed = append(changed, line.Item)
+			indexes = append(indexes, i)
+		}
+	}
+	var imported *ImportReport
+	var save func(tx *sql.Tx) error
+	if len(changed) > 0 {
+		imported, save, err = planTodoTxtImport(changed, accountId, false, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// lines deleted only in the file move their tasks to the trash, as a single undo step
+	now := int(time.Now().Unix())
+	olds := map[int]Task{}
+	for i := range before {
+		olds[before[i].Task.Id] = before[i].Task
+	}
+	trashes := Tasks{}
+	for _, id := range plan.Deletes {
+		trashes = append(trashes, olds[id])
+	}
+
+	// the account is either synced as a whole or left as it was
+	err = transaction(func(tx *sql.Tx) error {
+		if save != nil {
+			if err := save(tx); err != nil {
+				return err
+			}
+		}
+		if len(trashes) == 0 {
+			return nil
+		}
+
+		if err := trashes.trashTx(tx, now, 0); err != nil {
+			return err
+		}
+		changes := []TaskChange{}
+		for i := range trashes {
+			old := olds[trashes[i].Id]
+			if err := DiffChanges(EntityTask, old.Id, ActionDelete, accountId, now, old, trashes[i]).saveTx(tx); err != nil {
+				return err
+			}
+			changes = append(changes, TaskChange{&old, &trashes[i]})
+		}
+		step := UndoStep{-1, accountId, now, changes}
+		return step.saveTx(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	report.Deleted = len(trashes)
+
+	failed := map[int]bool{}
+	if imported != nil {
+		report.Created, report.Updated = imported.Created, imported.Updated
+		for i, result := range imported.Results {
+			if result.Error != "" {
+				report.Failed = append(report.Failed, result)
+				failed[indexes[i]] = true
+				continue
+			}
+			plan.Lines[indexes[i]].Id = result.Task.Id
+		}
+	}
+
+	after, err := getTodoTxtTasks(accountId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range after {
+		tasks[after[i].Task.Id] = after[i].Item()
+	}
+
+	// conflicts and failed lines keep the state of the last sync, so that they are found again
+	lines, synced := []string{}, map[int]string{}
+	for i, line := range plan.Lines {
+		item := line.Item
+		if line.Action != "" && !failed[i] {
+			item = tasks[line.Id]
+		}
+		if line.FromAccount {
+			report.Written++
+		}
+		if line.Conflict || failed[i] {
+			if base, ok := state[line.Id]; ok && line.Id > 0 {
+				synced[line.Id] = base
+			}
+		} else {
+			synced[line.Id] = item.String()
+		}
+		lines = append(lines, item.String()+"\n")
+	}
+
+	if err := os.WriteFile(filename, []byte(strings.Join(lines, "")), 0644); err != nil {
+		return nil, err
+	}
+	js, err = json.Marshal(synced)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filename+".sync", js, 0644); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// exportTasks writes the tasks of the account, or of all accounts for account id 0, in the export format.
+func exportTasks(w io.Writer, format string, accountId int) error {
+	exporter, err := NewTaskExporter(format, w)
+	if err != nil {
+		return err
+	}
+	if accountId > 0 {
+		err = StreamTasksByAccountId(accountId, exporter.Export)
+	} else {
+		err = StreamAllTasks(exporter.Export)
+	}
+	if err != nil {
+		return err
+	}
+	return exporter.Close()
 }
 
 func authHandler(mh MethodHandler) http.HandlerFunc {
@@ -105,6 +545,21 @@ func authHandler(mh MethodHandler) http.HandlerFunc {
 	}
 }
 
+// subresourceHandler routes requests like /task/{taskId}/tags to the handler of the subresource.
+func subresourceHandler(h http.HandlerFunc, sh SubresourceHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if v := validSubPath.FindStringSubmatch(r.URL.Path); v != nil {
+			if handler, ok := sh[v[3]]; ok {
+				handler(w, r)
+			} else {
+				http.NotFound(w, r)
+			}
+			return
+		}
+		h(w, r)
+	}
+}
+
 func getLogin(r *http.Request) string {
 	query := r.URL.Query()
 	return query.Get("login")
@@ -120,6 +575,97 @@ func getId(w http.ResponseWriter, r *http.Request) (int, error) {
 	return strconv.Atoi(v[2])
 }
 
+// getSubIds returns the id of the parent resource and the id of the subresource.
+// The subresource id is -1 if the URL does not contain one.
+func getSubIds(w http.ResponseWriter, r *http.Request) (int, int, error) {
+	// validate URL Path
+	v := validSubPath.FindStringSubmatch(r.URL.Path)
+	if v == nil {
+		http.NotFound(w, r)
+		return -1, -1, errors.New("Invalid URL")
+	}
+	id, err := strconv.Atoi(v[2])
+	if err != nil {
+		http.NotFound(w, r)
+		return -1, -1, err
+	}
+	if v[5] == "" {
+		return id, -1, nil
+	}
+	subId, err := strconv.Atoi(v[5])
+	if err != nil {
+		http.NotFound(w, r)
+		return -1, -1, err
+	}
+	return id, subId, nil
+}
+
+// getTrashId returns the kind of item ("task" or "account") and its id, for URLs like /trash/task/{taskId}.
+func getTrashId(w http.ResponseWriter, r *http.Request) (string, int, error) {
+	// validate URL Path
+	v := validTrashPath.FindStringSubmatch(r.URL.Path)
+	if v == nil {
+		http.NotFound(w, r)
+		return "", -1, errors.New("Invalid URL")
+	}
+	id, err := strconv.Atoi(v[2])
+	if err != nil {
+		http.NotFound(w, r)
+		return "", -1, err
+	}
+	return v[1], id, nil
+}
+
+// setETag sets the ETag header to the given version of a task or account.
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", "\""+strconv.Itoa(version)+"\"")
+}
+
+// getIfMatch returns the version required by the If-Match header, 0 for "*" and -1 if there is no such header.
+// A header without a valid version can never match, in which case 412 has already been written to the response.
+func getIfMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return -1, true
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	version, err := strconv.Atoi(strings.Trim(header, "\""))
+	if err != nil || version < 1 {
+		http.Error(w, ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+		return -1, false
+	}
+	return version, true
+}
+
+// checkNotModified writes 304 to the response and returns true if the If-None-Match header matches the given version.
+func checkNotModified(w http.ResponseWriter, r *http.Request, version int) bool {
+	if r.Header.Get("If-None-Match") == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || strings.Trim(tag, "\"") == strconv.Itoa(version) {
+			setETag(w, version)
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// writeSaveError writes 412 to the response if a conditional save failed because of another version, 500 otherwise.
+func writeSaveError(w http.ResponseWriter, err error) {
+	if err == ErrVersionMismatch {
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
 func getAuth(w http.ResponseWriter, r *http.Request) {
 	if isLogging {
 		log.Println("get Auth")
@@ -155,280 +701,4574 @@ func getAuth(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(auth))
 }
 
-func getTasks(w http.ResponseWriter, r *http.Request, accountId int) {
-	if isLogging {
-		log.Println("get Tasks")
+func checkOwnerOrAdmin(w http.ResponseWriter, ownerId int, accountId int) bool {
+	if ownerId == accountId {
+		return true
 	}
+	return checkAdmin(w, accountId)
+}
 
-	tasks, err := GetTasksByAccountId(accountId)
+// checkAdmin verifies that the account has role "Admin".
+func checkAdmin(w http.ResponseWriter, accountId int) bool {
+	account, err := GetAccountById(accountId)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return false
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return false
+		}
 	}
+	if account.Role != "Admin" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
 
-	js, err := json.Marshal(tasks)
+// checkTaskPermission verifies that the task belongs to the account, got shared with
+// it with at least the given permission level, or that the account has role "Admin".
+func checkTaskPermission(w http.ResponseWriter, task *Task, accountId int, permission int) bool {
+	if task.AccountId == accountId {
+		return true
+	}
+
+	level, err := GetTaskPermission(task, accountId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return false
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(js)
+	if level >= permission {
+		return true
+	}
+	return checkOwnerOrAdmin(w, task.AccountId, accountId)
 }
 
-func getTask(w http.ResponseWriter, r *http.Request, accountId int) {
-	id, err := getId(w, r)
+// checkProjectPermission is the same as checkTaskPermission, but for projects.
+func checkProjectPermission(w http.ResponseWriter, project *Project, accountId int, permission int) bool {
+	if project.AccountId == accountId {
+		return true
+	}
+
+	level, err := GetProjectPermission(project, accountId)
 	if err != nil {
-		return
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if level >= permission {
+		return true
 	}
+	return checkOwnerOrAdmin(w, project.AccountId, accountId)
+}
 
-	if isLogging {
-		log.Printf("get Task[%v]", id)
+// checkTeamPermission verifies that the account is a member of the team, with a role granting
+// at least the given permission level, or that the account has role "Admin".
+func checkTeamPermission(w http.ResponseWriter, teamId int, accountId int, permission int) bool {
+	member, err := GetMember(teamId, accountId)
+	if err != nil && strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
 	}
+	if member != nil && member.Level() >= permission {
+		return true
+	}
+	return checkAdmin(w, accountId)
+}
 
+// getTaskForAccount returns the task if the account is allowed to access it with the given permission level.
+// Otherwise the error has already been written to the response and nil is returned.
+func getTaskForAccount(w http.ResponseWriter, r *http.Request, id int, accountId int, permission int) *Task {
 	task, err := GetTaskById(id)
 	if err != nil {
 		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
 			http.NotFound(w, r)
-			return
+			return nil
 		} else {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
+			return nil
 		}
 	}
 
-	// check if task belongs to account id, or if account has role "Admin"
-	if task.AccountId != accountId {
-		account, err := GetAccountById(accountId)
-		if err != nil {
-			if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return
-			} else {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-		}
-		if account.Role != "Admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
+	// check if task belongs to account id, got shared with it, or if account has role "Admin"
+	if !checkTaskPermission(w, task, accountId, permission) {
+		return nil
 	}
+	return task
+}
 
-	js, err := json.Marshal(task)
+// checkProject verifies that tasks of the given account can be put into the project.
+// That is the case for the accounts own projects, and projects of teams it can edit.
+func checkProject(w http.ResponseWriter, projectId int, accountId int) bool {
+	allowed, err := projectAllowed(projectId, accountId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return false
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(js)
+	if !allowed {
+		http.Error(w, ErrInvalidProject.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
 }
 
-func addTask(w http.ResponseWriter, r *http.Request, accountId int) {
-	if isLogging {
-		log.Println("add Task")
+// checkProjectEdit verifies that the account may move tasks into the project, which needs Edit permission on it.
+// Editing a task of another account through a share does not grant that for the other projects of its owner.
+func checkProjectEdit(w http.ResponseWriter, projectId int, accountId int) bool {
+	if projectId == 0 {
+		return true
 	}
 
-	if err := r.ParseForm(); err != nil {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
-		return
+	project, err := GetProjectById(projectId)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.Error(w, ErrInvalidProject.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return false
 	}
+	return checkProjectPermission(w, project, accountId, PermissionEdit)
+}
 
-	data := r.Form
-	id := -1 // POST ignores taskId and always uses -1 to create a new task entry
-	accId, err := strconv.Atoi(data.Get("AccountId"))
+// projectAllowed returns true if tasks of the account may belong to the project, which is the case
+// for its own projects and those of teams it can edit in. Unknown projects are not allowed.
+func projectAllowed(projectId int, accountId int) (bool, error) {
+	if projectId == 0 { // tasks do not need to belong to a project
+		return true, nil
+	}
+
+	project, err := GetProjectById(projectId)
 	if err != nil {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
-		return
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			return false, nil
+		}
+		return false, err
+	}
+	if project.AccountId == accountId {
+		return true, nil
 	}
 
-	// timestamps upon task creating are enforced by server and cannot be overwritten by client
-	created := int(time.Now().Unix())
-	lastUpdated := int(time.Now().Unix())
+	if project.TeamId > 0 {
+		member, err := GetMember(project.TeamId, accountId)
+		if err != nil && strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
+			return false, err
+		}
+		if member != nil && member.Level() >= PermissionEdit {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func getTasks(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Tasks")
+	}
+
+	listTasks(w, r, accountId, nil)
+}
+
+// listTasks writes the tasks visible to the account, as selected by the query parameters of the request.
+// The filter of a view further restricts the tasks, and its sort keys apply unless the request sorts by itself.
+func listTasks(w http.ResponseWriter, r *http.Request, accountId int, view *View) {
+	var tasks *Tasks
+	query := r.URL.Query()
 
-	priority, err := strconv.Atoi(data.Get("Priority"))
+	// the filter expression is turned into SQL, so that only matching tasks are read at all.
+	// It is parsed on its own and only then combined with the filter of the view, so that it cannot escape from it.
+	var filter FilterNode
+	if expr := query.Get("filter"); expr != "" {
+		var err error
+		filter, err = ParseFilter(expr)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if view != nil && strings.TrimSpace(view.Filter) != "" {
+		viewFilter, err := ParseFilter(view.Filter)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		filter = AndFilters(viewFilter, filter)
+	}
+	sortValue := query.Get("sort")
+	if view != nil && sortValue == "" && query.Get("sortBy") == "" {
+		sortValue = view.Sort
+	}
+	keys, err := ParseSortKeys(sortValue, taskSortFields)
 	if err != nil {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-
-	task := Task{
-		id,
-		accId,
-		created,
-		lastUpdated,
-		priority,
-		data.Get("Task"),
+	// ?sortBy= and ?sortOrder= are the single key form of ?sort=
+	if len(keys) == 0 && query.Get("sortBy") != "" {
+		order := strings.ToUpper(query.Get("sortOrder"))
+		if order == "" {
+			order = "ASC"
+		}
+		key, ok := taskSortKey(query.Get("sortBy"), order)
+		if !ok || (order != "ASC" && order != "DESC") {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+		keys = SortKeys{key}
 	}
 
-	// check if task belongs to account id, or if account has role "Admin"
-	if task.AccountId != accountId {
-		account, err := GetAccountById(accountId)
+	var project *Project
+	if query.Get("project") != "" {
+		projectId, err := strconv.Atoi(query.Get("project"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+
+		project, err = GetProjectById(projectId)
 		if err != nil {
 			if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				http.NotFound(w, r)
+				return
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
+			}
+		}
+
+		// check if project belongs to account id, got shared with it, or if account has role "Admin"
+		if !checkProjectPermission(w, project, accountId, PermissionView) {
+			return
+		}
+	}
+
+	// pages need a well defined order, which is the one of the project or the default order without sort keys
+	paged := query.Get("limit") != "" || query.Get("cursor") != ""
+	if paged && len(keys) == 0 {
+		keys = defaultTaskSortKeys
+		if project != nil && project.SortBy != "" {
+			keys = SortKeys{}
+			if key, ok := taskSortKey(project.SortBy, project.SortOrder); ok {
+				keys = SortKeys{key}
+			}
+		}
+	}
+	page := Page{}
+	if paged {
+		var ok bool
+		if page, ok = getPage(w, r, "tasks", keys); !ok {
+			return
+		}
+	}
+
+	// tags are filtered afterwards, so pages with tag filters are cut out of all tasks
+	tagFilter := TagFilter{
+		ParseTagNames(query.Get("anyTags")),
+		ParseTagNames(query.Get("allTags")),
+		ParseTagNames(query.Get("noneTags")),
+	}
+	inSQL := keys.InSQL(taskSortFields) && len(tagFilter.Any)+len(tagFilter.All)+len(tagFilter.None) == 0
+	sqlPage := Page{}
+	if inSQL {
+		sqlPage = page
+	}
+
+	if project != nil {
+		tasks, err = GetTasksByProjectIdAndFilter(project.Id, filter, keys, sqlPage)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(keys) == 0 {
+			project.SortTasks(tasks)
+		}
+	} else {
+		tasks, err = GetVisibleTasksByAccountIdAndFilter(accountId, filter, keys, sqlPage)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tasks, err = tasks.FilterByTags(tagFilter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if paged {
+		total := -1
+		if query.Get("total") == "true" {
+			if inSQL && project != nil {
+				total, err = CountTasksByProjectIdAndFilter(project.Id, filter)
+			} else if inSQL {
+				total, err = CountVisibleTasksByAccountIdAndFilter(accountId, filter)
 			} else {
+				total = len(*tasks)
+			}
+			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
 		}
-		if account.Role != "Admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+
+		var result PageResult
+		tasks, result = tasks.Page(keys, page, inSQL)
+		setPageHeaders(w, r, page, result, total)
+	} else if len(keys) > 0 && !keys.InSQL(taskSortFields) {
+		// an explicit sort order overrides the one of the project, "Rank" gives the manual order.
+		// Sort keys the database could not sort by are sorted here.
+		tasks.SortByKeys(keys)
+	}
+
+	js, err := json.Marshal(tasks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getPage reads the cursor and limit of a page from the query parameters.
+func getPage(w http.ResponseWriter, r *http.Request, list string, keys SortKeys) (Page, bool) {
+	query := r.URL.Query()
+	page := Page{nil, defaultPageSize}
+	if query.Get("limit") != "" {
+		limit, err := strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return page, false
+		}
+		if limit > maxPageSize {
+			limit = maxPageSize
+		}
+		page.Limit = limit
+	}
+	if query.Get("cursor") != "" {
+		cursor, err := DecodeCursor(query.Get("cursor"), list, keys)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return page, false
+		}
+		page.Cursor = cursor
+	}
+	return page, true
+}
+
+// setPageHeaders links the next and previous pages in the Link header, and sets X-Total-Count unless total is negative.
+func setPageHeaders(w http.ResponseWriter, r *http.Request, page Page, result PageResult, total int) {
+	links := []string{}
+	for _, link := range []struct {
+		cursor *Cursor
+		rel    string
+	}{{result.Next, "next"}, {result.Prev, "prev"}} {
+		if link.cursor == nil {
+			continue
+		}
+		query := r.URL.Query()
+		query.Set("cursor", EncodeCursor(link.cursor))
+		query.Set("limit", strconv.Itoa(page.Limit))
+		links = append(links, "<"+r.URL.Path+"?"+query.Encode()+">; rel=\""+link.rel+"\"")
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+	if total >= 0 {
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	}
+}
+
+// searchTasks returns the tasks matching the full-text query ?q=, which are visible to the account used in the request.
+func searchTasks(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("search Tasks")
+	}
+
+	query := r.URL.Query()
+	if strings.TrimSpace(query.Get("q")) == "" {
+		http.Error(w, ErrInvalidSearch.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := maxSearchResults
+	if query.Get("limit") != "" {
+		var err error
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+		if limit > maxSearchResults {
+			limit = maxSearchResults
+		}
+	}
+
+	results, err := SearchTasks(accountId, query.Get("q"), limit)
+	if err != nil {
+		switch err {
+		case ErrInvalidSearch:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case ErrSearchUnavailable:
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	js, err := json.Marshal(results)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getPriorities returns the priority scale, so that clients know the names and colors of all priority levels.
+func getPriorities(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Priorities")
+	}
+
+	js, err := json.Marshal(GetPriorityScale())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getAssignedTasks returns all tasks assigned to the account used in the request, regardless of their owner.
+func getAssignedTasks(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get assigned Tasks")
+	}
+
+	tasks, err := GetTasksByAssigneeId(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(tasks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getTaskExport downloads the tasks of the account used in the request as JSON, CSV, Markdown or iCalendar,
+// picked by the query parameter ?format=, or otherwise by the Accept header.
+// An "Admin" can export the tasks of all accounts with ?all=true.
+func getTaskExport(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("export Tasks")
+	}
+
+	query := r.URL.Query()
+	format, err := NegotiateExportFormat(query.Get("format"), r.Header.Get("Accept"))
+	if err == ErrNotAcceptable {
+		http.Error(w, err.Error(), http.StatusNotAcceptable)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	exportAccountId := accountId
+	if query.Get("all") != "" {
+		all, err := strconv.ParseBool(query.Get("all"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+		if all {
+			if !checkAdmin(w, accountId) {
+				return
+			}
+			exportAccountId = 0
+		}
+	}
+
+	w.Header().Set("Content-Type", exportFormats[format])
+	w.Header().Set("Content-Disposition", "attachment; filename=\"tasks."+exportFileExtensions[format]+"\"")
+	// once the first task is written the status can no longer be changed, errors only end the export early
+	if err := exportTasks(w, format, exportAccountId); err != nil {
+		log.Printf("Export of tasks failed: [%v]", err)
+	}
+}
+
+// postTaskImport imports the tasks of a CSV or JSON file in the request body into the account used in the request,
+// and reports the result of every row. The format is given by ?format= (csv or json), or otherwise by the Content-Type.
+// Use ?columns= to map CSV columns to task fields, ?dryRun=true to only preview the import,
+// and ?continueOnError=true to import the valid rows even if other rows failed.
+func postTaskImport(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("import Tasks")
+	}
+
+	query := r.URL.Query()
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = "csv"
+		case "application/json":
+			format = "json"
+		}
+	}
+	columns, err := ParseColumnMapping(query.Get("columns"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	dryRun, continueOnError, ok := getImportOptions(w, query)
+	if !ok {
+		return
+	}
+
+	rows, err := ParseImport(http.MaxBytesReader(w, r.Body, 10<<20), format, columns)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := importTasks(rows, accountId, dryRun, continueOnError)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Imported && !report.DryRun {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	w.Write(js)
+}
+
+// getFeed returns the tasks of the account with the token in the path as iCalendar feed, for calendar clients that cannot sign requests.
+// Unknown tokens are not found, just like any other unknown path.
+func getFeed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	v := validFeedPath.FindStringSubmatch(r.URL.Path)
+	if v == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	token, err := GetFeedTokenByHash(HashFeedToken(v[1]))
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	// accounts in the trash keep their token, but not their feed
+	account, err := GetAccountById(token.AccountId)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	// neither do accounts that may not sign in
+	if account.Role == "None" || account.Role == "" {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Feed of Account[%v]", token.AccountId)
+	}
+
+	w.Header().Set("Content-Type", exportFormats["ical"])
+	if err := exportTasks(w, "ical", token.AccountId); err != nil {
+		log.Printf("Feed of tasks failed: [%v]", err)
+	}
+}
+
+// getFeedToken returns when the feed token of the account used in the request was created, the token itself cannot be shown again.
+func getFeedToken(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Feed Token")
+	}
+
+	token, err := GetFeedTokenByAccountId(accountId)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(fmt.Sprintf("{\"Created\": %v}", token.Created)))
+}
+
+// addFeedToken creates a new feed token for the account used in the request, and returns it along with the path of the feed.
+// A previous token of the account stops working.
+func addFeedToken(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("add Feed Token")
+	}
+
+	token, secret, err := NewFeedToken(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := token.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(map[string]interface{}{
+		"Token":   secret,
+		"Path":    "/feed/" + secret + ".ics",
+		"Created": token.Created,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// deleteFeedToken revokes the feed token of the account used in the request.
+func deleteFeedToken(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("delete Feed Token")
+	}
+
+	token := FeedToken{AccountId: accountId}
+	if err := token.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+// getImportOptions parses the query parameters dryRun and continueOnError of an import, both are false by default.
+func getImportOptions(w http.ResponseWriter, query url.Values) (bool, bool, bool) {
+	var options [2]bool
+	for i, name := range []string{"dryRun", "continueOnError"} {
+		if query.Get(name) != "" {
+			var err error
+			if options[i], err = strconv.ParseBool(query.Get(name)); err != nil {
+				http.Error(w, "Invalid data", http.StatusBadRequest)
+				return false, false, false
+			}
+		}
+	}
+	return options[0], options[1], true
+}
+
+// getTodoTxt downloads the tasks of the account used in the request as todo.txt file, see TodoTxtTask.Item.
+func getTodoTxt(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get todo.txt")
+	}
+
+	tasks, err := getTodoTxtTasks(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"todo.txt\"")
+	for i := range tasks {
+		io.WriteString(w, tasks[i].Item().String()+"\n")
+	}
+}
+
+// postTodoTxt imports the todo.txt file in the request body into the account used in the request, and reports the result of every line.
+// Lines with the id: of a task of the account update that task. Use ?dryRun=true to only preview the import,
+// and ?continueOnError=true to import the valid lines even if other lines failed.
+func postTodoTxt(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("import todo.txt")
+	}
+
+	dryRun, continueOnError, ok := getImportOptions(w, r.URL.Query())
+	if !ok {
+		return
+	}
+
+	items, err := ParseTodoTxt(http.MaxBytesReader(w, r.Body, 10<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	report, err := importTodoTxt(items, accountId, dryRun, continueOnError)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(report)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !report.Imported && !report.DryRun {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	w.Write(js)
+}
+
+// getTodoTxtTasks returns the tasks of the account in the order of their ids, with the names of their projects and tags.
+func getTodoTxtTasks(accountId int) ([]TodoTxtTask, error) {
+	tasks := []TodoTxtTask{}
+	if err := StreamTasksByAccountId(accountId, func(t *Task) error {
+		tasks = append(tasks, TodoTxtTask{*t, "", []string{}})
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	projects := map[int]string{}
+	for i := range tasks {
+		t := &tasks[i]
+		if t.Task.ProjectId > 0 {
+			name, ok := projects[t.Task.ProjectId]
+			if !ok {
+				project, err := GetProjectById(t.Task.ProjectId)
+				if err != nil && strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
+					return nil, err
+				} else if err == nil {
+					name = project.Name
+				}
+				projects[t.Task.ProjectId] = name
+			}
+			t.Project = name
+		}
+
+		tags, err := GetTagsByTaskId(t.Task.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range *tags {
+			t.Tags = append(t.Tags, tag.Name)
+		}
+	}
+	return tasks, nil
+}
+
+// importTodoTxt validates the items and imports them as tasks of the account, like importTasks does for rows.
+// An item with the id of a task of the account updates that task, which keeps its assignee and rank, and its timestamps if the item has the same dates.
+// Projects and tags are found by name, and created for the account if there is none of the name yet.
+// Nothing is imported for a dry run, or if any item failed unless continueOnError is set, and otherwise all of it within a single transaction.
+func importTodoTxt(items []TodoTxtItem, accountId int, dryRun bool, continueOnError bool) (*ImportReport, error) {
+	report, save, err := planTodoTxtImport(items, accountId, dryRun, continueOnError)
+	if err != nil {
+		return nil, err
+	}
+	if save != nil {
+		if err := transaction(save); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// planTodoTxtImport validates the items for importTodoTxt, and returns its report along with the function that saves the import within a transaction.
+// The function is nil if nothing is to be imported, the tasks of the report get their ids once it has been called.
+func planTodoTxtImport(items []TodoTxtItem, accountId int, dryRun bool, continueOnError bool) (*ImportReport, func(tx *sql.Tx) error, error) {
+	own := map[int]TodoTxtTask{}
+	tasks, err := getTodoTxtTasks(accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, t := range tasks {
+		own[t.Task.Id] = t
+	}
+	projects, err := GetVisibleProjectsByAccountId(accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err := GetTagsByAccountId(accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := int(time.Now().Unix())
+
+	report := &ImportReport{DryRun: dryRun, Results: []ImportResult{}}
+	todos, changes, indexes := []TodoTxtTask{}, []TaskChange{}, []int{}
+	seen := map[int]bool{}
+	for _, item := range items {
+		result := ImportResult{item.Line, "", ActionCreate, nil, ""}
+		t, err := item.Validate(accountId)
+		if err == nil && seen[t.Task.Id] {
+			err = errors.New("Task is part of the import more than once")
+		}
+		if err != nil {
+			result.Action = ""
+			result.Error = err.Error()
+			report.Results = append(report.Results, result)
+			report.Failed++
+			continue
+		}
+		for _, project := range *projects {
+			if !todoTxtNameMatches(project.Name, t.Project) {
+				continue
+			}
+			allowed, err := projectAllowed(project.Id, accountId)
+			if err != nil {
+				return nil, nil, err
+			}
+			if allowed {
+				t.Task.ProjectId = project.Id
+				break
+			}
+		}
+		for i, name := range t.Tags {
+			for _, tag := range *tags {
+				if todoTxtNameMatches(tag.Name, name) {
+					t.Tags[i] = tag.Name
+					break
+				}
+			}
+		}
+
+		change := TaskChange{nil, nil}
+		if old, ok := own[t.Task.Id]; ok {
+			seen[t.Task.Id] = true
+			result.ExternalId = strconv.Itoa(old.Task.Id)
+			t.Task.AssigneeId = old.Task.AssigneeId
+			t.Task.Rank = old.Task.Rank
+			if item.Created == "" || item.Created == todoTxtDay(old.Task.Created) {
+				t.Task.Created = old.Task.Created
+			}
+			// tasks that stay done keep the time they were done
+			if item.Done && old.Task.Status == "Done" && (item.Completed == "" || item.Completed == todoTxtDay(old.Task.LastUpdated)) {
+				t.Task.LastUpdated = old.Task.LastUpdated
+			}
+			change.Before = &old.Task
+			result.Action = ActionUpdate
+			report.Updated++
+		} else {
+			t.Task.Id = -1
+			report.Created++
+		}
+
+		todos = append(todos, t)
+		changes = append(changes, change)
+		indexes = append(indexes, len(report.Results))
+		report.Results = append(report.Results, result)
+	}
+
+	report.Imported = !dryRun && (report.Failed == 0 || continueOnError)
+
+	for i, j := range indexes {
+		report.Results[j].Task = &todos[i].Task
+	}
+	if !report.Imported || len(todos) == 0 {
+		return report, nil, nil
+	}
+
+	save := func(tx *sql.Tx) error {
+		for i := range todos {
+			if err := saveTodoTxtTaskTx(tx, &todos[i], projects); err != nil {
+				return err
+			}
+		}
+
+		// the whole import makes up a single undo step
+		for i := range todos {
+			changes[i].After = &todos[i].Task
+			old := Task{}
+			if changes[i].Before != nil {
+				old = *changes[i].Before
+			}
+			action := report.Results[indexes[i]].Action
+			if err := DiffChanges(EntityTask, todos[i].Task.Id, action, accountId, now, old, todos[i].Task).saveTx(tx); err != nil {
+				return err
+			}
+		}
+		step := UndoStep{-1, accountId, now, changes}
+		return step.saveTx(tx)
+	}
+	return report, save, nil
+}
+
+// saveTodoTxtTaskTx saves the task along with its project and tags. A new project is added to the projects,
+// so that the next tasks of the same name find it. The tags of the task are replaced by those of the item.
+func saveTodoTxtTaskTx(tx *sql.Tx, t *TodoTxtTask, projects *Projects) error {
+	if t.Project != "" && t.Task.ProjectId == 0 {
+		project := Project{-1, t.Task.AccountId, t.Project, "", "ASC", 0, 0}
+		if err := project.saveTx(tx); err != nil {
+			return err
+		}
+		*projects = append(*projects, project)
+		t.Task.ProjectId = project.Id
+	}
+
+	if err := t.Task.saveTx(tx); err != nil {
+		return err
+	}
+	return t.Task.setTagsTx(tx, t.Tags)
+}
+
+// importTasks validates the rows under the same rules as addTask, and imports them as tasks of the account.
+// A row with an external id the account has imported before updates that task instead, which keeps everything the import does not contain.
+// Nothing is imported for a dry run, or if any row failed unless continueOnError is set.
+func importTasks(rows []ImportRow, accountId int, dryRun bool, continueOnError bool) (*ImportReport, error) {
+	imported, err := GetImportedTasks(accountId)
+	if err != nil {
+		return nil, err
+	}
+	now := int(time.Now().Unix())
+
+	report := &ImportReport{DryRun: dryRun, Results: []ImportResult{}}
+	tasks, externalIds, changes := Tasks{}, []string{}, []TaskChange{}
+	indexes := []int{}
+	seen := map[string]bool{}
+	for _, row := range rows {
+		result := ImportResult{row.Row, row.ExternalId, ActionCreate, nil, ""}
+		task, err := row.Validate(accountId)
+		if err == nil && row.ExternalId != "" && seen[row.ExternalId] {
+			err = errors.New("External id is part of the import more than once")
+		}
+		if err == nil {
+			allowed, perr := projectAllowed(task.ProjectId, accountId)
+			if perr != nil {
+				return nil, perr
+			}
+			if !allowed {
+				err = ErrInvalidProject
+			}
+		}
+		if err != nil {
+			result.Action = ""
+			result.Error = err.Error()
+			report.Results = append(report.Results, result)
+			report.Failed++
+			continue
+		}
+		if row.ExternalId != "" {
+			seen[row.ExternalId] = true
+		}
+
+		change := TaskChange{nil, nil}
+		if old, ok := imported[row.ExternalId]; ok && row.ExternalId != "" {
+			task.Id = old.Id
+			task.AssigneeId = old.AssigneeId
+			task.Rank = old.Rank
+			if row.Created == "" {
+				task.Created = old.Created
+			}
+			if row.LastUpdated == "" {
+				task.LastUpdated = now
+			}
+			change.Before = &old
+			result.Action = ActionUpdate
+			report.Updated++
+		} else {
+			report.Created++
+		}
+
+		tasks = append(tasks, task)
+		externalIds = append(externalIds, row.ExternalId)
+		changes = append(changes, change)
+		indexes = append(indexes, len(report.Results))
+		report.Results = append(report.Results, result)
+	}
+
+	if !dryRun && (report.Failed == 0 || continueOnError) && len(tasks) > 0 {
+		err := transaction(func(tx *sql.Tx) error {
+			if err := importTasksTx(tx, accountId, tasks, externalIds); err != nil {
+				return err
+			}
+			for i := range tasks {
+				changes[i].After = &tasks[i]
+				old := Task{}
+				if changes[i].Before != nil {
+					old = *changes[i].Before
+				}
+				action := report.Results[indexes[i]].Action
+				if err := DiffChanges(EntityTask, tasks[i].Id, action, accountId, now, old, tasks[i]).saveTx(tx); err != nil {
+					return err
+				}
+			}
+
+			// the whole import makes up a single undo step
+			step := UndoStep{-1, accountId, now, changes}
+			return step.saveTx(tx)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	report.Imported = !dryRun && (report.Failed == 0 || continueOnError)
+
+	for i, j := range indexes {
+		report.Results[j].Task = &tasks[i]
+	}
+	return report, nil
+}
+
+// getAllTasks returns the tasks of all accounts, for support and moderation by an "Admin".
+// Unlike /tasks/ the tasks are always returned in pages, see getTaskListFilter for the query parameters to filter them.
+func getAllTasks(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get all Tasks")
+	}
+
+	if !checkAdmin(w, accountId) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter, err := getTaskListFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	keys, err := ParseSortKeys(query.Get("sort"), taskSortFields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(keys) == 0 {
+		keys = defaultTaskSortKeys
+	}
+	page, ok := getPage(w, r, "tasks", keys)
+	if !ok {
+		return
+	}
+	inSQL := keys.InSQL(taskSortFields)
+	sqlPage := Page{}
+	if inSQL {
+		sqlPage = page
+	}
+
+	tasks, err := GetAllTasksByFilter(filter, keys, sqlPage)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	total := -1
+	if query.Get("total") == "true" {
+		if inSQL {
+			total, err = CountAllTasksByFilter(filter)
+		} else {
+			total = len(*tasks)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	tasks, result := tasks.Page(keys, page, inSQL)
+	setPageHeaders(w, r, page, result, total)
+
+	js, err := json.Marshal(tasks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getAllTaskCounts returns the number of tasks of every account, filtered just like getAllTasks. Only an "Admin" can request this.
+func getAllTaskCounts(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get all Task Counts")
+	}
+
+	if !checkAdmin(w, accountId) {
+		return
+	}
+
+	filter, err := getTaskListFilter(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	counts, err := CountTasksPerAccount(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(counts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getTaskListFilter combines the filter expression of ?filter= with the query parameters
+// ?account= (a comma separated list of account ids), ?minPriority=, ?maxPriority=,
+// and ?from= and ?to=, which select tasks created at or after from, and before to.
+func getTaskListFilter(query url.Values) (FilterNode, error) {
+	var filter FilterNode
+	if query.Get("filter") != "" {
+		var err error
+		if filter, err = ParseFilter(query.Get("filter")); err != nil {
+			return nil, err
+		}
+	}
+
+	var accounts FilterNode
+	if query.Get("account") != "" {
+		for _, value := range strings.Split(query.Get("account"), ",") {
+			id, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, errors.New("Invalid data")
+			}
+			account := &FilterComparison{"account", "=", id, 0}
+			if accounts == nil {
+				accounts = account
+			} else {
+				accounts = &FilterLogical{"OR", accounts, account, 0}
+			}
+		}
+	}
+
+	filters := []FilterNode{filter, accounts}
+	for _, param := range []struct {
+		name string
+		op   string
+	}{{"minPriority", ">="}, {"maxPriority", "<="}} {
+		if query.Get(param.name) != "" {
+			priority, err := ParsePriority(query.Get(param.name))
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, &FilterComparison{"priority", param.op, int(priority), 0})
+		}
+	}
+	for _, param := range []struct {
+		name string
+		op   string
+	}{{"from", ">="}, {"to", "<"}} {
+		if query.Get(param.name) != "" {
+			date, err := parseFilterDate(query.Get(param.name))
+			if err != nil {
+				return nil, errors.New("Invalid data")
+			}
+			filters = append(filters, &FilterComparison{"created", param.op, date, 0})
+		}
+	}
+	return AndFilters(filters...), nil
+}
+
+// bulkTasks applies a list of create, update and delete operations on tasks, and reports the result of each of them.
+// Unless ContinueOnError is set, nothing is applied if any operation fails.
+func bulkTasks(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("bulk Tasks")
+	}
+
+	var bulk BulkRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 10<<20)).Decode(&bulk); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	if len(bulk.Operations) == 0 {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	if len(bulk.Operations) > maxBulkOperations {
+		http.Error(w, "Too many operations", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// every operation is checked on its own, with its errors going to its result instead of the response
+	results := make(BulkResults, len(bulk.Operations))
+	changes := make([]TaskChange, len(bulk.Operations))
+	seen := make(map[int]bool)
+	for i, op := range bulk.Operations {
+		results[i] = BulkResult{op.Op, op.Task.Id, http.StatusOK, ""}
+
+		rw := newBulkResponse()
+		change, ok := checkBulkOperation(rw, r, op, accountId, seen)
+		if !ok {
+			results[i].Status = rw.code
+			results[i].Error = strings.TrimSpace(rw.body.String())
+			continue
+		}
+		changes[i] = change
+	}
+
+	if results.Failed() && !bulk.ContinueOnError {
+		status := http.StatusOK
+		for i := range results {
+			if results[i].Status == http.StatusOK {
+				results[i].Status = http.StatusFailedDependency
+				results[i].Error = "Not applied"
+			} else if status == http.StatusOK {
+				status = results[i].Status
+			}
+		}
+		writeBulkResults(w, results, status)
+		return
+	}
+
+	saves, trashes := Tasks{}, Tasks{}
+	var saved, trashed []int
+	for i, change := range changes {
+		if results[i].Status != http.StatusOK {
+			continue
+		}
+		if bulk.Operations[i].Op == ActionDelete {
+			trashes = append(trashes, *change.After)
+			trashed = append(trashed, i)
+		} else {
+			saves = append(saves, *change.After)
+			saved = append(saved, i)
+		}
+	}
+
+	// all applied operations together make up a single undo step
+	step := []TaskChange{}
+	err := transaction(func(tx *sql.Tx) error {
+		if err := saveAndTrashTx(tx, saves, trashes); err != nil {
+			return err
+		}
+		for j, i := range saved {
+			changes[i].After = &saves[j]
+			results[i].Id = saves[j].Id
+		}
+		for j, i := range trashed {
+			changes[i].After = &trashes[j]
+		}
+
+		for i, change := range changes {
+			if results[i].Status != http.StatusOK {
+				continue
+			}
+			old := Task{}
+			if change.Before != nil {
+				old = *change.Before
+			}
+			if err := recordHistory(tx, EntityTask, change.After.Id, bulk.Operations[i].Op, accountId, old, *change.After); err != nil {
+				return err
+			}
+			step = append(step, change)
+		}
+		if len(step) == 0 {
+			return nil
+		}
+		return recordUndo(tx, accountId, step...)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeBulkResults(w, results, http.StatusOK)
+}
+
+// checkBulkOperation checks a single operation of a bulk request under the same rules as addTask, editTask and deleteTask,
+// and returns the change it is going to make. Otherwise the error has already been written to the response.
+// Assignees cannot be changed by bulk operations.
+func checkBulkOperation(w http.ResponseWriter, r *http.Request, op BulkOperation, accountId int, seen map[int]bool) (TaskChange, bool) {
+	task := op.Task
+	now := int(time.Now().Unix())
+
+	if op.Op == ActionUpdate || op.Op == ActionDelete {
+		if seen[task.Id] {
+			http.Error(w, "Task is part of the request more than once", http.StatusConflict)
+			return TaskChange{}, false
+		}
+		seen[task.Id] = true
+	}
+
+	var err error
+	switch op.Op {
+	case ActionCreate:
+		// check if task belongs to account id, or if account has role "Admin"
+		if !checkOwnerOrAdmin(w, task.AccountId, accountId) {
+			return TaskChange{}, false
+		}
+		if !checkProject(w, task.ProjectId, task.AccountId) {
+			return TaskChange{}, false
+		}
+		if task.Status, err = ParseTaskStatus(task.Status); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return TaskChange{}, false
+		}
+		if !task.Priority.IsValid() {
+			http.Error(w, ErrInvalidPriority.Error(), http.StatusBadRequest)
+			return TaskChange{}, false
+		}
+
+		// timestamps upon task creating are enforced by server and cannot be overwritten by client
+		task.Id = -1
+		task.Created = now
+		task.LastUpdated = now
+		task.AssigneeId = 0
+		task.Deleted = 0
+		task.Rank = ""
+		return TaskChange{nil, &task}, true
+
+	case ActionUpdate:
+		old := getTaskForAccount(w, r, task.Id, accountId, PermissionEdit)
+		if old == nil {
+			return TaskChange{}, false
+		}
+		// fields left out of the operation keep their current values
+		if !op.Has("AccountId") {
+			task.AccountId = old.AccountId
+		}
+		if !op.Has("Priority") {
+			task.Priority = old.Priority
+		}
+		if !op.Has("Task") {
+			task.Task = old.Task
+		}
+		if !op.Has("ProjectId") {
+			task.ProjectId = old.ProjectId
+		}
+		// overwrite accountId only possible if user is the owner or has role "Admin"
+		if task.AccountId != old.AccountId && old.AccountId != accountId {
+			if !checkOwnerOrAdmin(w, old.AccountId, accountId) {
+				return TaskChange{}, false
+			}
+		}
+		if !checkProject(w, task.ProjectId, task.AccountId) {
+			return TaskChange{}, false
+		}
+		if task.ProjectId != old.ProjectId && !checkProjectEdit(w, task.ProjectId, accountId) {
+			return TaskChange{}, false
+		}
+		if task.Status == "" {
+			task.Status = old.Status
+		}
+		if task.Status, err = ParseTaskStatus(task.Status); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return TaskChange{}, false
+		}
+		if !task.Priority.IsValid() {
+			http.Error(w, ErrInvalidPriority.Error(), http.StatusBadRequest)
+			return TaskChange{}, false
+		}
+
+		if task.LastUpdated < 1 {
+			task.LastUpdated = now
+		}
+		task.Created = old.Created
+		task.AssigneeId = old.AssigneeId
+		task.Deleted = old.Deleted
+		task.Rank = old.Rank
+		return TaskChange{old, &task}, true
+
+	case ActionDelete:
+		old := getTaskForAccount(w, r, task.Id, accountId, PermissionManage)
+		if old == nil {
+			return TaskChange{}, false
+		}
+		task = *old
+		return TaskChange{old, &task}, true
+	}
+
+	http.Error(w, "Invalid operation", http.StatusBadRequest)
+	return TaskChange{}, false
+}
+
+func writeBulkResults(w http.ResponseWriter, results BulkResults, status int) {
+	js, err := json.Marshal(results)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+}
+
+// bulkResponse captures what gets written for a single operation of a bulk request,
+// so that the usual checks can be used for each operation on its own.
+type bulkResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newBulkResponse() *bulkResponse {
+	return &bulkResponse{header: make(http.Header)}
+}
+
+func (b *bulkResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bulkResponse) Write(p []byte) (int, error) {
+	if b.code == 0 {
+		b.code = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+func (b *bulkResponse) WriteHeader(code int) {
+	if b.code == 0 {
+		b.code = code
+	}
+}
+
+func getTask(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Task[%v]", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	if checkNotModified(w, r, task.Version) {
+		return
+	}
+
+	js, err := json.Marshal(task)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func addTask(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("add Task")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+	id := -1 // POST ignores taskId and always uses -1 to create a new task entry
+	accId, err := strconv.Atoi(data.Get("AccountId"))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	// timestamps upon task creating are enforced by server and cannot be overwritten by client
+	created := int(time.Now().Unix())
+	lastUpdated := int(time.Now().Unix())
+
+	priority, err := ParsePriority(data.Get("Priority"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	projectId := 0
+	if data.Get("ProjectId") != "" {
+		projectId, err = strconv.Atoi(data.Get("ProjectId"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+	}
+
+	status, err := ParseTaskStatus(data.Get("Status"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	assigneeId, ok := parseAssignee(w, data)
+	if !ok {
+		return
+	}
+
+	task := Task{
+		id,
+		accId,
+		created,
+		lastUpdated,
+		priority,
+		data.Get("Task"),
+		projectId,
+		0,
+		status,
+		0,
+		0,
+		"",
+	}
+
+	// check if task belongs to account id, or if account has role "Admin"
+	if task.AccountId != accountId {
+		account, err := GetAccountById(accountId)
+		if err != nil {
+			if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if account.Role != "Admin" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if !checkProject(w, task.ProjectId, task.AccountId) {
+		return
+	}
+
+	var assignment *Assignment
+	err = transaction(func(tx *sql.Tx) error {
+		if err := task.saveTx(tx); err != nil {
+			return err
+		}
+		if assigneeId > 0 {
+			if assignment, err = task.assignTx(tx, assigneeId, accountId); err != nil {
+				return err
+			}
+		}
+		if err := recordHistory(tx, EntityTask, task.Id, ActionCreate, accountId, Task{}, task); err != nil {
+			return err
+		}
+		return recordUndo(tx, accountId, TaskChange{nil, &task})
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	notifyAssigned(&task, assignment)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+func editTask(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("edit Task[%v]", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+	formAccountId, err := strconv.Atoi(data.Get("AccountId"))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := getIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	action := ActionUpdate
+	task, err := GetTaskById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else {
+			// a task in the trash has to be restored first
+			if _, err := GetTrashedTaskById(id); err == nil {
+				http.Error(w, "Task is in the trash", http.StatusConflict)
+				return
+			}
+			action = ActionCreate
+			task = &Task{}
+			task.Id = id
+			task.AccountId = formAccountId
+		}
+	}
+	old := *task
+	if action == ActionCreate {
+		if version >= 0 {
+			http.Error(w, ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		old = Task{}
+	}
+
+	// check if task belongs to account id, got shared with it, or if account has role "Admin"
+	if task.AccountId != accountId {
+		if !checkTaskPermission(w, task, accountId, PermissionEdit) {
+			return
+		}
+		// overwrite accountId only possible if user has role "Admin"
+		if task.AccountId != formAccountId {
+			if !checkOwnerOrAdmin(w, task.AccountId, accountId) {
+				return
+			}
+			task.AccountId = formAccountId
+		}
+	}
+
+	formId, err := strconv.Atoi(data.Get("Id"))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	if id != formId {
+		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+		return
+	}
+
+	lastUpdated, err := strconv.Atoi(data.Get("LastUpdated"))
+	if err != nil || lastUpdated < 1 {
+		// server takes care of lastUpdated timestamp in this case
+		lastUpdated = int(time.Now().Unix())
+	}
+
+	priority, err := ParsePriority(data.Get("Priority"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//task.Created = created // created cannot be overwritten by client
+	if task.Created < 1 {
+		task.Created = int(time.Now().Unix())
+	}
+
+	// tasks stay in their project unless a new ProjectId is given
+	if data.Get("ProjectId") != "" {
+		task.ProjectId, err = strconv.Atoi(data.Get("ProjectId"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+	}
+	if !checkProject(w, task.ProjectId, task.AccountId) {
+		return
+	}
+	if task.ProjectId != old.ProjectId && !checkProjectEdit(w, task.ProjectId, accountId) {
+		return
+	}
+
+	// status and assignee stay the same unless given
+	if data.Get("Status") != "" {
+		task.Status, err = ParseTaskStatus(data.Get("Status"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if task.Status == "" {
+		task.Status, _ = ParseTaskStatus("")
+	}
+	assigneeId, ok := parseAssignee(w, data)
+	if !ok {
+		return
+	}
+
+	task.LastUpdated = lastUpdated
+	task.Priority = priority
+	task.Task = data.Get("Task")
+
+	change := TaskChange{&old, task}
+	if action == ActionCreate {
+		change.Before = nil
+	}
+
+	// with If-Match the task is only saved if nobody else changed it in the meantime
+	var assignment *Assignment
+	err = transaction(func(tx *sql.Tx) error {
+		if err := task.saveVersionTx(tx, version); err != nil {
+			return err
+		}
+		if assigneeId >= 0 && assigneeId != task.AssigneeId {
+			if assignment, err = task.assignTx(tx, assigneeId, accountId); err != nil {
+				return err
+			}
+		}
+		if err := recordHistory(tx, EntityTask, task.Id, action, accountId, old, *task); err != nil {
+			return err
+		}
+		return recordUndo(tx, accountId, change)
+	})
+	if err != nil {
+		writeSaveError(w, err)
+		return
+	}
+	notifyAssigned(task, assignment)
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Edit\": \"Success\"}"))
+}
+
+// patchTask changes only the fields given in a JSON merge patch or JSON patch, following the same rules as editTask.
+func patchTask(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("patch Task[%v]", id)
+	}
+
+	version, ok := getIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionEdit)
+	if task == nil {
+		return
+	}
+	old := *task
+
+	// removed fields are reset to their zero value
+	task = &Task{}
+	if !applyPatch(w, r, old, task) {
+		return
+	}
+	if task.Id != old.Id {
+		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+		return
+	}
+
+	// overwrite accountId only possible if user is the owner or has role "Admin"
+	if task.AccountId != old.AccountId && old.AccountId != accountId {
+		if !checkOwnerOrAdmin(w, old.AccountId, accountId) {
+			return
+		}
+	}
+	if !checkProject(w, task.ProjectId, task.AccountId) {
+		return
+	}
+	if task.ProjectId != old.ProjectId && !checkProjectEdit(w, task.ProjectId, accountId) {
+		return
+	}
+
+	task.Status, err = ParseTaskStatus(task.Status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !task.Priority.IsValid() {
+		http.Error(w, ErrInvalidPriority.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// the assignee is changed separately after saving, so that the change gets recorded
+	assigneeId := -1
+	if task.AssigneeId != old.AssigneeId {
+		assigneeId, ok = parseAssignee(w, url.Values{"AssigneeId": {strconv.Itoa(task.AssigneeId)}})
+		if !ok {
+			return
+		}
+		task.AssigneeId = old.AssigneeId
+	}
+
+	// these are maintained by the server and cannot be patched
+	task.Created = old.Created
+	task.Deleted = old.Deleted
+	task.Version = old.Version
+	task.Rank = old.Rank
+	if task.LastUpdated == old.LastUpdated || task.LastUpdated < 1 {
+		task.LastUpdated = int(time.Now().Unix())
+	}
+
+	var assignment *Assignment
+	err = transaction(func(tx *sql.Tx) error {
+		if err := task.saveVersionTx(tx, version); err != nil {
+			return err
+		}
+		if assigneeId >= 0 {
+			if assignment, err = task.assignTx(tx, assigneeId, accountId); err != nil {
+				return err
+			}
+		}
+		if err := recordHistory(tx, EntityTask, task.Id, ActionUpdate, accountId, old, *task); err != nil {
+			return err
+		}
+		return recordUndo(tx, accountId, TaskChange{&old, task})
+	})
+	if err != nil {
+		writeSaveError(w, err)
+		return
+	}
+	notifyAssigned(task, assignment)
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Patch\": \"Success\"}"))
+}
+
+// applyPatch applies the patch in the request body to the current state of a task or account, and decodes the result into patched.
+// The patch format is chosen by the Content-Type, a JSON merge patch being the default.
+func applyPatch(w http.ResponseWriter, r *http.Request, current interface{}, patched interface{}) bool {
+	mediaType := MergePatchType
+	if r.Header.Get("Content-Type") != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return false
+		}
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return false
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+
+	switch mediaType {
+	case MergePatchType, "application/json":
+		doc, err = MergePatch(doc, patch)
+	case JSONPatchType:
+		doc, err = JSONPatch(doc, patch)
+	default:
+		http.Error(w, "Unsupported patch format", http.StatusUnsupportedMediaType)
+		return false
+	}
+	if err == ErrPatchTestFailed {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return false
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	// the result has to be a complete entity again, without any unknown or mistyped fields
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patched); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// parseAssignee returns the AssigneeId given in the form, or -1 if there is none.
+// 0 unassigns a task, any other id has to belong to an existing account.
+func parseAssignee(w http.ResponseWriter, data url.Values) (int, bool) {
+	if data.Get("AssigneeId") == "" {
+		return -1, true
+	}
+
+	assigneeId, err := strconv.Atoi(data.Get("AssigneeId"))
+	if err != nil || assigneeId < 0 {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return -1, false
+	}
+	if assigneeId == 0 {
+		return 0, true
+	}
+
+	if _, err := GetAccountById(assigneeId); err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.Error(w, "Invalid account", http.StatusBadRequest)
+			return -1, false
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return -1, false
+		}
+	}
+	return assigneeId, true
+}
+
+// notifyAssigned tells the new assignee about the assignment once it has been committed, without an assignment it does nothing.
+func notifyAssigned(task *Task, assignment *Assignment) {
+	if assignment == nil || !task.IsAssigned() {
+		return
+	}
+	// a failed notification does not undo the assignment
+	if err := notifier.TaskAssigned(task, assignment); err != nil {
+		log.Println(err)
+	}
+}
+
+// recordHistory appends all differences between the old and new version of a task or account to the history,
+// within the transaction of the change itself.
+func recordHistory(tx *sql.Tx, entity string, id int, action string, accountId int, old interface{}, new interface{}) error {
+	return DiffChanges(entity, id, action, accountId, int(time.Now().Unix()), old, new).saveTx(tx)
+}
+
+// recordUndo pushes the changes of a single mutation onto the undo stack of the account,
+// within the transaction of the mutation itself.
+func recordUndo(tx *sql.Tx, accountId int, changes ...TaskChange) error {
+	step := UndoStep{-1, accountId, int(time.Now().Unix()), changes}
+	return step.saveTx(tx)
+}
+
+func deleteTask(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Task[%v]", id)
+	}
+
+	version, ok := getIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionManage)
+	if task == nil {
+		return
+	}
+	old := *task
+
+	// tasks are only moved to the trash, from where they can be restored or purged
+	if version < 0 {
+		version = 0
+	}
+	err = transaction(func(tx *sql.Tx) error {
+		if err := task.trashTx(tx, int(time.Now().Unix()), version); err != nil {
+			return err
+		}
+		if err := recordHistory(tx, EntityTask, task.Id, ActionDelete, accountId, old, *task); err != nil {
+			return err
+		}
+		return recordUndo(tx, accountId, TaskChange{&old, task})
+	})
+	if err != nil {
+		writeSaveError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getProjects(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Projects")
+	}
+
+	projects, err := GetVisibleProjectsByAccountId(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(projects)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func getProject(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Project[%v]", id)
+	}
+
+	project, err := GetProjectById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// check if project belongs to account id, got shared with it, or if account has role "Admin"
+	if !checkProjectPermission(w, project, accountId, PermissionView) {
+		return
+	}
+
+	js, err := json.Marshal(project)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// parseProjectForm reads the user editable fields of a project from the form data.
+func parseProjectForm(data url.Values, project *Project) error {
+	project.Name = data.Get("Name")
+	if project.Name == "" {
+		return errors.New("Invalid data")
+	}
+
+	project.SortBy = data.Get("SortBy")
+	if project.SortBy != "" && !IsTaskSortField(project.SortBy) {
+		return errors.New("Invalid data")
+	}
+	project.SortOrder = strings.ToUpper(data.Get("SortOrder"))
+	if project.SortOrder == "" {
+		project.SortOrder = "ASC"
+	}
+	if project.SortOrder != "ASC" && project.SortOrder != "DESC" {
+		return errors.New("Invalid data")
+	}
+
+	// projects stay with their team unless a new TeamId is given
+	if data.Get("TeamId") != "" {
+		teamId, err := strconv.Atoi(data.Get("TeamId"))
+		if err != nil || teamId < 0 {
+			return errors.New("Invalid data")
+		}
+		project.TeamId = teamId
+	}
+
+	if data.Get("Archived") != "" {
+		archived, err := strconv.ParseBool(data.Get("Archived"))
+		if err != nil {
+			return errors.New("Invalid data")
+		}
+		if !archived {
+			project.Archived = 0
+		} else if !project.IsArchived() {
+			project.Archived = int(time.Now().Unix())
+		}
+	}
+	return nil
+}
+
+func addProject(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("add Project")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	project := Project{}
+	project.Id = -1 // POST ignores projectId and always uses -1 to create a new project entry
+	project.AccountId = accountId
+	if data.Get("AccountId") != "" {
+		accId, err := strconv.Atoi(data.Get("AccountId"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+		project.AccountId = accId
+	}
+	if err := parseProjectForm(data, &project); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// check if project belongs to account id, or if account has role "Admin"
+	if !checkOwnerOrAdmin(w, project.AccountId, accountId) {
+		return
+	}
+
+	// only team members can create projects for their team
+	if project.TeamId > 0 && !checkTeamPermission(w, project.TeamId, accountId, PermissionEdit) {
+		return
+	}
+
+	if err := project.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+func editProject(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("edit Project[%v]", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	project, err := GetProjectById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// check if project belongs to account id, got shared with it, or if account has role "Admin"
+	if !checkProjectPermission(w, project, accountId, PermissionEdit) {
+		return
+	}
+
+	formId, err := strconv.Atoi(data.Get("Id"))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	if id != formId {
+		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+		return
+	}
+
+	original := *project
+	if err := parseProjectForm(data, project); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// moving a project to another team needs "Manage" permission on the project, and membership in the new team
+	if project.TeamId != original.TeamId {
+		if !checkProjectPermission(w, &original, accountId, PermissionManage) {
+			return
+		}
+		if project.TeamId > 0 && !checkTeamPermission(w, project.TeamId, accountId, PermissionEdit) {
+			return
+		}
+	}
+
+	if err := project.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Edit\": \"Success\"}"))
+}
+
+func deleteProject(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Project[%v]", id)
+	}
+
+	project, err := GetProjectById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// check if project belongs to account id, got shared with it, or if account has role "Admin"
+	if !checkProjectPermission(w, project, accountId, PermissionManage) {
+		return
+	}
+
+	if err := project.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getTags(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Tags")
+	}
+
+	tags, err := GetTagsByAccountId(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func getTagCloud(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Tag Cloud")
+	}
+
+	cloud, err := GetTagCloud(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(cloud)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func getTag(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Tag[%v]", id)
+	}
+
+	tag, err := GetTagById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// check if tag belongs to account id, or if account has role "Admin"
+	if !checkOwnerOrAdmin(w, tag.AccountId, accountId) {
+		return
+	}
+
+	js, err := json.Marshal(tag)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func addTag(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("add Tag")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	tag := Tag{}
+	tag.Id = -1 // POST ignores tagId and always uses -1 to create a new tag entry
+	tag.AccountId = accountId
+	if data.Get("AccountId") != "" {
+		accId, err := strconv.Atoi(data.Get("AccountId"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+		tag.AccountId = accId
+	}
+	name, err := ParseTagName(data.Get("Name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tag.Name = name
+
+	// check if tag belongs to account id, or if account has role "Admin"
+	if !checkOwnerOrAdmin(w, tag.AccountId, accountId) {
+		return
+	}
+
+	if _, err := GetTagByName(tag.AccountId, tag.Name); err == nil {
+		http.Error(w, "Tag already exists", http.StatusConflict)
+		return
+	}
+
+	if err := tag.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+// editTag renames a tag. If the account already has another tag with the new name,
+// the tag gets merged into it if the form value Merge is set to true.
+func editTag(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("edit Tag[%v]", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	tag, err := GetTagById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// check if tag belongs to account id, or if account has role "Admin"
+	if !checkOwnerOrAdmin(w, tag.AccountId, accountId) {
+		return
+	}
+
+	formId, err := strconv.Atoi(data.Get("Id"))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	if id != formId {
+		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+		return
+	}
+
+	name, err := ParseTagName(data.Get("Name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	other, err := GetTagByName(tag.AccountId, name)
+	if err != nil && strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if other != nil && other.Id != tag.Id {
+		merge, _ := strconv.ParseBool(data.Get("Merge"))
+		if !merge {
+			http.Error(w, "Tag already exists", http.StatusConflict)
+			return
+		}
+
+		if err := tag.MergeInto(other); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{\"Merge\": \"Success\"}"))
+		return
+	}
+
+	tag.Name = name
+	if err := tag.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Edit\": \"Success\"}"))
+}
+
+func deleteTag(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Tag[%v]", id)
+	}
+
+	tag, err := GetTagById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// check if tag belongs to account id, or if account has role "Admin"
+	if !checkOwnerOrAdmin(w, tag.AccountId, accountId) {
+		return
+	}
+
+	if err := tag.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+// getViews returns the views of the account used in the request, together with all shared views.
+func getViews(w http.ResponseWriter, r *http.Request, accountId int) {
+	if r.URL.Path != "/views/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Println("get Views")
+	}
+
+	views, err := GetViewsByAccountId(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(views)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func getView(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get View[%v]", id)
+	}
+
+	view := getViewForAccount(w, r, id, accountId, false)
+	if view == nil {
+		return
+	}
+
+	js, err := json.Marshal(view)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func addView(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("add View")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	view := View{}
+	view.Id = -1 // POST ignores viewId and always uses -1 to create a new view entry
+	view.AccountId = accountId
+	if data.Get("AccountId") != "" {
+		accId, err := strconv.Atoi(data.Get("AccountId"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+		view.AccountId = accId
+	}
+	if err := parseViewForm(data, &view); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// check if view belongs to account id, or if account has role "Admin", which is the only one to publish shared views
+	if !checkViewPermission(w, &view, accountId, true) {
+		return
+	}
+
+	if err := view.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+func editView(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("edit View[%v]", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	view := getViewForAccount(w, r, id, accountId, true)
+	if view == nil {
+		return
+	}
+
+	formId, err := strconv.Atoi(data.Get("Id"))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	if id != formId {
+		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+		return
+	}
+
+	if err := parseViewForm(data, view); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// publishing a view needs the role "Admin"
+	if !checkViewPermission(w, view, accountId, true) {
+		return
+	}
+
+	if err := view.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Edit\": \"Success\"}"))
+}
+
+func deleteView(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete View[%v]", id)
+	}
+
+	view := getViewForAccount(w, r, id, accountId, true)
+	if view == nil {
+		return
+	}
+
+	if err := view.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+// getViewTasks evaluates a view: it returns the tasks visible to the account that match the filter of the view, in its sort order.
+// The query parameters of GET on /tasks/ can be used as well, a filter given there is combined with the one of the view.
+func getViewTasks(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get View[%v] Tasks", id)
+	}
+
+	view := getViewForAccount(w, r, id, accountId, false)
+	if view == nil {
+		return
+	}
+
+	listTasks(w, r, accountId, view)
+}
+
+func parseViewForm(data url.Values, view *View) error {
+	view.Name = data.Get("Name")
+	view.Filter = data.Get("Filter")
+	view.Sort = data.Get("Sort")
+
+	if data.Get("Shared") != "" {
+		shared, err := strconv.ParseBool(data.Get("Shared"))
+		if err != nil {
+			return errors.New("Invalid data")
+		}
+		view.Shared = 0
+		if shared {
+			view.Shared = 1
+		}
+	}
+	return view.Validate()
+}
+
+// getViewForAccount reads the view, and checks that the account may use it, or change it as well.
+// It writes the error response and returns nil otherwise.
+func getViewForAccount(w http.ResponseWriter, r *http.Request, id int, accountId int, change bool) *View {
+	view, err := GetViewById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return nil
+	}
+
+	if !checkViewPermission(w, view, accountId, change) {
+		return nil
+	}
+	return view
+}
+
+// checkViewPermission verifies that the view belongs to the account, or that the account has role "Admin".
+// Shared views can be used by every account, but only an "Admin" can change them.
+func checkViewPermission(w http.ResponseWriter, view *View, accountId int, change bool) bool {
+	if view.IsShared() {
+		if !change {
+			return true
+		}
+		return checkAdmin(w, accountId)
+	}
+	return checkOwnerOrAdmin(w, view.AccountId, accountId)
+}
+
+func getTaskAssignments(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Task[%v] Assignments", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	assignments, err := GetAssignmentsByTaskId(task.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(assignments)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// editTaskStatus only updates the status of a task.
+// Next to everyone allowed to edit the task, its assignee can do this as well.
+func editTaskStatus(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("edit Task[%v] Status", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	status, err := ParseTaskStatus(r.Form.Get("Status"))
+	if err != nil || r.Form.Get("Status") == "" {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	version, ok := getIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+	if task.AssigneeId != accountId && !checkTaskPermission(w, task, accountId, PermissionEdit) {
+		return
+	}
+
+	old := *task
+	task.Status = status
+	task.LastUpdated = int(time.Now().Unix())
+	err = transaction(func(tx *sql.Tx) error {
+		if err := task.saveVersionTx(tx, version); err != nil {
+			return err
+		}
+		if err := recordHistory(tx, EntityTask, task.Id, ActionUpdate, accountId, old, *task); err != nil {
+			return err
+		}
+		return recordUndo(tx, accountId, TaskChange{&old, task})
+	})
+	if err != nil {
+		writeSaveError(w, err)
+		return
+	}
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Edit\": \"Success\"}"))
+}
+
+// moveTask puts a task right before or after another task of the same list, by only changing its rank.
+func moveTask(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("move Task[%v]", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	before := r.Form.Get("Before") != ""
+	target := r.Form.Get("After")
+	if before {
+		target = r.Form.Get("Before")
+	}
+	targetId, err := strconv.Atoi(target)
+	if err != nil || (before && r.Form.Get("After") != "") || targetId == id {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionEdit)
+	if task == nil {
+		return
+	}
+
+	list, err := GetTaskList(task)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// find the neighbours of the new position, without the moved task itself
+	olds := map[int]Task{}
+	others := Tasks{}
+	for _, t := range *list {
+		olds[t.Id] = t
+		if t.Id == task.Id {
+			*task = t
+		} else {
+			others = append(others, t)
+		}
+	}
+	i := -1
+	for j, t := range others {
+		if t.Id == targetId {
+			i = j
+		}
+	}
+	if i < 0 {
+		http.Error(w, "Tasks are not in the same list", http.StatusConflict)
+		return
+	}
+
+	// tasks that have never been ranked come after all others, so only those up to the new position need a rank to keep it
+	last := i
+	if before {
+		last = i - 1
+	}
+	ranked := rankTaskList(others, last)
+	prev, next := rankNeighbours(others, i, before)
+	if next != "" && prev >= next {
+		// tasks moved in from other lists can share a rank, this is only resolved by ranking the whole list again
+		for j := range others {
+			others[j].Rank = ""
+		}
+		ranked = rankTaskList(others, len(others)-1)
+		prev, next = rankNeighbours(others, i, before)
+	}
+
+	moved := Tasks{*task}
+	moved[0].Rank = RankBetween(prev, next)
+	moved = append(ranked, moved...)
+	// any other task that had to be ranked is part of the history and the undo step of the move
+	err = transaction(func(tx *sql.Tx) error {
+		if err := moved.saveRanksTx(tx); err != nil {
+			return err
+		}
+		changes := []TaskChange{}
+		for j := range moved {
+			old := olds[moved[j].Id]
+			if err := recordHistory(tx, EntityTask, old.Id, ActionUpdate, accountId, old, moved[j]); err != nil {
+				return err
+			}
+			changes = append(changes, TaskChange{&old, &moved[j]})
+		}
+		*task = moved[len(moved)-1]
+		return recordUndo(tx, accountId, changes...)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	setETag(w, task.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Move\": \"Success\"}"))
+}
+
+// rankNeighbours returns the ranks between which a task is moved, right before or after the task at index i.
+func rankNeighbours(list Tasks, i int, before bool) (string, string) {
+	if before {
+		if i > 0 {
+			return list[i-1].Rank, list[i].Rank
+		}
+		return "", list[i].Rank
+	}
+	if i+1 < len(list) {
+		return list[i].Rank, list[i+1].Rank
+	}
+	return list[i].Rank, ""
+}
+
+// rankTaskList ranks the tasks of a list up to index last that have never been ranked, in their current order after the ranked ones.
+// It returns the tasks it has ranked, their ranks are not saved yet. Only ever ranking the tasks a move needs to be ranked
+// keeps all other tasks as they are.
+func rankTaskList(list Tasks, last int) Tasks {
+	ranked := Tasks{}
+	rank := ""
+	for i := 0; i <= last && i < len(list); i++ {
+		if list[i].Rank == "" {
+			rank = RankBetween(rank, "")
+			list[i].Rank = rank
+			ranked = append(ranked, list[i])
+		} else {
+			rank = list[i].Rank
+		}
+	}
+	return ranked
+}
+
+// getCommentOf returns the comment, if it exists and belongs to the given task.
+// Otherwise the error has already been written to the response and nil is returned.
+func getCommentOf(w http.ResponseWriter, r *http.Request, id int, taskId int) *Comment {
+	comment, err := GetCommentById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+	if comment.TaskId != taskId {
+		http.NotFound(w, r)
+		return nil
+	}
+	return comment
+}
+
+// resolveMentions returns the ids of all accounts mentioned by name within the body.
+// Mentions of unknown account names are ignored.
+func resolveMentions(w http.ResponseWriter, body string) ([]int, bool) {
+	accounts, err := GetAccountsByNames(ParseMentions(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+
+	mentions := []int{}
+	for _, account := range *accounts {
+		mentions = append(mentions, account.Id)
+	}
+	return mentions, true
+}
+
+func getTaskComments(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Task[%v] Comments", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	comments, err := GetCommentsByTaskId(task.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(comments)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// addTaskComment adds a comment to the thread of a task, everyone who can view the task can comment on it.
+func addTaskComment(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("add Task[%v] Comment", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	body := r.Form.Get("Body")
+	if strings.TrimSpace(body) == "" {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	mentions, ok := resolveMentions(w, body)
+	if !ok {
+		return
+	}
+
+	now := int(time.Now().Unix())
+	comment := Comment{-1, task.Id, accountId, now, now, body, mentions}
+	if err := comment.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+// editTaskComment changes the body of a comment, only its author can do this.
+func editTaskComment(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, commentId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+	if commentId < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Printf("edit Task[%v] Comment[%v]", id, commentId)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	body := r.Form.Get("Body")
+	if strings.TrimSpace(body) == "" {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	comment := getCommentOf(w, r, commentId, task.Id)
+	if comment == nil {
+		return
+	}
+	if comment.AccountId != accountId {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	mentions, ok := resolveMentions(w, body)
+	if !ok {
+		return
+	}
+
+	comment.Body = body
+	comment.Mentions = mentions
+	comment.LastUpdated = int(time.Now().Unix())
+	if err := comment.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Edit\": \"Success\"}"))
+}
+
+// deleteTaskComment removes a comment, only its author or an "Admin" can do this.
+func deleteTaskComment(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, commentId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+	if commentId < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Task[%v] Comment[%v]", id, commentId)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	comment := getCommentOf(w, r, commentId, task.Id)
+	if comment == nil {
+		return
+	}
+	if !checkOwnerOrAdmin(w, comment.AccountId, accountId) {
+		return
+	}
+
+	if err := comment.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+// getAttachmentOf returns the attachment, if it exists and belongs to the given task.
+// Otherwise the error has already been written to the response and nil is returned.
+func getAttachmentOf(w http.ResponseWriter, r *http.Request, id int, taskId int) *Attachment {
+	attachment, err := GetAttachmentById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+	if attachment.TaskId != taskId {
+		http.NotFound(w, r)
+		return nil
+	}
+	return attachment
+}
+
+// getTaskAttachments returns the metadata of all attachments of a task,
+// or the file itself when requested by /task/{taskId}/attachments/{attachmentId}.
+func getTaskAttachments(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, attachmentId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		if attachmentId < 0 {
+			log.Printf("get Task[%v] Attachments", id)
+		} else {
+			log.Printf("get Task[%v] Attachment[%v]", id, attachmentId)
+		}
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	if attachmentId >= 0 {
+		downloadAttachment(w, r, attachmentId, task.Id)
+		return
+	}
+
+	attachments, err := GetAttachmentsByTaskId(task.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(attachments)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func downloadAttachment(w http.ResponseWriter, r *http.Request, id int, taskId int) {
+	attachment := getAttachmentOf(w, r, id, taskId)
+	if attachment == nil {
+		return
+	}
+
+	blob, err := blobStore.Get(attachment.BlobKey)
+	if err != nil {
+		if err == ErrBlobNotFound {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, blob); err != nil && isLogging {
+		log.Println(err)
+	}
+}
+
+// addTaskAttachment uploads a file given as multipart form field "File" and attaches it to the task.
+// The content type is sniffed from the file itself, the one sent by the client is ignored.
+func addTaskAttachment(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("add Task[%v] Attachment", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionEdit)
+	if task == nil {
+		return
+	}
+
+	// leave some room for the multipart boundaries and headers
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize+(1<<16))
+	file, header, err := r.FormFile("File")
+	if err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			http.Error(w, "Attachment too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if size > maxAttachmentSize {
+		http.Error(w, "Attachment too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	sniff := make([]byte, 512)
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	key, err := GenerateRandomString()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	name := header.Filename
+	if i := strings.LastIndexAny(name, "/\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	if name == "" {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	attachment := Attachment{
+		-1,
+		task.Id,
+		accountId,
+		name,
+		http.DetectContentType(sniff[:n]),
+		size,
+		int(time.Now().Unix()),
+		*key,
+	}
+
+	if err := blobStore.Put(attachment.BlobKey, file); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := attachment.Save(); err != nil {
+		blobStore.Delete(attachment.BlobKey)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+// deleteTaskAttachment removes an attachment, the uploader can always remove its own attachments.
+func deleteTaskAttachment(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, attachmentId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+	if attachmentId < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Task[%v] Attachment[%v]", id, attachmentId)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	attachment := getAttachmentOf(w, r, attachmentId, task.Id)
+	if attachment == nil {
+		return
+	}
+	if attachment.AccountId != accountId && !checkTaskPermission(w, task, accountId, PermissionEdit) {
+		return
+	}
+
+	if err := attachment.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getTaskHistory(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Task[%v] History", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	changes, err := GetChangesByEntity(EntityTask, task.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(changes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func getTaskTags(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Task[%v] Tags", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionView)
+	if task == nil {
+		return
+	}
+
+	tags, err := GetTagsByTaskId(task.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(tags)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// addTaskTag tags a task by tag name, the tag is created for the task owner if it does not exist yet.
+func addTaskTag(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("add Task[%v] Tag", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	name, err := ParseTagName(r.Form.Get("Name"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionEdit)
+	if task == nil {
+		return
+	}
+
+	tag, err := GetTagByName(task.AccountId, name)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		} else {
+			tag = &Tag{-1, task.AccountId, name}
+			if err := tag.Save(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+	}
+
+	if err := task.AddTag(tag); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+func deleteTaskTag(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, tagId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+	if tagId < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Task[%v] Tag[%v]", id, tagId)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionEdit)
+	if task == nil {
+		return
+	}
+
+	tag, err := GetTagById(tagId)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := task.RemoveTag(tag); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getShares(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Shares")
+	}
+
+	shares, err := GetSharesByAccountId(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(shares)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// parseShareForm reads the recipient and permission level of a new share from the form data.
+// The recipient can either be given by AccountId or by Email.
+func parseShareForm(w http.ResponseWriter, r *http.Request, ownerId int) *Share {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return nil
+	}
+
+	data := r.Form
+	share := &Share{}
+	share.Id = -1 // POST always creates a new share entry, or replaces the existing share with the same account
+
+	level, err := ParsePermission(data.Get("Permission"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+	share.Permission = permissionNames[level]
+
+	var account *Account
+	if data.Get("Email") != "" {
+		account, err = GetAccountByEmail(data.Get("Email"))
+	} else {
+		var id int
+		id, err = strconv.Atoi(data.Get("AccountId"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return nil
+		}
+		account, err = GetAccountById(id)
+	}
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.Error(w, "Invalid account", http.StatusBadRequest)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+	if account.Id == ownerId {
+		http.Error(w, "Invalid account", http.StatusBadRequest)
+		return nil
+	}
+	share.AccountId = account.Id
+
+	return share
+}
+
+// getShareOf returns the share with the given id, if it belongs to the given task or project.
+func getShareOf(w http.ResponseWriter, r *http.Request, id int, taskId int, projectId int) *Share {
+	share, err := GetShareById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+	if share.TaskId != taskId || share.ProjectId != projectId {
+		http.NotFound(w, r)
+		return nil
+	}
+	return share
+}
+
+func getTaskShares(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Task[%v] Shares", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionManage)
+	if task == nil {
+		return
+	}
+
+	shares, err := GetSharesByTaskId(task.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(shares)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func addTaskShare(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("add Task[%v] Share", id)
+	}
+
+	task := getTaskForAccount(w, r, id, accountId, PermissionManage)
+	if task == nil {
+		return
+	}
+
+	share := parseShareForm(w, r, task.AccountId)
+	if share == nil {
+		return
+	}
+	share.TaskId = task.Id
+
+	if err := share.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+// deleteTaskShare revokes a share. Recipients can always remove their own shares.
+func deleteTaskShare(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, shareId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+	if shareId < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Task[%v] Share[%v]", id, shareId)
+	}
+
+	share := getShareOf(w, r, shareId, id, 0)
+	if share == nil {
+		return
+	}
+
+	if share.AccountId != accountId {
+		if task := getTaskForAccount(w, r, id, accountId, PermissionManage); task == nil {
+			return
+		}
+	}
+
+	if err := share.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+// getProjectForAccount returns the project if the account is allowed to access it with the given permission level.
+// Otherwise the error has already been written to the response and nil is returned.
+func getProjectForAccount(w http.ResponseWriter, r *http.Request, id int, accountId int, permission int) *Project {
+	project, err := GetProjectById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+
+	// check if project belongs to account id, got shared with it, or if account has role "Admin"
+	if !checkProjectPermission(w, project, accountId, permission) {
+		return nil
+	}
+	return project
+}
+
+func getProjectShares(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Project[%v] Shares", id)
+	}
+
+	project := getProjectForAccount(w, r, id, accountId, PermissionManage)
+	if project == nil {
+		return
+	}
+
+	shares, err := GetSharesByProjectId(project.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(shares)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func addProjectShare(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("add Project[%v] Share", id)
+	}
+
+	project := getProjectForAccount(w, r, id, accountId, PermissionManage)
+	if project == nil {
+		return
+	}
+
+	share := parseShareForm(w, r, project.AccountId)
+	if share == nil {
+		return
+	}
+	share.ProjectId = project.Id
+
+	if err := share.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+// deleteProjectShare revokes a share. Recipients can always remove their own shares.
+func deleteProjectShare(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, shareId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+	if shareId < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Project[%v] Share[%v]", id, shareId)
+	}
+
+	share := getShareOf(w, r, shareId, 0, id)
+	if share == nil {
+		return
+	}
+
+	if share.AccountId != accountId {
+		if project := getProjectForAccount(w, r, id, accountId, PermissionManage); project == nil {
+			return
+		}
+	}
+
+	if err := share.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getTeams(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Teams")
+	}
+
+	teams, err := GetTeamsByAccountId(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(teams)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getTeamForAccount returns the team if the account is a member with at least the given permission level.
+// Otherwise the error has already been written to the response and nil is returned.
+func getTeamForAccount(w http.ResponseWriter, r *http.Request, id int, accountId int, permission int) *Team {
+	team, err := GetTeamById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+
+	// check if account is a member of the team, or if account has role "Admin"
+	if !checkTeamPermission(w, team.Id, accountId, permission) {
+		return nil
+	}
+	return team
+}
+
+func getTeam(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Team[%v]", id)
+	}
+
+	team := getTeamForAccount(w, r, id, accountId, PermissionView)
+	if team == nil {
+		return
+	}
+
+	js, err := json.Marshal(team)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// addTeam creates a new team, with the account used in the request as its first owner.
+func addTeam(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("add Team")
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	team := Team{}
+	team.Id = -1 // POST ignores teamId and always uses -1 to create a new team entry
+	team.Name = data.Get("Name")
+	if team.Name == "" {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := GetAccountById(accountId); err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err := team.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	member := Member{team.Id, accountId, "Owner"}
+	if err := member.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+func editTeam(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("edit Team[%v]", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	team := getTeamForAccount(w, r, id, accountId, PermissionManage)
+	if team == nil {
+		return
+	}
+
+	formId, err := strconv.Atoi(data.Get("Id"))
+	if err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	if id != formId {
+		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+		return
+	}
+
+	team.Name = data.Get("Name")
+	if team.Name == "" {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	if err := team.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Edit\": \"Success\"}"))
+}
+
+func deleteTeam(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Team[%v]", id)
+	}
+
+	team := getTeamForAccount(w, r, id, accountId, PermissionManage)
+	if team == nil {
+		return
+	}
+
+	if err := team.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getTeamMembers(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Team[%v] Members", id)
+	}
+
+	team := getTeamForAccount(w, r, id, accountId, PermissionView)
+	if team == nil {
+		return
+	}
+
+	members, err := GetMembersByTeamId(team.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(members)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// checkLastOwner makes sure a team is never left without an owner.
+func checkLastOwner(w http.ResponseWriter, member *Member) bool {
+	if !member.IsOwner() {
+		return true
+	}
+
+	members, err := GetMembersByTeamId(member.TeamId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
+	}
+	if members.Owners() < 2 {
+		http.Error(w, "Team needs at least one owner", http.StatusConflict)
+		return false
+	}
+	return true
+}
+
+// addTeamMember adds an account to the team, or changes the role of an existing member.
+// The account can either be given by AccountId or by Email.
+func addTeamMember(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("add Team[%v] Member", id)
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+
+	data := r.Form
+
+	team := getTeamForAccount(w, r, id, accountId, PermissionManage)
+	if team == nil {
+		return
+	}
+
+	role, err := ParseTeamRole(data.Get("Role"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var account *Account
+	if data.Get("Email") != "" {
+		account, err = GetAccountByEmail(data.Get("Email"))
+	} else {
+		var accId int
+		accId, err = strconv.Atoi(data.Get("AccountId"))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
+			return
+		}
+		account, err = GetAccountById(accId)
+	}
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.Error(w, "Invalid account", http.StatusBadRequest)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// demoting the last owner is not possible
+	if existing, err := GetMember(team.Id, account.Id); err == nil && role != "Owner" {
+		if !checkLastOwner(w, existing) {
+			return
+		}
+	}
+
+	member := Member{team.Id, account.Id, role}
+	if err := member.Save(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Add\": \"Success\"}"))
+}
+
+// deleteTeamMember removes an account from the team. Members can always leave a team by themselves.
+func deleteTeamMember(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, memberId, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+	if memberId < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Printf("delete Team[%v] Member[%v]", id, memberId)
+	}
+
+	if memberId != accountId {
+		if team := getTeamForAccount(w, r, id, accountId, PermissionManage); team == nil {
+			return
+		}
+	}
+
+	member, err := GetMember(id, memberId)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if !checkLastOwner(w, member) {
+		return
+	}
+
+	if err := member.Delete(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getTeamProjects(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Team[%v] Projects", id)
+	}
+
+	team := getTeamForAccount(w, r, id, accountId, PermissionView)
+	if team == nil {
+		return
+	}
+
+	projects, err := GetProjectsByTeamId(team.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(projects)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func getTeamTasks(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, _, err := getSubIds(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("get Team[%v] Tasks", id)
+	}
+
+	team := getTeamForAccount(w, r, id, accountId, PermissionView)
+	if team == nil {
+		return
+	}
+
+	tasks, err := GetTasksByTeamId(team.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(tasks)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getTrash returns all tasks of the account used in the request that are in the trash.
+// Accounts in the trash are only listed for an "Admin".
+func getTrash(w http.ResponseWriter, r *http.Request, accountId int) {
+	if r.URL.Path != "/trash/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if isLogging {
+		log.Println("get Trash")
+	}
+
+	tasks, err := GetTrashedTasksByAccountId(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	trash := Trash{*tasks, Accounts{}}
+
+	account, err := GetAccountById(accountId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if account.Role == "Admin" {
+		accounts, err := GetTrashedAccounts()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		trash.Accounts = *accounts
+	}
+
+	js, err := json.Marshal(trash)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+// getTrashedTask returns the task if it is in the trash and the account is allowed to manage it.
+// Otherwise the error has already been written to the response and nil is returned.
+func getTrashedTask(w http.ResponseWriter, r *http.Request, id int, accountId int) *Task {
+	task, err := GetTrashedTaskById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+
+	if !checkTaskPermission(w, task, accountId, PermissionManage) {
+		return nil
+	}
+	return task
+}
+
+// getTrashedAccount returns the account if it is in the trash, only an "Admin" can manage these.
+// Otherwise the error has already been written to the response and nil is returned.
+func getTrashedAccount(w http.ResponseWriter, r *http.Request, id int, accountId int) *Account {
+	if !checkAdmin(w, accountId) {
+		return nil
+	}
+
+	account, err := GetTrashedAccountById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return nil
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil
+		}
+	}
+	return account
+}
+
+// restoreTrash moves a task or account out of the trash again.
+func restoreTrash(w http.ResponseWriter, r *http.Request, accountId int) {
+	kind, id, err := getTrashId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("restore %v[%v]", kind, id)
+	}
+
+	if kind == "task" {
+		task := getTrashedTask(w, r, id, accountId)
+		if task == nil {
+			return
+		}
+		old := *task
+		err := transaction(func(tx *sql.Tx) error {
+			if err := task.trashTx(tx, 0, 0); err != nil {
+				return err
+			}
+			if err := recordHistory(tx, EntityTask, task.Id, ActionRestore, accountId, old, *task); err != nil {
+				return err
+			}
+			return recordUndo(tx, accountId, TaskChange{&old, task})
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		account := getTrashedAccount(w, r, id, accountId)
+		if account == nil {
+			return
+		}
+		old := *account
+		err := transaction(func(tx *sql.Tx) error {
+			if err := account.trashTx(tx, 0, 0); err != nil {
+				return err
+			}
+			return recordHistory(tx, EntityAccount, account.Id, ActionRestore, accountId, old, *account)
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-	}
-
-	if err := task.Save(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte("{\"Add\": \"Success\"}"))
+	w.Write([]byte("{\"Restore\": \"Success\"}"))
 }
 
-func editTask(w http.ResponseWriter, r *http.Request, accountId int) {
-	id, err := getId(w, r)
+// purgeTrash permanently deletes a task or account that is in the trash.
+func purgeTrash(w http.ResponseWriter, r *http.Request, accountId int) {
+	kind, id, err := getTrashId(w, r)
 	if err != nil {
 		return
 	}
 
 	if isLogging {
-		log.Printf("edit Task[%v]", id)
-	}
-
-	if err := r.ParseForm(); err != nil {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
-		return
+		log.Printf("purge %v[%v]", kind, id)
 	}
 
-	data := r.Form
-	formAccountId, err := strconv.Atoi(data.Get("AccountId"))
-	if err != nil {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
-		return
-	}
-
-	task, err := GetTaskById(id)
-	if err != nil {
-		if strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+	if kind == "task" {
+		task := getTrashedTask(w, r, id, accountId)
+		if task == nil {
 			return
-		} else {
-			task = &Task{}
-			task.Id = id
-			task.AccountId = formAccountId
 		}
-	}
-
-	// check if task belongs to account id, or if account has role "Admin"
-	if task.AccountId != accountId {
-		account, err := GetAccountById(accountId)
+		old := *task
+		var blobKeys []string
+		err := transaction(func(tx *sql.Tx) error {
+			var err error
+			if blobKeys, err = task.deleteTx(tx); err != nil {
+				return err
+			}
+			return recordHistory(tx, EntityTask, old.Id, ActionPurge, accountId, old, Task{})
+		})
+		if err == nil {
+			err = deleteBlobs(blobKeys)
+		}
 		if err != nil {
-			if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return
-			} else {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		account := getTrashedAccount(w, r, id, accountId)
+		if account == nil {
+			return
+		}
+		old := *account
+		var blobKeys []string
+		err := transaction(func(tx *sql.Tx) error {
+			var err error
+			if blobKeys, err = account.deleteTx(tx); err != nil {
+				return err
 			}
+			return recordHistory(tx, EntityAccount, old.Id, ActionPurge, accountId, old, Account{})
+		})
+		if err == nil {
+			err = deleteBlobs(blobKeys)
 		}
-		if account.Role != "Admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		// overwrite accountId only possible if user has role "Admin"
-		task.AccountId = formAccountId
 	}
 
-	formId, err := strconv.Atoi(data.Get("Id"))
-	if err != nil {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
-		return
-	}
-	if id != formId {
-		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Delete\": \"Success\"}"))
+}
+
+func getUndo(w http.ResponseWriter, r *http.Request, accountId int) {
+	if r.URL.Path != "/undo/" {
+		http.NotFound(w, r)
 		return
 	}
 
-	lastUpdated, err := strconv.Atoi(data.Get("LastUpdated"))
-	if err != nil || lastUpdated < 1 {
-		// server takes care of lastUpdated timestamp in this case
-		lastUpdated = int(time.Now().Unix())
+	if isLogging {
+		log.Println("get Undo")
 	}
 
-	priority, err := strconv.Atoi(data.Get("Priority"))
+	steps, err := GetUndoStepsByAccountId(accountId)
 	if err != nil {
-		http.Error(w, "Invalid data", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	//task.Created = created // created cannot be overwritten by client
-	if task.Created < 1 {
-		task.Created = int(time.Now().Unix())
-	}
-
-	task.LastUpdated = lastUpdated
-	task.Priority = priority
-	task.Task = data.Get("Task")
-
-	if err := task.Save(); err != nil {
+	js, err := json.Marshal(steps)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte("{\"Edit\": \"Success\"}"))
+	w.Write(js)
 }
 
-func deleteTask(w http.ResponseWriter, r *http.Request, accountId int) {
-	id, err := getId(w, r)
-	if err != nil {
+// undo reverts the most recent mutation of the account.
+// It is refused if any of the tasks has been changed by someone else in the meantime.
+func undo(w http.ResponseWriter, r *http.Request, accountId int) {
+	if r.URL.Path != "/undo/" {
+		http.NotFound(w, r)
 		return
 	}
 
 	if isLogging {
-		log.Printf("delete Task[%v]", id)
+		log.Println("undo")
 	}
 
-	task, err := GetTaskById(id)
+	steps, err := GetUndoStepsByAccountId(accountId)
 	if err != nil {
-		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
-			http.NotFound(w, r)
-			return
-		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(*steps) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	step := (*steps)[0]
+
+	// the account must still be allowed to edit all the tasks it changed
+	for _, change := range step.Changes {
+		task := change.After
+		if task == nil {
+			task = change.Before
+		}
+		if !checkTaskPermission(w, task, accountId, PermissionEdit) {
 			return
 		}
 	}
 
-	// check if task belongs to account id, or if account has role "Admin"
-	if task.AccountId != accountId {
-		account, err := GetAccountById(accountId)
+	err = transaction(func(tx *sql.Tx) error {
+		tasks, err := step.undoTx(tx)
 		if err != nil {
-			if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return
-			} else {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+			return err
+		}
+		for i, task := range tasks {
+			old := step.Changes[len(step.Changes)-1-i].After
+			if err := recordHistory(tx, EntityTask, task.Id, ActionUndo, accountId, *old, task); err != nil {
+				return err
 			}
 		}
-		if account.Role != "Admin" {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return nil
+	})
+	if err != nil {
+		if err == ErrUndoConflict {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Undo\": \"Success\"}"))
+}
+
+// getAuditLog returns the history of all tasks and accounts. It can be filtered
+// by the query parameters actor, entity, entityId, from and to (timestamps).
+func getAuditLog(w http.ResponseWriter, r *http.Request, accountId int) {
+	if isLogging {
+		log.Println("get Audit Log")
+	}
+
+	// only Admins can see the audit log
+	if !checkAdmin(w, accountId) {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := AuditFilter{}
+	filter.Entity = query.Get("entity")
+	if filter.Entity != "" && filter.Entity != EntityTask && filter.Entity != EntityAccount {
+		http.Error(w, "Invalid data", http.StatusBadRequest)
+		return
+	}
+	for param, value := range map[string]*int{
+		"actor":    &filter.AccountId,
+		"entityId": &filter.EntityId,
+		"from":     &filter.From,
+		"to":       &filter.To,
+	} {
+		if query.Get(param) == "" {
+			continue
+		}
+		v, err := strconv.Atoi(query.Get(param))
+		if err != nil {
+			http.Error(w, "Invalid data", http.StatusBadRequest)
 			return
 		}
+		*value = v
+	}
+
+	changes, err := GetChanges(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
-	if err := task.Delete(); err != nil {
+	js, err := json.Marshal(changes)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.Write([]byte("{\"Delete\": \"Success\"}"))
+	w.Write(js)
 }
 
 func getAccounts(w http.ResponseWriter, r *http.Request, accountId int) {
@@ -452,12 +5292,53 @@ func getAccounts(w http.ResponseWriter, r *http.Request, accountId int) {
 		return
 	}
 
-	accounts, err := GetAllAccounts()
+	query := r.URL.Query()
+	keys, err := ParseSortKeys(query.Get("sort"), accountSortFields)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// pages need a well defined order, accounts are sorted by id by default
+	paged := query.Get("limit") != "" || query.Get("cursor") != ""
+	if paged && len(keys) == 0 {
+		keys = SortKeys{{"id", false}}
+	}
+	page := Page{}
+	if paged {
+		var ok bool
+		if page, ok = getPage(w, r, "accounts", keys); !ok {
+			return
+		}
+	}
+	inSQL := keys.InSQL(accountSortFields)
+	sqlPage := Page{}
+	if inSQL {
+		sqlPage = page
+	}
+
+	accounts, err := GetSortedAccounts(keys, sqlPage)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	if paged {
+		total := -1
+		if query.Get("total") == "true" {
+			if total, err = CountAccounts(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		var result PageResult
+		accounts, result = accounts.Page(keys, page, inSQL)
+		setPageHeaders(w, r, page, result, total)
+	} else if !inSQL {
+		accounts.SortByKeys(keys)
+	}
+
 	js, err := json.Marshal(accounts)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -507,6 +5388,10 @@ func getAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 		}
 	}
 
+	if checkNotModified(w, r, account.Version) {
+		return
+	}
+
 	// do not return Password, duh!
 	account.Password = ""
 
@@ -516,6 +5401,7 @@ func getAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 		return
 	}
 
+	setETag(w, account.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(js)
 }
@@ -577,7 +5463,13 @@ func addAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 		return
 	}
 
-	if err := account.Save(); err != nil {
+	err = transaction(func(tx *sql.Tx) error {
+		if err := account.saveTx(tx); err != nil {
+			return err
+		}
+		return recordHistory(tx, EntityAccount, account.Id, ActionCreate, accountId, Account{}, account)
+	})
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -603,16 +5495,33 @@ func editAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 
 	data := r.Form
 
+	version, ok := getIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	action := ActionUpdate
 	account, err := GetAccountById(id)
 	if err != nil {
 		if strings.Trim(err.Error(), "\n") != "sql: no rows in result set" {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		} else {
+			// an account in the trash has to be restored first
+			if _, err := GetTrashedAccountById(id); err == nil {
+				http.Error(w, "Account is in the trash", http.StatusConflict)
+				return
+			}
+			if version >= 0 {
+				http.Error(w, ErrVersionMismatch.Error(), http.StatusPreconditionFailed)
+				return
+			}
+			action = ActionCreate
 			account = &Account{}
 			account.Id = id
 		}
 	}
+	old := *account
 
 	acc, err := GetAccountById(accountId)
 	if err != nil {
@@ -648,15 +5557,100 @@ func editAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 		account.Role = data.Get("Role")
 	}
 
-	if err := account.Save(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	err = transaction(func(tx *sql.Tx) error {
+		if err := account.saveVersionTx(tx, version); err != nil {
+			return err
+		}
+		return recordHistory(tx, EntityAccount, account.Id, action, accountId, old, *account)
+	})
+	if err != nil {
+		writeSaveError(w, err)
 		return
 	}
 
+	setETag(w, account.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.Write([]byte("{\"Edit\": \"Success\"}"))
 }
 
+// patchAccount changes only the fields given in a JSON merge patch or JSON patch, following the same rules as editAccount.
+func patchAccount(w http.ResponseWriter, r *http.Request, accountId int) {
+	id, err := getId(w, r)
+	if err != nil {
+		return
+	}
+
+	if isLogging {
+		log.Printf("patch Account[%v]", id)
+	}
+
+	version, ok := getIfMatch(w, r)
+	if !ok {
+		return
+	}
+
+	acc, err := GetAccountById(accountId)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	// check if account belongs to account id, or if account has role "Admin"
+	if id != accountId && acc.Role != "Admin" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	account, err := GetAccountById(id)
+	if err != nil {
+		if strings.Trim(err.Error(), "\n") == "sql: no rows in result set" {
+			http.NotFound(w, r)
+			return
+		} else {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	old := *account
+
+	account = &Account{}
+	if !applyPatch(w, r, old, account) {
+		return
+	}
+	if account.Id != old.Id {
+		http.Error(w, "URL Id and Form Id do not match", http.StatusConflict)
+		return
+	}
+	if account.Role != old.Role && acc.Role != "Admin" { // only Admins can change roles
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	// these are maintained by the server and cannot be patched
+	account.LastAuth = old.LastAuth
+	account.Deleted = old.Deleted
+	account.Version = old.Version
+
+	err = transaction(func(tx *sql.Tx) error {
+		if err := account.saveVersionTx(tx, version); err != nil {
+			return err
+		}
+		return recordHistory(tx, EntityAccount, account.Id, ActionUpdate, accountId, old, *account)
+	})
+	if err != nil {
+		writeSaveError(w, err)
+		return
+	}
+
+	setETag(w, account.Version)
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{\"Patch\": \"Success\"}"))
+}
+
 func deleteAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 	id, err := getId(w, r)
 	if err != nil {
@@ -667,6 +5661,11 @@ func deleteAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 		log.Printf("delete Account[%v]", id)
 	}
 
+	version, ok := getIfMatch(w, r)
+	if !ok {
+		return
+	}
+
 	// check if account belongs to account id, or if account has role "Admin"
 	if id != accountId {
 		acc, err := GetAccountById(accountId)
@@ -696,8 +5695,19 @@ func deleteAccount(w http.ResponseWriter, r *http.Request, accountId int) {
 		}
 	}
 
-	if err := account.Delete(); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// accounts are only moved to the trash, from where they can be restored or purged
+	old := *account
+	if version < 0 {
+		version = 0
+	}
+	err = transaction(func(tx *sql.Tx) error {
+		if err := account.trashTx(tx, int(time.Now().Unix()), version); err != nil {
+			return err
+		}
+		return recordHistory(tx, EntityAccount, account.Id, ActionDelete, accountId, old, *account)
+	})
+	if err != nil {
+		writeSaveError(w, err)
 		return
 	}
 
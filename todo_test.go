@@ -1,12 +1,17 @@
 package main
 
 import "testing"
+import "os"
+import "fmt"
+import "bytes"
 import "time"
 import "strconv"
 import "strings"
 import "net/url"
 import "net/http"
 import "net/http/httptest"
+import "mime/multipart"
+import "encoding/csv"
 import "encoding/json"
 
 func Test_todo_setup(t *testing.T) {
@@ -123,7 +128,7 @@ func Test_todo_getAuth(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	beforeLastauthUpdate := Account{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890}
+	beforeLastauthUpdate := Account{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1}
 	if *account == beforeLastauthUpdate {
 		t.Errorf("getAuth() Account.LastAuth should not be the same anymore: [%v] vs. [%v]", *account, beforeLastauthUpdate)
 	}
@@ -181,15 +186,15 @@ func _todo_getTasks(t *testing.T, id int, expectedTasks Tasks) {
 func Test_todo_getTasks(t *testing.T) {
 	// should be sorted by Priority by default, and only return users tasks.
 	expectedTasks := Tasks{
-		{1, 1, 1234567890, 1234567895, 3, "Buy food!"},
-		{4, 1, 1234567893, 1234567895, 3, "Buy water!"},
-		{6, 1, 1234567890, 1234567895, 2, "Watch TV.."},
+		{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"},
+		{Id: 4, AccountId: 1, Created: 1234567893, LastUpdated: 1234567895, Priority: 3, Task: "Buy water!", Status: "Open", Version: 1, Rank: "k"},
+		{Id: 6, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 2, Task: "Watch TV..", Status: "Open", Version: 1, Rank: "s"},
 	}
 	_todo_getTasks(t, 1, expectedTasks)
 
 	expectedTasks = Tasks{
-		{3, 2, 1234567892, 1234567895, 4, "Buy xmas presents!"},
-		{2, 2, 1234567891, 1234567895, 1, "Get some sleep..."},
+		{Id: 3, AccountId: 2, Created: 1234567892, LastUpdated: 1234567895, Priority: 4, Task: "Buy xmas presents!", Status: "Open", Version: 1, Rank: "k"},
+		{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"},
 	}
 	_todo_getTasks(t, 2, expectedTasks)
 
@@ -210,7 +215,7 @@ func Test_todo_getTask(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 
 	body := response.Body.String()
-	expected := Task{1, 1, 1234567890, 1234567895, 3, "Buy food!"}
+	expected := Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"}
 	var task Task
 	if err := json.Unmarshal([]byte(body), &task); err != nil {
 		t.Error(err)
@@ -255,7 +260,7 @@ func Test_todo_getTask(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 
 	body = response.Body.String()
-	expected = Task{2, 2, 1234567891, 1234567895, 1, "Get some sleep..."}
+	expected = Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"}
 	if err := json.Unmarshal([]byte(body), &task); err != nil {
 		t.Error(err)
 	}
@@ -295,7 +300,7 @@ func Test_todo_addTask(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	newTask := Task{7, 2, 1234567890, 1234567899, 1, "Get some more sleep!"} // task will belong to AccountId 2
+	newTask := Task{Id: 7, AccountId: 2, Created: 1234567890, LastUpdated: 1234567899, Priority: 1, Task: "Get some more sleep!", Status: "Open", Version: 1} // task will belong to AccountId 2
 	request.PostForm = url.Values{
 		"Id":          {"-1"},
 		"AccountId":   {"2"},
@@ -342,6 +347,24 @@ func Test_todo_addTask(t *testing.T) {
 	_checkResponseCode(t, response, 400)
 	_checkResponseBody(t, response, "Invalid data")
 
+	for _, priority := range []string{"9999", "-5", "extreme"} {
+		request, err = http.NewRequest("POST", "http://localhost:8008/task/", nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		request.PostForm = url.Values{
+			"AccountId": {"2"},
+			"Priority":  {priority},
+			"Task":      {"Out of range"},
+		}
+		response = httptest.NewRecorder()
+
+		addTask(response, request, 2)
+		_checkResponseCode(t, response, 400)
+		_checkResponseBody(t, response, "Invalid priority")
+	}
+
 	// ============================================ Unauthorized ============================================
 	request, err = http.NewRequest("POST", "http://localhost:8008/task/", nil)
 	if err != nil {
@@ -374,13 +397,13 @@ func Test_todo_addTask(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	newTask = Task{8, 3, 1234567800, 1234567809, 3, "Get some more sleep!!!"} // task would belong to AccountId 3
+	newTask = Task{Id: 8, AccountId: 3, Created: 1234567800, LastUpdated: 1234567809, Priority: 3, Task: "Get some more sleep!!!", Status: "Open", Version: 1} // task would belong to AccountId 3
 	request.PostForm = url.Values{
 		"Id":          {"-1"},
 		"AccountId":   {"3"}, // task would belong to AccountId 3
 		"Created":     {"1234567800"},
 		"LastUpdated": {"1234567809"},
-		"Priority":    {"3"},
+		"Priority":    {"normal"}, // priorities can also be given by name
 		"Task":        {"Get some more sleep!!!"},
 	}
 
@@ -438,13 +461,13 @@ func Test_todo_editTask(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	editedTask := Task{6, 1, 12345678977, 12345678977, 7, "Watch TV.. !!!!!!"}
+	editedTask := Task{Id: 6, AccountId: 1, Created: 12345678977, LastUpdated: 12345678977, Priority: 5, Task: "Watch TV.. !!!!!!", Status: "Open", Version: 1}
 	request.PostForm = url.Values{
 		"Id":          {"6"},
 		"AccountId":   {"1"},
 		"Created":     {"12345678977"},
 		"LastUpdated": {"12345678977"},
-		"Priority":    {"7"},
+		"Priority":    {"5"},
 		"Task":        {"Watch TV.. !!!!!!"},
 	}
 
@@ -516,7 +539,7 @@ func Test_todo_editTask(t *testing.T) {
 	_checkResponseCode(t, response, 401)
 	_checkResponseBody(t, response, "Unauthorized")
 
-	editedTask = Task{1, 1, 1234567890, 1234567895, 3, "Buy food!"}
+	editedTask = Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"}
 	task, err = GetTaskById(1)
 	if err != nil {
 		t.Error(err)
@@ -547,7 +570,7 @@ func Test_todo_editTask(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
 
-	editedTask = Task{5, 1, 1234567897, 1234567897, 1, "Test!"}
+	editedTask = Task{Id: 5, AccountId: 1, Created: 1234567897, LastUpdated: 1234567897, Priority: 1, Task: "Test!", Status: "Open", Version: 1}
 	task, err = GetTaskById(5)
 	if err != nil {
 		t.Error(err)
@@ -586,7 +609,7 @@ func Test_todo_editTask(t *testing.T) {
 	_checkResponseCode(t, response, 409)
 	_checkResponseBody(t, response, "URL Id and Form Id do not match")
 
-	editedTask = Task{1, 1, 1234567890, 1234567895, 3, "Buy food!"}
+	editedTask = Task{Id: 1, AccountId: 1, Created: 1234567890, LastUpdated: 1234567895, Priority: 3, Task: "Buy food!", Status: "Open", Version: 1, Rank: "V"}
 	task, err = GetTaskById(1)
 	if err != nil {
 		t.Error(err)
@@ -596,7 +619,7 @@ func Test_todo_editTask(t *testing.T) {
 		t.Errorf("GetTaskById() after editTask() returned [%v], but expected task [%v]", task, editedTask)
 	}
 
-	editedTask = Task{2, 2, 1234567891, 1234567895, 1, "Get some sleep..."}
+	editedTask = Task{Id: 2, AccountId: 2, Created: 1234567891, LastUpdated: 1234567895, Priority: 1, Task: "Get some sleep...", Status: "Open", Version: 1, Rank: "V"}
 	task, err = GetTaskById(2)
 	if err != nil {
 		t.Error(err)
@@ -627,7 +650,7 @@ func Test_todo_editTask(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
 
-	editedTask = Task{10, 3, 1234567897, 1234567897, 1, "Test!"}
+	editedTask = Task{Id: 10, AccountId: 3, Created: 1234567897, LastUpdated: 1234567897, Priority: 1, Task: "Test!", Status: "Open", Version: 1}
 	task, err = GetTaskById(10)
 	if err != nil {
 		t.Error(err)
@@ -695,7 +718,7 @@ func Test_todo_editTask(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
 
-	editedTask = Task{12, 2, 1234567897, 1234567897, 1, "Test!!!"}
+	editedTask = Task{Id: 12, AccountId: 2, Created: 1234567897, LastUpdated: 1234567897, Priority: 1, Task: "Test!!!", Status: "Open", Version: 1}
 	task, err = GetTaskById(12)
 	if err != nil {
 		t.Error(err)
@@ -732,102 +755,3433 @@ func Test_todo_editTask(t *testing.T) {
 	_checkResponseBody(t, response, "Unauthorized")
 }
 
-func Test_todo_deleteTask(t *testing.T) {
-	// ============================================ Valid ============================================
-	request, err := http.NewRequest("DELETE", "http://localhost:8008/task/3", nil) // task belongs to AccountId 2
+func Test_todo_deleteTask(t *testing.T) {
+	// ============================================ Valid ============================================
+	request, err := http.NewRequest("DELETE", "http://localhost:8008/task/3", nil) // task belongs to AccountId 2
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	deleteTask(response, request, 2) // Use AccountId 2
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	task, err := GetTaskById(3)
+	if task != nil {
+		t.Errorf("GetTaskById() after deleteTask() still returned a task object, when it should not! Got [%v]", task)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// ============================================ Invalid ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/öäü", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 1)
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+
+	// ============================================ Unauthorized ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/4", nil) // task belongs to AccountId 1
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 2) // Use AccountId 2, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	task, err = GetTaskById(4)
+	if err != nil {
+		t.Error(err)
+	}
+	if task == nil {
+		t.Error("GetTaskById() after deleteTask() did not return a task object, but it still should!")
+	}
+
+	// ============================================ Valid Admin ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/2", nil) // task belongs to AccountId 2
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 1) // Use AccountId 1, which has Admin role
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	task, err = GetTaskById(2)
+	if task != nil {
+		t.Errorf("GetTaskById() after deleteTask() still returned a task object, when it should not! Got [%v]", task)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// ============================================ Nonexisting Task ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/77", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 1)
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+
+	// ============================================ Nonexisting Account ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 77)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+}
+
+func _todo_getProjects(t *testing.T, id int, expectedProjects Projects) {
+	request, err := http.NewRequest("GET", "http://localhost:8008/projects/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	getProjects(response, request, id)
+	_checkResponseCode(t, response, 200)
+
+	body := response.Body.String()
+	var projects Projects
+	if err := json.Unmarshal([]byte(body), &projects); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(projects) != len(expectedProjects) {
+		t.Errorf("getProjects() are not as expected: [%v], instead of [%v]", projects, expectedProjects)
+		return
+	}
+	for i, p := range projects {
+		if p != expectedProjects[i] {
+			t.Errorf("getProjects() are not as expected: [%v], instead of [%v]", projects, expectedProjects)
+			return
+		}
+	}
+}
+
+func Test_todo_projects(t *testing.T) {
+	// ============================================ Add ============================================
+	request, err := http.NewRequest("POST", "http://localhost:8008/project/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Name":      {"Shopping"},
+		"SortBy":    {"Task"},
+		"SortOrder": {"DESC"},
+	}
+	response := httptest.NewRecorder()
+
+	addProject(response, request, 2) // Use AccountId 2
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	_todo_getProjects(t, 2, Projects{{1, 2, "Shopping", "Task", "DESC", 0, 0}})
+
+	// ============================================ Invalid SortBy ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/project/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Name":   {"Shopping"},
+		"SortBy": {"Password"},
+	}
+	response = httptest.NewRecorder()
+
+	addProject(response, request, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid data")
+
+	// ============================================ Unauthorized Add ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/project/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId": {"1"}, // project would belong to AccountId 1
+		"Name":      {"Not mine"},
+	}
+	response = httptest.NewRecorder()
+
+	addProject(response, request, 2) // Use AccountId 2, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Add Tasks to Project ============================================
+	for _, text := range []string{"Apples", "Bananas"} {
+		request, err = http.NewRequest("POST", "http://localhost:8008/task/", nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		request.PostForm = url.Values{
+			"AccountId": {"2"},
+			"Priority":  {"3"},
+			"Task":      {text},
+			"ProjectId": {"1"},
+		}
+		response = httptest.NewRecorder()
+
+		addTask(response, request, 2)
+		_checkResponseCode(t, response, 200)
+		_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+	}
+
+	// ============================================ Add Task to foreign Project ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/task/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId": {"1"},
+		"Priority":  {"3"},
+		"Task":      {"Cherries"},
+		"ProjectId": {"1"}, // project belongs to AccountId 2
+	}
+	response = httptest.NewRecorder()
+
+	addTask(response, request, 1)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid project")
+
+	// ============================================ Filter Tasks by Project ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/tasks/?project=1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTasks(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var tasks Tasks
+	if err := json.Unmarshal([]byte(response.Body.String()), &tasks); err != nil {
+		t.Error(err)
+		return
+	}
+	// sorted by the projects default sort order, Task DESC
+	if len(tasks) != 2 || tasks[0].Task != "Bananas" || tasks[1].Task != "Apples" || tasks[0].ProjectId != 1 {
+		t.Errorf("getTasks() with project filter returned [%v]", tasks)
+	}
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/tasks/?project=1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTasks(response, request, 3) // Use AccountId 3, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Move Task out of Project ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/"+strconv.Itoa(tasks[1].Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {strconv.Itoa(tasks[1].Id)},
+		"AccountId": {"2"},
+		"Priority":  {"3"},
+		"Task":      {"Apples"},
+		"ProjectId": {"0"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	task, err := GetTaskById(tasks[1].Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if task.ProjectId != 0 {
+		t.Errorf("editTask() should have moved task out of project, got [%v]", task)
+	}
+
+	// ============================================ Archive Project ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/project/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":       {"1"},
+		"Name":     {"Groceries"},
+		"Archived": {"true"},
+	}
+	response = httptest.NewRecorder()
+
+	editProject(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	project, err := GetProjectById(1)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if project.Name != "Groceries" || !project.IsArchived() || project.SortBy != "" {
+		t.Errorf("GetProjectById() after editProject() returned [%v]", project)
+	}
+
+	archived, err := GetTasksByAccountId(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	for _, tk := range *archived {
+		if tk.ProjectId == 1 {
+			t.Errorf("GetTasksByAccountId() should not return tasks of archived projects, got [%v]", archived)
+		}
+	}
+
+	// ============================================ Get Project ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/project/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getProject(response, request, 1) // Use AccountId 1, which has Admin role
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "\"Name\":\"Groceries\"")
+
+	// ============================================ Delete Project ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/project/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteProject(response, request, 3) // Use AccountId 3, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	deleteProject(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	task, err = GetTaskById(tasks[0].Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if task.ProjectId != 0 {
+		t.Errorf("deleteProject() should have removed task from project, got [%v]", task)
+	}
+	_todo_getProjects(t, 2, Projects{})
+
+	response = httptest.NewRecorder()
+
+	deleteProject(response, request, 2)
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+}
+
+func Test_todo_subresourceHandler(t *testing.T) {
+	handler := func(body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}
+	}
+	h := subresourceHandler(handler("Task"), SubresourceHandler{"tags": handler("Tags")})
+
+	for path, expected := range map[string]string{
+		"/task/7":          "Task",
+		"/task/":           "Task",
+		"/task/7/tags":     "Tags",
+		"/task/7/tags/3":   "Tags",
+		"/task/7/unknown":  "404 page not found",
+		"/task/7/tags/abc": "Task", // not a valid subresource path, handled by getId()
+	} {
+		request, err := http.NewRequest("GET", "http://localhost:8008"+path, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		response := httptest.NewRecorder()
+
+		h(response, request)
+		_checkResponseBody(t, response, expected)
+	}
+}
+
+func Test_todo_tags(t *testing.T) {
+	// ============================================ Tag Tasks ============================================
+	taggings := []struct {
+		taskId int
+		name   string
+	}{{7, "home"}, {7, "urgent"}, {12, "Home"}} // tasks belong to AccountId 2
+	for _, tagging := range taggings {
+		request, err := http.NewRequest("POST", "http://localhost:8008/task/"+strconv.Itoa(tagging.taskId)+"/tags", nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		request.PostForm = url.Values{"Name": {tagging.name}}
+		response := httptest.NewRecorder()
+
+		addTaskTag(response, request, 2)
+		_checkResponseCode(t, response, 200)
+		_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+	}
+
+	// ============================================ Unauthorized Tag ============================================
+	request, err := http.NewRequest("POST", "http://localhost:8008/task/7/tags", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{"Name": {"mine"}}
+	response := httptest.NewRecorder()
+
+	addTaskTag(response, request, 3) // Use AccountId 3, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Get Task Tags ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/7/tags", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTaskTags(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var tags Tags
+	if err := json.Unmarshal([]byte(response.Body.String()), &tags); err != nil {
+		t.Error(err)
+		return
+	}
+	expectedTags := Tags{{1, 2, "home"}, {2, 2, "urgent"}}
+	if len(tags) != len(expectedTags) || tags[0] != expectedTags[0] || tags[1] != expectedTags[1] {
+		t.Errorf("getTaskTags() returned [%v], but expected [%v]", tags, expectedTags)
+	}
+
+	// ============================================ Filter Tasks by Tags ============================================
+	for query, expectedIds := range map[string][]int{
+		"anyTags=home":                 {12, 7},
+		"allTags=HOME,urgent":          {7},
+		"anyTags=home&noneTags=urgent": {12},
+		"anyTags=unknown":              {},
+	} {
+		request, err = http.NewRequest("GET", "http://localhost:8008/tasks/?"+query, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		response = httptest.NewRecorder()
+
+		getTasks(response, request, 2)
+		_checkResponseCode(t, response, 200)
+
+		var tasks Tasks
+		if err := json.Unmarshal([]byte(response.Body.String()), &tasks); err != nil {
+			t.Error(err)
+			return
+		}
+		if len(tasks) != len(expectedIds) {
+			t.Errorf("getTasks() with [%v] returned [%v], but expected task ids [%v]", query, tasks, expectedIds)
+			continue
+		}
+		for i, tk := range tasks {
+			if tk.Id != expectedIds[i] {
+				t.Errorf("getTasks() with [%v] returned [%v], but expected task ids [%v]", query, tasks, expectedIds)
+			}
+		}
+	}
+
+	// ============================================ Tag Cloud ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/tags/cloud", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTagCloud(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, `[{"Id":1,"Name":"home","Count":2},{"Id":2,"Name":"urgent","Count":1}]`)
+
+	// ============================================ Rename Conflict ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/tag/2", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{"Id": {"2"}, "Name": {"Home"}}
+	response = httptest.NewRecorder()
+
+	editTag(response, request, 2)
+	_checkResponseCode(t, response, 409)
+	_checkResponseBody(t, response, "Tag already exists")
+
+	// ============================================ Merge ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/tag/2", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{"Id": {"2"}, "Name": {"Home"}, "Merge": {"true"}}
+	response = httptest.NewRecorder()
+
+	editTag(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Merge\": \"Success\"}")
+
+	// ============================================ Rename ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/tag/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{"Id": {"1"}, "Name": {"household"}}
+	response = httptest.NewRecorder()
+
+	editTag(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/tags/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTags(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, `[{"Id":1,"AccountId":2,"Name":"household"}]`)
+
+	// ============================================ Untag ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/12/tags/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTaskTag(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	tagsOfTask, err := GetTagsByTaskId(12)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tagsOfTask) != 0 {
+		t.Errorf("GetTagsByTaskId() after deleteTaskTag() should be empty, instead of [%v]", tagsOfTask)
+	}
+
+	// ============================================ Delete Tag ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/tag/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTag(response, request, 3) // Use AccountId 3, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	deleteTag(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	tagsOfTask, err = GetTagsByTaskId(7)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(*tagsOfTask) != 0 {
+		t.Errorf("GetTagsByTaskId() after deleteTag() should be empty, instead of [%v]", tagsOfTask)
+	}
+}
+
+func Test_todo_shares(t *testing.T) {
+	// ============================================ Unauthorized before sharing ============================================
+	request, err := http.NewRequest("GET", "http://localhost:8008/task/7", nil) // task belongs to AccountId 2
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	getTask(response, request, 3) // Use AccountId 3, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Share Task ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/task/7/shares", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Email":      {"ozzie@abrakadabra"}, // AccountId 3
+		"Permission": {"View"},
+	}
+	response = httptest.NewRecorder()
+
+	addTaskShare(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	// ============================================ Share Task with Owner ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/task/7/shares", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId":  {"2"},
+		"Permission": {"View"},
+	}
+	response = httptest.NewRecorder()
+
+	addTaskShare(response, request, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid account")
+
+	// ============================================ View shared Task ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTask(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "\"Id\":7")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/tasks/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTasks(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "\"Id\":7")
+
+	// ============================================ Edit with View permission ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {"7"},
+		"AccountId": {"2"},
+		"Priority":  {"2"},
+		"Task":      {"Shared sleep"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Edit with Edit permission ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/task/7/shares", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId":  {"3"},
+		"Permission": {"Edit"},
+	}
+	response = httptest.NewRecorder()
+
+	addTaskShare(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {"7"},
+		"AccountId": {"2"},
+		"Priority":  {"2"},
+		"Task":      {"Shared sleep"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	task, err := GetTaskById(7)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if task.Task != "Shared sleep" || task.AccountId != 2 {
+		t.Errorf("GetTaskById() after editTask() returned [%v]", task)
+	}
+
+	// editing a shared task does not allow to move it into other projects of its owner
+	private := Project{-1, 2, "Private", "", "ASC", 0, 0}
+	if err := private.Save(); err != nil {
+		t.Fatal(err)
+	}
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {"7"},
+		"AccountId": {"2"},
+		"Priority":  {"2"},
+		"Task":      {"Shared sleep"},
+		"ProjectId": {strconv.Itoa(private.Id)},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	if task, err := GetTaskById(7); err != nil || task.ProjectId != 0 {
+		t.Errorf("editTask() moved the shared task into the project: [%v], [%v]", task, err)
+	}
+	if err := private.Delete(); err != nil {
+		t.Error(err)
+	}
+
+	// changing the owner is still only possible for Admins
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {"7"},
+		"AccountId": {"3"},
+		"Priority":  {"2"},
+		"Task":      {"Mine now"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Delete and manage shares with Edit permission ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/7/shares", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTaskShares(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	getTaskShares(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var shares Shares
+	if err := json.Unmarshal([]byte(response.Body.String()), &shares); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(shares) != 1 || shares[0].AccountId != 3 || shares[0].Permission != "Edit" {
+		t.Errorf("getTaskShares() returned [%v]", shares)
+		return
+	}
+
+	// ============================================ Share Project ============================================
+	project := Project{-1, 2, "Release", "", "ASC", 0, 0}
+	if err := project.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	task = &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 3, Task: "Tag release", ProjectId: project.Id, Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	request, err = http.NewRequest("POST", "http://localhost:8008/project/"+strconv.Itoa(project.Id)+"/shares", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId":  {"3"},
+		"Permission": {"Manage"},
+	}
+	response = httptest.NewRecorder()
+
+	addProjectShare(response, request, 3) // Use AccountId 3, which is not the owner
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	addProjectShare(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/tasks/?project="+strconv.Itoa(project.Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTasks(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "Tag release")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/shares/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getShares(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	if err := json.Unmarshal([]byte(response.Body.String()), &shares); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(shares) != 2 {
+		t.Errorf("getShares() returned [%v]", shares)
+		return
+	}
+
+	// ============================================ Delete Task with Manage permission ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/"+strconv.Itoa(task.Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	// ============================================ Revoke Shares ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/project/"+strconv.Itoa(project.Id)+"/shares/"+strconv.Itoa(shares[0].Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteProjectShare(response, request, 2) // share does not belong to this project
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/7/shares/"+strconv.Itoa(shares[0].Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTaskShare(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/project/"+strconv.Itoa(project.Id)+"/shares/"+strconv.Itoa(shares[1].Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteProjectShare(response, request, 3) // recipients can remove their own shares
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	if err := project.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_todo_teams(t *testing.T) {
+	// ============================================ Create Team ============================================
+	request, err := http.NewRequest("POST", "http://localhost:8008/team/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Name": {"Operations"},
+	}
+	response := httptest.NewRecorder()
+
+	addTeam(response, request, 2) // AccountId 2 becomes the first owner
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/teams/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTeams(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var teams Teams
+	if err := json.Unmarshal([]byte(response.Body.String()), &teams); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(teams) != 1 || teams[0].Name != "Operations" {
+		t.Errorf("getTeams() returned [%v]", teams)
+		return
+	}
+	teamUrl := "http://localhost:8008/team/" + strconv.Itoa(teams[0].Id)
+
+	// ============================================ Add Members ============================================
+	request, err = http.NewRequest("POST", teamUrl+"/members", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Email": {"ozzie@abrakadabra"}, // AccountId 3
+		"Role":  {"Viewer"},
+	}
+	response = httptest.NewRecorder()
+
+	addTeamMember(response, request, 3) // Use AccountId 3, which is not a member yet
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	addTeamMember(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	request, err = http.NewRequest("POST", teamUrl+"/members", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId": {"3"},
+		"Role":      {"Boss"},
+	}
+	response = httptest.NewRecorder()
+
+	addTeamMember(response, request, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid role")
+
+	// ============================================ Team Project and Tasks ============================================
+	project := Project{-1, 2, "Operations board", "", "ASC", 0, teams[0].Id}
+	if err := project.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	task := &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 2, Task: "Rotate certificates", ProjectId: project.Id, Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	request, err = http.NewRequest("GET", teamUrl+"/tasks", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTeamTasks(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "Rotate certificates")
+
+	request, err = http.NewRequest("GET", teamUrl+"/projects", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTeamProjects(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "Operations board")
+
+	// ============================================ Edit as Viewer ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/"+strconv.Itoa(task.Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {strconv.Itoa(task.Id)},
+		"AccountId": {"2"},
+		"Priority":  {"1"},
+		"Task":      {"Rotate all certificates"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Add Task as Member ============================================
+	request, err = http.NewRequest("POST", teamUrl+"/members", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId": {"3"},
+		"Role":      {"Member"},
+	}
+	response = httptest.NewRecorder()
+
+	addTeamMember(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	request, err = http.NewRequest("POST", "http://localhost:8008/task/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId": {"3"},
+		"Priority":  {"3"},
+		"Task":      {"Renew domain"},
+		"ProjectId": {strconv.Itoa(project.Id)},
+	}
+	response = httptest.NewRecorder()
+
+	addTask(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	tasks, err := GetTasksByTeamId(teams[0].Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(*tasks) != 2 {
+		t.Errorf("GetTasksByTeamId() after addTask() returned [%v]", tasks)
+		return
+	}
+
+	// ============================================ Last Owner ============================================
+	request, err = http.NewRequest("POST", teamUrl+"/members", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"AccountId": {"2"},
+		"Role":      {"Member"},
+	}
+	response = httptest.NewRecorder()
+
+	addTeamMember(response, request, 2)
+	_checkResponseCode(t, response, 409)
+	_checkResponseBody(t, response, "Team needs at least one owner")
+
+	request, err = http.NewRequest("DELETE", teamUrl+"/members/2", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTeamMember(response, request, 2)
+	_checkResponseCode(t, response, 409)
+	_checkResponseBody(t, response, "Team needs at least one owner")
+
+	// ============================================ Leave Team ============================================
+	request, err = http.NewRequest("DELETE", teamUrl+"/members/3", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTeamMember(response, request, 3) // members can leave by themselves
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	request, err = http.NewRequest("GET", teamUrl+"/tasks", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTeamTasks(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Delete Team ============================================
+	request, err = http.NewRequest("DELETE", teamUrl, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTeam(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := project.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+type recordingNotifier struct {
+	assignments Assignments
+}
+
+func (n *recordingNotifier) TaskAssigned(task *Task, assignment *Assignment) error {
+	n.assignments = append(n.assignments, *assignment)
+	return nil
+}
+
+func Test_todo_assignments(t *testing.T) {
+	recorder := &recordingNotifier{}
+	SetNotifier(recorder)
+	defer SetNotifier(&logNotifier{})
+
+	// ============================================ Assign Task ============================================
+	request, err := http.NewRequest("PUT", "http://localhost:8008/task/7", nil) // task belongs to AccountId 2
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":         {"7"},
+		"AccountId":  {"2"},
+		"Priority":   {"2"},
+		"Task":       {"Shared sleep"},
+		"AssigneeId": {"99"},
+	}
+	response := httptest.NewRecorder()
+
+	editTask(response, request, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid account")
+
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":         {"7"},
+		"AccountId":  {"2"},
+		"Priority":   {"2"},
+		"Task":       {"Shared sleep"},
+		"AssigneeId": {"3"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	task, err := GetTaskById(7)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if task.AccountId != 2 || task.AssigneeId != 3 || task.Status != "Open" {
+		t.Errorf("GetTaskById() after editTask() returned [%v]", task)
+	}
+	if len(recorder.assignments) != 1 || recorder.assignments[0].AssigneeId != 3 || recorder.assignments[0].AssignedBy != 2 {
+		t.Errorf("Notifications after editTask() are not as expected: [%v]", recorder.assignments)
+	}
+
+	// ============================================ Assigned Tasks ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/tasks/assigned", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAssignedTasks(response, request, 3)
+	_checkResponseCode(t, response, 200)
+
+	var tasks Tasks
+	if err := json.Unmarshal([]byte(response.Body.String()), &tasks); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(tasks) != 1 || tasks[0].Id != 7 {
+		t.Errorf("getAssignedTasks() returned [%v]", tasks)
+	}
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTask(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "\"AssigneeId\":3")
+
+	// ============================================ Update Status as Assignee ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7/status", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Status": {"Closed"},
+	}
+	response = httptest.NewRecorder()
+
+	editTaskStatus(response, request, 3)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid status")
+
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7/status", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Status": {"Done"},
+	}
+	response = httptest.NewRecorder()
+
+	editTaskStatus(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	task, err = GetTaskById(7)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if task.Status != "Done" || task.Task != "Shared sleep" {
+		t.Errorf("GetTaskById() after editTaskStatus() returned [%v]", task)
+	}
+
+	// ============================================ Edit and Delete as Assignee ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {"7"},
+		"AccountId": {"2"},
+		"Priority":  {"5"},
+		"Task":      {"Sleep all day"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Unassign Task ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":         {"7"},
+		"AccountId":  {"2"},
+		"Priority":   {"2"},
+		"Task":       {"Shared sleep"},
+		"AssigneeId": {"0"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	if len(recorder.assignments) != 1 {
+		t.Errorf("Unassigning should not notify anyone: [%v]", recorder.assignments)
+	}
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/7/assignments", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTaskAssignments(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var assignments Assignments
+	if err := json.Unmarshal([]byte(response.Body.String()), &assignments); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(assignments) != 2 || assignments[0].AssigneeId != 3 || assignments[1].AssigneeId != 0 || assignments[1].AssignedBy != 2 {
+		t.Errorf("getTaskAssignments() returned [%v]", assignments)
+	}
+
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/7/status", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Status": {"Open"},
+	}
+	response = httptest.NewRecorder()
+
+	editTaskStatus(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+}
+
+func Test_todo_comments(t *testing.T) {
+	// ============================================ Comment without access ============================================
+	request, err := http.NewRequest("POST", "http://localhost:8008/task/7/comments", nil) // task belongs to AccountId 2
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Body": {"Let me in"},
+	}
+	response := httptest.NewRecorder()
+
+	addTaskComment(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Add Comment ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/task/7/comments", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Body": {"  "},
+	}
+	response = httptest.NewRecorder()
+
+	addTaskComment(response, request, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid data")
+
+	request, err = http.NewRequest("POST", "http://localhost:8008/task/7/comments", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Body": {"*Really* tired, @ozzie and @nobody can you take over?"},
+	}
+	response = httptest.NewRecorder()
+
+	addTaskComment(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/7/comments", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTaskComments(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var comments Comments
+	if err := json.Unmarshal([]byte(response.Body.String()), &comments); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(comments) != 1 || comments[0].AccountId != 2 ||
+		comments[0].Body != "*Really* tired, @ozzie and @nobody can you take over?" ||
+		len(comments[0].Mentions) != 1 || comments[0].Mentions[0] != 3 {
+		t.Errorf("getTaskComments() returned [%v]", comments)
+		return
+	}
+	commentUrl := "http://localhost:8008/task/7/comments/" + strconv.Itoa(comments[0].Id)
+
+	// ============================================ Edit Comment ============================================
+	request, err = http.NewRequest("PUT", commentUrl, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Body": {"Never mind"},
+	}
+	response = httptest.NewRecorder()
+
+	editTaskComment(response, request, 1) // Use AccountId 1, which is an Admin but not the author
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	editTaskComment(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+
+	comment, err := GetCommentById(comments[0].Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if comment.Body != "Never mind" || len(comment.Mentions) != 0 {
+		t.Errorf("GetCommentById() after editTaskComment() returned [%v]", comment)
+	}
+
+	// ============================================ Delete Comment ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/12/comments/"+strconv.Itoa(comment.Id), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTaskComment(response, request, 2) // comment does not belong to this task
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+
+	request, err = http.NewRequest("DELETE", commentUrl, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTaskComment(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	remaining, err := GetCommentsByTaskId(7)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if len(*remaining) != 0 {
+		t.Errorf("GetCommentsByTaskId() after deleteTaskComment() returned [%v]", remaining)
+	}
+}
+
+func _todo_uploadRequest(t *testing.T, url string, field string, filename string, content string) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := part.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func Test_todo_attachments(t *testing.T) {
+	store := NewMemoryBlobStore()
+	SetBlobStore(store)
+	defer SetBlobStore(NewLocalBlobStore("./data/attachments"))
+	SetMaxAttachmentSize(64)
+	defer SetMaxAttachmentSize(10 << 20)
+
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+
+	// ============================================ Upload ============================================
+	request := _todo_uploadRequest(t, "http://localhost:8008/task/7/attachments", "File", "screenshot.png", png) // task belongs to AccountId 2
+	response := httptest.NewRecorder()
+
+	addTaskAttachment(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	request = _todo_uploadRequest(t, "http://localhost:8008/task/7/attachments", "Upload", "screenshot.png", png)
+	response = httptest.NewRecorder()
+
+	addTaskAttachment(response, request, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid data")
+
+	request = _todo_uploadRequest(t, "http://localhost:8008/task/7/attachments", "File", "huge.log", strings.Repeat("log", 100))
+	response = httptest.NewRecorder()
+
+	addTaskAttachment(response, request, 2)
+	_checkResponseCode(t, response, 413)
+	_checkResponseBody(t, response, "Attachment too large")
+
+	request = _todo_uploadRequest(t, "http://localhost:8008/task/7/attachments", "File", "../screenshot.png", png)
+	response = httptest.NewRecorder()
+
+	addTaskAttachment(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	// ============================================ List ============================================
+	request, err := http.NewRequest("GET", "http://localhost:8008/task/7/attachments", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTaskAttachments(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	if strings.Contains(response.Body.String(), "BlobKey") {
+		t.Errorf("getTaskAttachments() should not expose the blob key: [%v]", response.Body.String())
+	}
+
+	var attachments Attachments
+	if err := json.Unmarshal([]byte(response.Body.String()), &attachments); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(attachments) != 1 || attachments[0].Name != "screenshot.png" ||
+		attachments[0].ContentType != "image/png" || attachments[0].Size != int64(len(png)) || attachments[0].AccountId != 2 {
+		t.Errorf("getTaskAttachments() returned [%v]", attachments)
+		return
+	}
+	attachmentUrl := "http://localhost:8008/task/7/attachments/" + strconv.Itoa(attachments[0].Id)
+
+	// ============================================ Download ============================================
+	request, err = http.NewRequest("GET", attachmentUrl, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTaskAttachments(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	getTaskAttachments(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	if response.Body.String() != png {
+		t.Errorf("Downloaded attachment is not as expected: [%v]", response.Body.String())
+	}
+	if response.Header().Get("Content-Type") != "image/png" ||
+		response.Header().Get("Content-Disposition") != "attachment; filename=screenshot.png" {
+		t.Errorf("Download headers are not as expected: [%v]", response.Header())
+	}
+
+	// ============================================ Delete ============================================
+	request, err = http.NewRequest("DELETE", attachmentUrl, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTaskAttachment(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	if store.Len() != 0 {
+		t.Errorf("Blob should be removed together with its attachment, [%v] blobs left", store.Len())
+	}
+}
+
+func Test_todo_history(t *testing.T) {
+	// ============================================ Task History ============================================
+	request, err := http.NewRequest("GET", "http://localhost:8008/task/7/history", nil) // task belongs to AccountId 2
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	getTaskHistory(response, request, 3)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	getTaskHistory(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var changes Changes
+	if err := json.Unmarshal([]byte(response.Body.String()), &changes); err != nil {
+		t.Error(err)
+		return
+	}
+	found := map[string]bool{}
+	for _, c := range changes {
+		if c.Entity != EntityTask || c.EntityId != 7 {
+			t.Errorf("getTaskHistory() returned change of another entity [%v]", c)
+		}
+		found[fmt.Sprintf("%v %v %v>%v", c.AccountId, c.Field, c.OldValue, c.NewValue)] = true
+	}
+	for _, expected := range []string{"3 Task Get some more sleep!>Shared sleep", "2 AssigneeId 0>3", "3 Status Open>Done", "2 AssigneeId 3>0"} {
+		if !found[expected] {
+			t.Errorf("getTaskHistory() is missing change [%v]: [%v]", expected, changes)
+		}
+	}
+
+	// ============================================ Audit Log ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/audit/?entity=Task&entityId=7&actor=3", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAuditLog(response, request, 2) // Use AccountId 2, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	getAuditLog(response, request, 1)
+	_checkResponseCode(t, response, 200)
+	if err := json.Unmarshal([]byte(response.Body.String()), &changes); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(changes) == 0 {
+		t.Error("getAuditLog() returned no changes")
+	}
+	for _, c := range changes {
+		if c.AccountId != 3 || c.EntityId != 7 {
+			t.Errorf("getAuditLog() returned unexpected change [%v]", c)
+		}
+	}
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/audit/?entity=Project", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAuditLog(response, request, 1)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid data")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/audit/?from=yesterday", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAuditLog(response, request, 1)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid data")
+}
+
+func Test_todo_trash(t *testing.T) {
+	task := &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Do not lose me", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	taskId := strconv.Itoa(task.Id)
+
+	// ============================================ Move to Trash ============================================
+	request, err := http.NewRequest("DELETE", "http://localhost:8008/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	deleteTask(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTask(response, request, 2)
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/tasks/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTasks(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	if strings.Contains(response.Body.String(), "Do not lose me") {
+		t.Errorf("getTasks() should not return tasks in the trash: [%v]", response.Body.String())
+	}
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/trash/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getTrash(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var trash Trash
+	if err := json.Unmarshal([]byte(response.Body.String()), &trash); err != nil {
+		t.Error(err)
+		return
+	}
+	inTrash := false
+	for _, trashed := range trash.Tasks {
+		if trashed.AccountId != 2 || !trashed.IsDeleted() {
+			t.Errorf("getTrash() returned unexpected task [%v]", trashed)
+		}
+		inTrash = inTrash || trashed.Id == task.Id
+	}
+	if !inTrash || len(trash.Accounts) != 0 {
+		t.Errorf("getTrash() returned [%v]", trash)
+	}
+
+	// ============================================ Edit in Trash ============================================
+	request, err = http.NewRequest("PUT", "http://localhost:8008/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.PostForm = url.Values{
+		"Id":        {taskId},
+		"AccountId": {"2"},
+		"Priority":  {"1"},
+		"Task":      {"Overwritten"},
+	}
+	response = httptest.NewRecorder()
+
+	editTask(response, request, 2)
+	_checkResponseCode(t, response, 409)
+	_checkResponseBody(t, response, "Task is in the trash")
+
+	// ============================================ Restore ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/trash/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	restoreTrash(response, request, 3) // Use AccountId 3, which is not the owner
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	restoreTrash(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Restore\": \"Success\"}")
+
+	restored, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if restored.Task != "Do not lose me" || restored.IsDeleted() {
+		t.Errorf("GetTaskById() after restoreTrash() returned [%v]", restored)
+	}
+
+	response = httptest.NewRecorder()
+
+	restoreTrash(response, request, 2) // not in the trash anymore
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+
+	// ============================================ Purge ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/trash/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	purgeTrash(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	if _, err := GetTrashedTaskById(task.Id); err == nil {
+		t.Errorf("Task [%v] should be purged from the trash", task.Id)
+	}
+
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/trash/project/1", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	purgeTrash(response, request, 2)
+	_checkResponseCode(t, response, 404)
+	_checkResponseBody(t, response, "404 page not found")
+}
+
+func Test_todo_undo(t *testing.T) {
+	task := &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Undo my edits", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	taskId := strconv.Itoa(task.Id)
+
+	edit := func(accountId int, priority string) {
+		request, err := http.NewRequest("PUT", "http://localhost:8008/task/"+taskId, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.PostForm = url.Values{
+			"Id":        {taskId},
+			"AccountId": {"2"},
+			"Priority":  {priority},
+			"Task":      {"Overwritten from another tab"},
+		}
+		response := httptest.NewRecorder()
+
+		editTask(response, request, accountId)
+		_checkResponseCode(t, response, 200)
+	}
+
+	// ============================================ Undo Edit ============================================
+	edit(2, "4")
+
+	request, err := http.NewRequest("GET", "http://localhost:8008/undo/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	getUndo(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	var steps UndoSteps
+	if err := json.Unmarshal([]byte(response.Body.String()), &steps); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(steps) == 0 || steps[0].Changes[0].Before.Task != "Undo my edits" || steps[0].Changes[0].After.Priority != 4 {
+		t.Errorf("getUndo() returned [%v]", steps)
+	}
+
+	request, err = http.NewRequest("POST", "http://localhost:8008/undo/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	undo(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Undo\": \"Success\"}")
+
+	current, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Priority != task.Priority || current.Task != task.Task {
+		t.Errorf("Task after undo is [%v], instead of [%v]", current, task)
+	}
+
+	// ============================================ Conflicting Undo ============================================
+	edit(2, "4")
+	edit(1, "5") // AccountId 1 is an Admin and changes the task afterwards
+
+	response = httptest.NewRecorder()
+
+	undo(response, request, 2)
+	_checkResponseCode(t, response, 409)
+	_checkResponseBody(t, response, "Conflicting change")
+
+	current, err = GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Priority != 5 {
+		t.Errorf("Conflicting undo should not change the task: [%v]", current)
+	}
+
+	// the admin can still undo its own change
+	response = httptest.NewRecorder()
+
+	undo(response, request, 1)
+	_checkResponseCode(t, response, 200)
+
+	current, err = GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Priority != 4 {
+		t.Errorf("Task after undo is [%v]", current)
+	}
+}
+
+func Test_todo_etags(t *testing.T) {
+	task := &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Edited in two tabs", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	taskId := strconv.Itoa(task.Id)
+
+	// ============================================ ETag ============================================
+	request, err := http.NewRequest("GET", "http://localhost:8008/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	getTask(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	if response.Header().Get("ETag") != "\"1\"" {
+		t.Errorf("getTask() returned ETag [%v]", response.Header().Get("ETag"))
+	}
+
+	request.Header.Set("If-None-Match", "\"1\"")
+	response = httptest.NewRecorder()
+
+	getTask(response, request, 2)
+	_checkResponseCode(t, response, 304)
+
+	// ============================================ If-Match ============================================
+	edit := func(ifMatch string, text string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("PUT", "http://localhost:8008/task/"+taskId, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("If-Match", ifMatch)
+		request.PostForm = url.Values{
+			"Id":        {taskId},
+			"AccountId": {"2"},
+			"Priority":  {"1"},
+			"Task":      {text},
+		}
+		response := httptest.NewRecorder()
+
+		editTask(response, request, 2)
+		return response
+	}
+
+	response = edit("\"1\"", "First tab")
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+	if response.Header().Get("ETag") != "\"2\"" {
+		t.Errorf("editTask() returned ETag [%v]", response.Header().Get("ETag"))
+	}
+
+	response = edit("\"1\"", "Second tab")
+	_checkResponseCode(t, response, 412)
+	_checkResponseBody(t, response, "Precondition Failed")
+
+	response = edit("garbage", "Second tab")
+	_checkResponseCode(t, response, 412)
+
+	current, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Task != "First tab" || current.Version != 2 {
+		t.Errorf("Task should not be overwritten by a stale edit: [%v]", current)
+	}
+
+	editStatus := func(ifMatch string, status string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("PUT", "http://localhost:8008/task/"+taskId+"/status", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("If-Match", ifMatch)
+		request.PostForm = url.Values{"Status": {status}}
+		response := httptest.NewRecorder()
+
+		editTaskStatus(response, request, 2)
+		return response
+	}
+
+	response = editStatus("\"1\"", "Done")
+	_checkResponseCode(t, response, 412)
+	if current, err := GetTaskById(task.Id); err != nil || current.Status != "Open" || current.Version != 2 {
+		t.Errorf("Task status should not be changed by a stale edit: [%v], [%v]", current, err)
+	}
+	response = editStatus("\"2\"", "InProgress")
+	_checkResponseCode(t, response, 200)
+	if response.Header().Get("ETag") != "\"3\"" {
+		t.Errorf("editTaskStatus() returned ETag [%v]", response.Header().Get("ETag"))
+	}
+
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/"+taskId, nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	request.Header.Set("If-Match", "\"1\"")
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 2)
+	_checkResponseCode(t, response, 412)
+
+	request.Header.Set("If-Match", "\"3\"")
+	response = httptest.NewRecorder()
+
+	deleteTask(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	// ============================================ Accounts ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/account/2", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAccount(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	etag := response.Header().Get("ETag")
+	if etag == "" {
+		t.Errorf("getAccount() should return an ETag")
+	}
+
+	request.Header.Set("If-None-Match", etag)
+	response = httptest.NewRecorder()
+
+	getAccount(response, request, 2)
+	_checkResponseCode(t, response, 304)
+}
+
+func Test_todo_projectAllowed(t *testing.T) {
+	project := Project{-1, 1, "Allowed", "", "ASC", 0, 0}
+	if err := project.Save(); err != nil {
+		t.Fatal(err)
+	}
+	defer project.Delete()
+
+	var tests = []struct {
+		projectId int
+		accountId int
+		expected  bool
+	}{
+		{0, 2, true},
+		{project.Id, 1, true},
+		{project.Id, 2, false},
+		{999999, 1, false},
+	}
+	for _, test := range tests {
+		allowed, err := projectAllowed(test.projectId, test.accountId)
+		if err != nil || allowed != test.expected {
+			t.Errorf("projectAllowed(%v, %v) returned [%v], [%v], instead of [%v]", test.projectId, test.accountId, allowed, err, test.expected)
+		}
+	}
+}
+
+func Test_todo_bulk(t *testing.T) {
+	task := &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Bulk me", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	other := &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Bulk delete me", Status: "Open", Version: 1}
+	if err := other.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	taskId, otherId := strconv.Itoa(task.Id), strconv.Itoa(other.Id)
+
+	bulk := func(body string) (*httptest.ResponseRecorder, BulkResults) {
+		request, err := http.NewRequest("POST", "http://localhost:8008/tasks/bulk", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		bulkTasks(response, request, 2)
+
+		var results BulkResults
+		json.Unmarshal([]byte(response.Body.String()), &results)
+		return response, results
+	}
+
+	// ============================================ Atomic ============================================
+	response, results := bulk(`{"Operations": [
+		{"Op": "Create", "Task": {"AccountId": 2, "Priority": 2, "Task": "Bulk created"}},
+		{"Op": "Update", "Task": {"Id": ` + taskId + `, "AccountId": 2, "Priority": 4, "Task": "Bulk updated"}},
+		{"Op": "Delete", "Task": {"Id": 1}}
+	]}`) // AccountId 2 cannot delete the task of AccountId 1
+	_checkResponseCode(t, response, 401)
+	if len(results) != 3 || results[0].Status != 424 || results[1].Status != 424 || results[2].Status != 401 || results[2].Error != "Unauthorized" {
+		t.Errorf("bulkTasks() returned [%v]", results)
+	}
+
+	current, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Task != "Bulk me" {
+		t.Errorf("A failed bulk request should not change any task: [%v]", current)
+	}
+
+	response, results = bulk(`{"Operations": [
+		{"Op": "Create", "Task": {"AccountId": 2, "Priority": 2, "Task": "Bulk created"}},
+		{"Op": "Update", "Task": {"Id": ` + taskId + `, "AccountId": 2, "Priority": 4, "Task": "Bulk updated"}},
+		{"Op": "Delete", "Task": {"Id": ` + otherId + `}}
+	]}`)
+	_checkResponseCode(t, response, 200)
+	if len(results) != 3 || results.Failed() || results[0].Id < 1 {
+		t.Errorf("bulkTasks() returned [%v]", results)
+		return
+	}
+
+	created, err := GetTaskById(results[0].Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	current, err = GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if created.Task != "Bulk created" || created.Status != "Open" || current.Task != "Bulk updated" || current.Priority != 4 {
+		t.Errorf("Tasks after bulkTasks() are not as expected: [%v], [%v]", created, current)
+	}
+	if _, err := GetTrashedTaskById(other.Id); err != nil {
+		t.Errorf("Task [%v] should have been moved to the trash", other)
+	}
+
+	// ============================================ Undo ============================================
+	request, err := http.NewRequest("POST", "http://localhost:8008/undo/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	undo(response, request, 2)
+	_checkResponseCode(t, response, 200)
+
+	current, err = GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Task != "Bulk me" {
+		t.Errorf("Undo of a bulk request should revert all of its operations: [%v]", current)
+	}
+	if _, err := GetTaskById(other.Id); err != nil {
+		t.Errorf("Undo of a bulk request should restore deleted tasks: [%v]", err)
+	}
+	if _, err := GetTrashedTaskById(created.Id); err != nil {
+		t.Errorf("Undo of a bulk request should trash created tasks: [%v]", err)
+	}
+
+	// ============================================ Continue On Error ============================================
+	response, results = bulk(`{"ContinueOnError": true, "Operations": [
+		{"Op": "Update", "Task": {"Id": ` + taskId + `, "AccountId": 2, "Priority": 5, "Task": "Updated anyway"}},
+		{"Op": "Update", "Task": {"Id": ` + taskId + `, "AccountId": 2, "Priority": 1, "Task": "Twice"}},
+		{"Op": "Update", "Task": {"Id": 999999, "AccountId": 2, "Priority": 1, "Task": "Missing"}},
+		{"Op": "Archive", "Task": {"Id": ` + taskId + `}}
+	]}`)
+	_checkResponseCode(t, response, 200)
+	if len(results) != 4 || results[0].Status != 200 || results[1].Status != 409 || results[2].Status != 404 || results[3].Status != 400 {
+		t.Errorf("bulkTasks() returned [%v]", results)
+	}
+
+	current, err = GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Task != "Updated anyway" || current.Priority != 5 {
+		t.Errorf("Task after bulkTasks() is not as expected: [%v]", current)
+	}
+
+	// ============================================ Omitted Fields ============================================
+	project := Project{-1, 2, "Bulk project", "", "ASC", 0, 0}
+	if err := project.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer project.Delete()
+	current.ProjectId = project.Id
+	if err := current.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+
+	response, results = bulk(`{"Operations": [{"Op": "Update", "Task": {"Id": ` + taskId + `, "Status": "Done"}}]}`)
+	_checkResponseCode(t, response, 200)
+	if len(results) != 1 || results.Failed() {
+		t.Errorf("bulkTasks() returned [%v]", results)
+	}
+
+	updated, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if updated.Status != "Done" || updated.ProjectId != project.Id || updated.Priority != 5 || updated.Task != "Updated anyway" || updated.AccountId != 2 || updated.Rank != current.Rank {
+		t.Errorf("A bulk update should keep the fields it leaves out: [%v], instead of [%v]", updated, current)
+	}
+
+	response, _ = bulk(`{"Operations": []}`)
+	_checkResponseCode(t, response, 400)
+}
+
+func Test_todo_filterTasks(t *testing.T) {
+	getFiltered := func(filter string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("GET", "http://localhost:8008/tasks/?filter="+url.QueryEscape(filter), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		getTasks(response, request, 1)
+		return response
+	}
+
+	// ============================================ Valid ============================================
+	response := getFiltered(`priority>=3 AND text~"buy"`)
+	_checkResponseCode(t, response, 200)
+
+	var tasks Tasks
+	if err := json.Unmarshal([]byte(response.Body.String()), &tasks); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(tasks) == 0 {
+		t.Errorf("getTasks() with filter returned no tasks")
+	}
+	for _, task := range tasks {
+		if task.Priority < 3 || !strings.Contains(strings.ToLower(task.Task), "buy") {
+			t.Errorf("getTasks() with filter returned [%v]", task)
+		}
+	}
+
+	// ============================================ Invalid ============================================
+	response = getFiltered(`priority>=3 AND secret="buy"`)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, `Invalid filter at position 17: unknown field "secret"`)
+
+	response = getFiltered(`priority>=`)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, `Invalid filter at position 11`)
+}
+
+func Test_todo_sortTasks(t *testing.T) {
+	getSorted := func(sort string) (*httptest.ResponseRecorder, Tasks) {
+		request, err := http.NewRequest("GET", "http://localhost:8008/tasks/?sort="+url.QueryEscape(sort), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		getTasks(response, request, 1)
+
+		var tasks Tasks
+		json.Unmarshal([]byte(response.Body.String()), &tasks)
+		return response, tasks
+	}
+
+	// ============================================ SQL ============================================
+	response, tasks := getSorted("-priority,created")
+	_checkResponseCode(t, response, 200)
+	if len(tasks) < 2 {
+		t.Errorf("getTasks() sorted returned [%v]", tasks)
+	}
+	for i := 1; i < len(tasks); i++ {
+		a, b := tasks[i-1], tasks[i]
+		if a.Priority < b.Priority || (a.Priority == b.Priority && (a.Created > b.Created || (a.Created == b.Created && a.Id > b.Id))) {
+			t.Errorf("getTasks() sorted by -priority,created is not as expected: [%v]", tasks)
+			break
+		}
+	}
+
+	// ============================================ Go ============================================
+	response, tasks = getSorted("priority,-text")
+	_checkResponseCode(t, response, 200)
+	for i := 1; i < len(tasks); i++ {
+		a, b := tasks[i-1], tasks[i]
+		if a.Priority > b.Priority || (a.Priority == b.Priority && strings.ToLower(a.Task) < strings.ToLower(b.Task)) {
+			t.Errorf("getTasks() sorted by priority,-text is not as expected: [%v]", tasks)
+			break
+		}
+	}
+
+	// ============================================ Invalid ============================================
+	response, _ = getSorted("-priority,secret")
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid sort")
+}
+
+func Test_todo_pageTasks(t *testing.T) {
+	texts := []string{"Paging: delta", "Paging: alpha", "Paging: echo", "Paging: charlie", "Paging: bravo"}
+	tasks := Tasks{}
+	for i, text := range texts {
+		tasks = append(tasks, Task{Id: -1, AccountId: 1, Created: 1704067100, LastUpdated: 1704067100, Priority: Priority(1 + i%2), Task: text, Status: "Open", Version: 1})
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// follows the next links from the first page and returns the ids of all pages
+	pageThrough := func(sort string) ([][]int, string) {
+		link := "/tasks/?filter=" + url.QueryEscape(`text~"paging:"`) + "&sort=" + sort + "&limit=2&total=true"
+		pages := [][]int{}
+		total := ""
+		for link != "" && len(pages) < 10 {
+			request, err := http.NewRequest("GET", "http://localhost:8008"+link, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			response := httptest.NewRecorder()
+
+			getTasks(response, request, 1)
+			_checkResponseCode(t, response, 200)
+
+			var ts Tasks
+			json.Unmarshal([]byte(response.Body.String()), &ts)
+			ids := []int{}
+			for _, task := range ts {
+				ids = append(ids, task.Id)
+			}
+			pages = append(pages, ids)
+			total = response.Header().Get("X-Total-Count")
+			link = _getLink(response, "next")
+			if len(pages) > 1 && _getLink(response, "prev") == "" {
+				t.Errorf("getTasks() page [%v] has no previous page", len(pages))
+			}
+		}
+		return pages, total
+	}
+
+	// ============================================ SQL ============================================
+	pages, total := pageThrough("-priority")
+	expected := [][]int{{tasks[1].Id, tasks[3].Id}, {tasks[0].Id, tasks[2].Id}, {tasks[4].Id}}
+	if fmt.Sprint(pages) != fmt.Sprint(expected) || total != "5" {
+		t.Errorf("getTasks() pages are [%v] of [%v], instead of [%v]", pages, total, expected)
+	}
+
+	// ============================================ Go ============================================
+	pages, total = pageThrough("text")
+	expected = [][]int{{tasks[1].Id, tasks[4].Id}, {tasks[3].Id, tasks[0].Id}, {tasks[2].Id}}
+	if fmt.Sprint(pages) != fmt.Sprint(expected) || total != "5" {
+		t.Errorf("getTasks() pages are [%v] of [%v], instead of [%v]", pages, total, expected)
+	}
+
+	// ============================================ Invalid ============================================
+	for _, query := range []string{"limit=0", "limit=many", "cursor=abc.def", "sort=text&cursor=" + EncodeCursor(&Cursor{"tasks", "-priority", []interface{}{1}, 1, false})} {
+		request, err := http.NewRequest("GET", "http://localhost:8008/tasks/?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		getTasks(response, request, 1)
+		_checkResponseCode(t, response, 400)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_todo_views(t *testing.T) {
+	tasks := Tasks{
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 5, Task: "View: call mum", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 2, Task: "View: water plants", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 4, Task: "View: pay rent", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704067100, LastUpdated: 1704067100, Priority: 4, Task: "View: file taxes", Status: "Done", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(method string, path string, form url.Values, handler func(http.ResponseWriter, *http.Request, int), accountId int) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(method, "http://localhost:8008"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.PostForm = form
+		response := httptest.NewRecorder()
+
+		handler(response, request, accountId)
+		return response
+	}
+	getViewIds := func(accountId int) map[string]int {
+		response := send("GET", "/views/", nil, getViews, accountId)
+		_checkResponseCode(t, response, 200)
+		var views Views
+		json.Unmarshal([]byte(response.Body.String()), &views)
+		ids := map[string]int{}
+		for _, v := range views {
+			ids[v.Name] = v.Id
+		}
+		return ids
+	}
+	getTaskIds := func(path string, accountId int) []int {
+		response := send("GET", path, nil, getViewTasks, accountId)
+		_checkResponseCode(t, response, 200)
+		var ts Tasks
+		json.Unmarshal([]byte(response.Body.String()), &ts)
+		ids := []int{}
+		for _, task := range ts {
+			ids = append(ids, task.Id)
+		}
+		return ids
+	}
+
+	// ============================================ Add ============================================
+	response := send("POST", "/view/", url.Values{"Name": {"Important"}, "Filter": {`text~"view:" AND priority>=high`}, "Sort": {"-priority,text"}}, addView, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Add\": \"Success\"}")
+
+	response = send("POST", "/view/", url.Values{"Name": {"Broken"}, "Filter": {"priority>>3"}}, addView, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid filter at position")
+
+	// only an "Admin" can publish shared views
+	response = send("POST", "/view/", url.Values{"Name": {"Open"}, "Filter": {"status=Open"}, "Shared": {"true"}}, addView, 2)
+	_checkResponseCode(t, response, 401)
+	response = send("POST", "/view/", url.Values{"Name": {"Open"}, "Filter": {"status=Open"}, "Shared": {"true"}}, addView, 1)
+	_checkResponseCode(t, response, 200)
+
+	ids := getViewIds(2)
+	if len(ids) != 2 || ids["Important"] == 0 || ids["Open"] == 0 {
+		t.Fatalf("getViews() returned [%v]", ids)
+	}
+	important, open := strconv.Itoa(ids["Important"]), strconv.Itoa(ids["Open"])
+	if ids = getViewIds(3); len(ids) != 1 || ids["Open"] == 0 {
+		t.Errorf("getViews() of another account returned [%v]", ids)
+	}
+
+	// ============================================ Tasks ============================================
+	expected := []int{tasks[0].Id, tasks[3].Id, tasks[2].Id}
+	if taskIds := getTaskIds("/views/"+important+"/tasks", 2); fmt.Sprint(taskIds) != fmt.Sprint(expected) {
+		t.Errorf("getViewTasks() returned [%v], instead of [%v]", taskIds, expected)
+	}
+	expected = []int{tasks[0].Id, tasks[2].Id}
+	if taskIds := getTaskIds("/views/"+important+"/tasks?filter=status%3DOpen", 2); fmt.Sprint(taskIds) != fmt.Sprint(expected) {
+		t.Errorf("getViewTasks() with another filter returned [%v], instead of [%v]", taskIds, expected)
+	}
+	// a filter that is not valid on its own cannot escape from the filter of the view
+	response = send("GET", "/views/"+important+"/tasks?filter="+url.QueryEscape("id>0) OR (id>0"), nil, getViewTasks, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid filter at position 5")
+	// shared views show the tasks of the account using them
+	for _, id := range getTaskIds("/view/"+open+"/tasks", 3) {
+		if id == tasks[0].Id {
+			t.Errorf("getViewTasks() of a shared view returned tasks of another account")
+		}
+	}
+
+	response = send("GET", "/views/"+important+"/tasks", nil, getViewTasks, 3) // Use AccountId 3, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	response = send("GET", "/views/999/tasks", nil, getViewTasks, 2)
+	_checkResponseCode(t, response, 404)
+
+	// ============================================ Edit ============================================
+	response = send("PUT", "/view/"+important, url.Values{"Id": {important}, "Name": {"Important"}, "Filter": {`text~"view:" AND priority>=urgent`}}, editView, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
+	expected = []int{tasks[0].Id}
+	if taskIds := getTaskIds("/views/"+important+"/tasks", 2); fmt.Sprint(taskIds) != fmt.Sprint(expected) {
+		t.Errorf("getViewTasks() after editing returned [%v], instead of [%v]", taskIds, expected)
+	}
+
+	response = send("PUT", "/view/"+open, url.Values{"Id": {open}, "Name": {"Mine now"}}, editView, 2)
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	// ============================================ Delete ============================================
+	response = send("DELETE", "/view/"+open, nil, deleteView, 2)
+	_checkResponseCode(t, response, 401)
+	response = send("DELETE", "/view/"+open, nil, deleteView, 1)
+	_checkResponseCode(t, response, 200)
+	response = send("DELETE", "/view/"+important, nil, deleteView, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+	if ids := getViewIds(2); len(ids) != 0 {
+		t.Errorf("getViews() after deleting returned [%v]", ids)
+	}
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_todo_getAllTasks(t *testing.T) {
+	tasks := Tasks{
+		{Id: -1, AccountId: 1, Created: 1704067100, LastUpdated: 1704067100, Priority: 5, Task: "All: audit logs", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1704153600, LastUpdated: 1704153600, Priority: 2, Task: "All: spam", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 3, Created: 1704153600, LastUpdated: 1704153600, Priority: 4, Task: "All: report", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 3, Created: 1704240000, LastUpdated: 1704240000, Priority: 1, Task: "All: more spam", Status: "Open", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
+		t.Fatal(err)
+	}
+	get := func(path string, handler func(http.ResponseWriter, *http.Request, int), accountId int) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("GET", "http://localhost:8008"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		handler(response, request, accountId)
+		return response
+	}
+	text := "filter=" + url.QueryEscape(`text~"all:"`)
+
+	// ============================================ Filtered ============================================
+	for query, expected := range map[string][]int{
+		"":                                   {tasks[0].Id, tasks[2].Id, tasks[1].Id, tasks[3].Id},
+		"account=2,3":                        {tasks[2].Id, tasks[1].Id, tasks[3].Id},
+		"minPriority=minor&maxPriority=4":    {tasks[2].Id, tasks[1].Id},
+		"from=2024-01-02&to=2024-01-03":      {tasks[2].Id, tasks[1].Id},
+		"account=3&from=2024-01-02":          {tasks[2].Id, tasks[3].Id},
+		"sort=-text&account=3&minPriority=1": {tasks[2].Id, tasks[3].Id},
+	} {
+		response := get("/tasks/all?"+text+"&"+query, getAllTasks, 1)
+		_checkResponseCode(t, response, 200)
+		var ts Tasks
+		json.Unmarshal([]byte(response.Body.String()), &ts)
+		ids := []int{}
+		for _, task := range ts {
+			ids = append(ids, task.Id)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(expected) {
+			t.Errorf("getAllTasks() with [%v] returned [%v], instead of [%v]", query, ids, expected)
+		}
+	}
+
+	// ============================================ Paged ============================================
+	response := get("/tasks/all?"+text+"&limit=3&total=true", getAllTasks, 1)
+	_checkResponseCode(t, response, 200)
+	var page Tasks
+	json.Unmarshal([]byte(response.Body.String()), &page)
+	if len(page) != 3 || response.Header().Get("X-Total-Count") != "4" || _getLink(response, "next") == "" {
+		t.Errorf("getAllTasks() first page is [%v] of [%v]", page, response.Header().Get("X-Total-Count"))
+	}
+	response = get(_getLink(response, "next"), getAllTasks, 1)
+	_checkResponseCode(t, response, 200)
+	page = Tasks{}
+	json.Unmarshal([]byte(response.Body.String()), &page)
+	if len(page) != 1 || page[0].Id != tasks[3].Id || _getLink(response, "prev") == "" {
+		t.Errorf("getAllTasks() last page is [%v]", page)
+	}
+
+	// ============================================ Counts ============================================
+	response = get("/tasks/all/counts?"+text+"&minPriority=2", getAllTaskCounts, 1)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, `[{"AccountId":1,"Name":"JamesClonk","Count":1},{"AccountId":2,"Name":"Clude","Count":1},{"AccountId":3,"Name":"ozzie","Count":1}]`)
+
+	// ============================================ Invalid ============================================
+	for _, query := range []string{"account=one", "minPriority=extreme", "from=yesterday", "filter=priority%3E", "limit=-1"} {
+		response = get("/tasks/all?"+query, getAllTasks, 1)
+		_checkResponseCode(t, response, 400)
+	}
+
+	// ============================================ Unauthorized ============================================
+	response = get("/tasks/all", getAllTasks, 2) // Use AccountId 2, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+	response = get("/tasks/all/counts", getAllTaskCounts, 2)
+	_checkResponseCode(t, response, 401)
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_todo_exportTasks(t *testing.T) {
+	export := func(query string, accept string, accountId int) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("GET", "http://localhost:8008/tasks/export"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Accept", accept)
+		response := httptest.NewRecorder()
+
+		getTaskExport(response, request, accountId)
+		return response
+	}
+
+	// ============================================ CSV ============================================
+	response := export("?format=csv", "application/json", 2)
+	_checkResponseCode(t, response, 200)
+	if response.Header().Get("Content-Type") != "text/csv; charset=utf-8" || !strings.Contains(response.Header().Get("Content-Disposition"), "tasks.csv") {
+		t.Errorf("getTaskExport() headers are [%v]", response.Header())
+	}
+	records, err := csv.NewReader(strings.NewReader(response.Body.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) < 2 || strings.Join(records[0], ",") != "Id,AccountId,Created,LastUpdated,Priority,Task,ProjectId,AssigneeId,Status,Rank" {
+		t.Errorf("getTaskExport() as CSV returned [%v]", records)
+	}
+	for _, record := range records[1:] {
+		if record[1] != "2" {
+			t.Errorf("getTaskExport() exported a task of another account: [%v]", record)
+		}
+	}
+
+	// ============================================ Markdown ============================================
+	response = export("", "text/markdown", 2)
+	_checkResponseCode(t, response, 200)
+	if response.Header().Get("Content-Type") != "text/markdown; charset=utf-8" || !strings.HasPrefix(response.Body.String(), "- [") {
+		t.Errorf("getTaskExport() as Markdown returned [%v]", response.Body.String())
+	}
+
+	// ============================================ JSON ============================================
+	response = export("?all=true", "", 1)
+	_checkResponseCode(t, response, 200)
+	var tasks Tasks
+	if err := json.Unmarshal([]byte(response.Body.String()), &tasks); err != nil {
+		t.Fatal(err)
+	}
+	all, err := GetAllTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != len(*all) {
+		t.Errorf("getTaskExport() of all tasks returned [%v] tasks, instead of [%v]", len(tasks), len(*all))
+	}
+
+	// the command line export writes the same
+	var buf bytes.Buffer
+	if err := exportTasks(&buf, "json", 0); err != nil {
+		t.Error(err)
+	}
+	if buf.String() != response.Body.String() {
+		t.Errorf("exportTasks() returned [%v], instead of [%v]", buf.String(), response.Body.String())
+	}
+
+	// ============================================ Invalid ============================================
+	response = export("?format=xml", "", 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid format")
+	response = export("", "image/png", 2)
+	_checkResponseCode(t, response, 406)
+	response = export("?all=true", "", 2) // Use AccountId 2, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+}
+
+func Test_todo_importTasks(t *testing.T) {
+	project := Project{-1, 1, "Not yours", "", "ASC", 0, 0}
+	if err := project.Save(); err != nil {
+		t.Fatal(err)
+	}
+	send := func(query string, contentType string, body string) (*httptest.ResponseRecorder, ImportReport) {
+		request, err := http.NewRequest("POST", "http://localhost:8008/tasks/import"+query, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Header.Set("Content-Type", contentType)
+		response := httptest.NewRecorder()
+
+		postTaskImport(response, request, 3)
+
+		var report ImportReport
+		json.Unmarshal([]byte(response.Body.String()), &report)
+		return response, report
+	}
+	countTasks := func() int {
+		tasks, err := GetTasksByAccountId(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(*tasks)
+	}
+	before := countTasks()
+	columns := "?columns=" + url.QueryEscape("Ref:ExternalId,Title:Task,Prio:Priority,Project:ProjectId")
+	data := "Ref,Title,Prio,Project\n" +
+		"r-1,Import: buy milk,high,\n" +
+		"r-2,Import: pay rent,5,0\n" +
+		"r-3,Import: not mine,3," + strconv.Itoa(project.Id) + "\n"
+
+	// ============================================ Dry Run ============================================
+	response, report := send(columns+"&dryRun=true", "text/csv", data)
+	_checkResponseCode(t, response, 200)
+	if !report.DryRun || report.Imported || report.Created != 2 || report.Failed != 1 || len(report.Results) != 3 {
+		t.Errorf("postTaskImport() dry run reported [%v]", report)
+	}
+	if r := report.Results[0]; r.Action != ActionCreate || r.ExternalId != "r-1" || r.Task == nil || r.Task.Task != "Import: buy milk" || r.Task.Priority != 4 {
+		t.Errorf("postTaskImport() dry run previewed [%v]", r)
+	}
+	if r := report.Results[2]; r.Row != 3 || r.Action != "" || r.Error != "Invalid project" {
+		t.Errorf("postTaskImport() dry run reported [%v] for an invalid row", r)
+	}
+	if countTasks() != before {
+		t.Error("postTaskImport() dry run imported tasks")
+	}
+
+	// ============================================ Failed ============================================
+	response, report = send(columns, "text/csv", data)
+	_checkResponseCode(t, response, 400)
+	if report.Imported || report.Failed != 1 || countTasks() != before {
+		t.Errorf("postTaskImport() with a failed row reported [%v]", report)
+	}
+
+	// ============================================ Import ============================================
+	response, report = send(columns+"&continueOnError=true", "text/csv", data)
+	_checkResponseCode(t, response, 200)
+	if !report.Imported || report.Created != 2 || report.Failed != 1 || countTasks() != before+2 {
+		t.Errorf("postTaskImport() reported [%v]", report)
+	}
+	id := report.Results[1].Task.Id
+
+	// importing again updates the tasks with the same external ids
+	data = "Ref,Title,Prio\nr-2,Import: pay the rent,low\nr-4,Import: water plants,2\n"
+	response, report = send(columns, "text/csv; charset=utf-8", data)
+	_checkResponseCode(t, response, 200)
+	if !report.Imported || report.Created != 1 || report.Updated != 1 || countTasks() != before+3 {
+		t.Errorf("postTaskImport() again reported [%v]", report)
+	}
+	task, err := GetTaskById(id)
+	if err != nil || task.Task != "Import: pay the rent" || task.Priority != 1 || task.Version != 2 {
+		t.Errorf("postTaskImport() did not update the task: [%v], [%v]", task, err)
+	}
+
+	// ============================================ JSON ============================================
+	response, report = send("", "application/json", `[{"ExternalId": "r-4", "Task": "Import: water all plants", "Status": "Done"}, {"Task": ""}]`)
+	_checkResponseCode(t, response, 400)
+	response, report = send("?format=json&continueOnError=true", "", `[{"ExternalId": "r-4", "Task": "Import: water all plants", "Status": "Done"}, {"Task": ""}]`)
+	_checkResponseCode(t, response, 200)
+	if report.Updated != 1 || report.Failed != 1 || report.Results[0].Task.Status != "Done" || report.Results[1].Error != "Invalid task" {
+		t.Errorf("postTaskImport() of JSON reported [%v]", report)
+	}
+
+	// ============================================ Command Line ============================================
+	file, err := os.CreateTemp("", "import-*.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("ExternalId,Task\nr-1,Import: buy oat milk\nr-5,Import: call mum\n")
+	file.Close()
+
+	fileReport, err := importFile(file.Name(), 3, "", true)
+	if err != nil || !fileReport.DryRun || fileReport.Updated != 1 || fileReport.Created != 1 || countTasks() != before+3 {
+		t.Errorf("importFile() dry run reported [%v], [%v]", fileReport, err)
+	}
+	fileReport, err = importFile(file.Name(), 3, "", false)
+	if err != nil || !fileReport.Imported || countTasks() != before+4 {
+		t.Errorf("importFile() reported [%v], [%v]", fileReport, err)
+	}
+	if _, err := importFile(file.Name(), 99, "", false); err == nil {
+		t.Error("importFile() into an unknown account should fail")
+	}
+
+	// ============================================ Invalid ============================================
+	response, _ = send("", "text/plain", data)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid format")
+	response, _ = send("?columns=Title:Secret", "text/csv", data)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid columns")
+	response, _ = send("?dryRun=maybe", "text/csv", data)
+	_checkResponseCode(t, response, 400)
+
+	imported, err := GetImportedTasks(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks := Tasks{}
+	for _, task := range imported {
+		tasks = append(tasks, task)
+	}
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := project.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_todo_todoTxt(t *testing.T) {
+	project := Project{-1, 3, "Todo Family", "", "ASC", 0, 0}
+	if err := project.Save(); err != nil {
+		t.Fatal(err)
+	}
+	send := func(method string, query string, body string) (*httptest.ResponseRecorder, ImportReport) {
+		request, err := http.NewRequest(method, "http://localhost:8008/tasks/todotxt"+query, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		if method == "GET" {
+			getTodoTxt(response, request, 3)
+		} else {
+			postTodoTxt(response, request, 3)
+		}
+
+		var report ImportReport
+		json.Unmarshal([]byte(response.Body.String()), &report)
+		return response, report
+	}
+	countTasks := func() int {
+		tasks, err := GetTasksByAccountId(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(*tasks)
+	}
+	before := countTasks()
+	data := "(A) 2024-01-02 Todo: call mum +Todo_Family @phone\n\n" +
+		"x 2024-01-05 2024-01-01 Todo: pay rent pri:B\n"
+
+	// ============================================ Import ============================================
+	response, report := send("POST", "?dryRun=true", data)
+	_checkResponseCode(t, response, 200)
+	if !report.DryRun || report.Imported || report.Created != 2 || countTasks() != before {
+		t.Errorf("postTodoTxt() dry run reported [%v]", report)
+	}
+	if r := report.Results[1]; r.Row != 3 || r.Task.Status != "Done" || r.Task.Priority != 4 || r.Task.LastUpdated != 1704412800 {
+		t.Errorf("postTodoTxt() dry run previewed [%v]", r)
+	}
+
+	response, report = send("POST", "", data)
+	_checkResponseCode(t, response, 200)
+	if !report.Imported || report.Created != 2 || countTasks() != before+2 {
+		t.Errorf("postTodoTxt() reported [%v]", report)
+	}
+	mum, rent := report.Results[0].Task.Id, report.Results[1].Task.Id
+	task, err := GetTaskById(mum)
+	if err != nil || task.Priority != 5 || task.Created != 1704153600 || task.ProjectId != project.Id {
+		t.Errorf("postTodoTxt() imported [%v], [%v]", task, err)
+	}
+	if tags, err := GetTagsByTaskId(mum); err != nil || len(*tags) != 1 || (*tags)[0].Name != "phone" {
+		t.Errorf("postTodoTxt() tagged the task with [%v], [%v]", tags, err)
+	}
+
+	// ============================================ Export ============================================
+	response, _ = send("GET", "", "")
+	_checkResponseCode(t, response, 200)
+	for _, line := range []string{
+		"(A) 2024-01-02 Todo: call mum +Todo_Family @phone id:" + strconv.Itoa(mum) + "\n",
+		"x 2024-01-05 2024-01-01 Todo: pay rent pri:B id:" + strconv.Itoa(rent) + "\n",
+	} {
+		if !strings.Contains(response.Body.String(), line) {
+			t.Errorf("getTodoTxt() returned [%v], without [%v]", response.Body.String(), line)
+		}
+	}
+	if response.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("getTodoTxt() returned Content-Type [%v]", response.Header().Get("Content-Type"))
+	}
+
+	// importing a line with the id of a task updates it, its tags are replaced
+	response, report = send("POST", "", "(B) Todo: call dad @mobile id:"+strconv.Itoa(mum))
+	_checkResponseCode(t, response, 200)
+	task, err = GetTaskById(mum)
+	if report.Updated != 1 || err != nil || task.Task != "Todo: call dad" || task.Priority != 4 || task.ProjectId != 0 || task.Created != 1704153600 {
+		t.Errorf("postTodoTxt() updated [%v], [%v], reported [%v]", task, err, report)
+	}
+	if tags, err := GetTagsByTaskId(mum); err != nil || len(*tags) != 1 || (*tags)[0].Name != "mobile" {
+		t.Errorf("postTodoTxt() tagged the task with [%v], [%v]", tags, err)
+	}
+
+	response, report = send("POST", "", "x 2024-01-05\nTodo: valid\n")
+	_checkResponseCode(t, response, 400)
+	if report.Imported || report.Failed != 1 || report.Results[0].Error != "Invalid task" || countTasks() != before+2 {
+		t.Errorf("postTodoTxt() with a failed line reported [%v]", report)
+	}
+	response, _ = send("POST", "?dryRun=maybe", data)
+	_checkResponseCode(t, response, 400)
+
+	// ============================================ Sync ============================================
+	dir, err := os.MkdirTemp("", "todotxt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := dir + "/todo.txt"
+	os.WriteFile(filename, []byte("Todo: water plants\n"), 0644)
+	readFile := func() string {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	// findLine returns the line of the task in the file
+	findLine := func(id int) string {
+		for _, line := range strings.Split(readFile(), "\n") {
+			if strings.HasSuffix(line, " id:"+strconv.Itoa(id)) {
+				return line
+			}
+		}
+		return ""
+	}
+	editFile := func(old string, new string) {
+		os.WriteFile(filename, []byte(strings.Replace(readFile(), old, new, 1)), 0644)
+	}
+
+	syncReport, err := syncTodoTxt(filename, 3)
+	if err != nil || syncReport.Created != 1 || syncReport.Written != before+2 || len(syncReport.Conflicts) != 0 || countTasks() != before+3 {
+		t.Fatalf("syncTodoTxt() reported [%v], [%v]", syncReport, err)
+	}
+	lines := strings.Split(strings.TrimSpace(readFile()), "\n")
+	plants := ParseTodoTxtLine(lines[0]).Id()
+	if len(lines) != before+3 || plants == 0 || !strings.HasSuffix(lines[0], " Todo: water plants id:"+strconv.Itoa(plants)) {
+		t.Errorf("syncTodoTxt() wrote [%v]", lines)
+	}
+
+	synced := readFile()
+	syncReport, err = syncTodoTxt(filename, 3)
+	if err != nil || syncReport.Created+syncReport.Updated+syncReport.Deleted+syncReport.Written+syncReport.Removed != 0 || readFile() != synced {
+		t.Errorf("syncTodoTxt() without changes reported [%v], [%v]", syncReport, err)
+	}
+
+	// changes on either side are taken over by the other side
+	editFile(" Todo: water plants ", " Todo: water all plants ")
+	task, _ = GetTaskById(mum)
+	task.Task = "Todo: call dad now"
+	task.Save()
+	syncReport, err = syncTodoTxt(filename, 3)
+	if err != nil || syncReport.Updated != 1 || syncReport.Written != 1 || len(syncReport.Conflicts) != 0 {
+		t.Errorf("syncTodoTxt() with changes reported [%v], [%v]", syncReport, err)
+	}
+	if task, err := GetTaskById(plants); err != nil || task.Task != "Todo: water all plants" {
+		t.Errorf("syncTodoTxt() did not update the task: [%v], [%v]", task, err)
+	}
+	if line := findLine(mum); !strings.Contains(line, " Todo: call dad now @mobile ") {
+		t.Errorf("syncTodoTxt() did not update the line: [%v]", line)
+	}
+
+	// changes on both sides are conflicts, until both sides are the same again
+	editFile(" Todo: call dad now ", " Todo: call dad today ")
+	task, _ = GetTaskById(mum)
+	task.Task = "Todo: call dad tomorrow"
+	task.Save()
+	for i := 0; i < 2; i++ {
+		syncReport, err = syncTodoTxt(filename, 3)
+		if err != nil || len(syncReport.Conflicts) != 1 || syncReport.Conflicts[0].Id != mum || syncReport.Conflicts[0].Reason != "Changed in the file and in the account" {
+			t.Errorf("syncTodoTxt() with a conflict reported [%v], [%v]", syncReport, err)
+		}
+		if task, _ := GetTaskById(mum); task.Task != "Todo: call dad tomorrow" || !strings.Contains(findLine(mum), " Todo: call dad today ") {
+			t.Errorf("syncTodoTxt() changed a side of the conflict: [%v], [%v]", task, findLine(mum))
+		}
+	}
+	editFile(findLine(mum), syncReport.Conflicts[0].Account)
+	if syncReport, err = syncTodoTxt(filename, 3); err != nil || len(syncReport.Conflicts) != 0 {
+		t.Errorf("syncTodoTxt() after the conflict reported [%v], [%v]", syncReport, err)
+	}
+
+	// deleting on either side deletes on the other side
+	editFile(findLine(plants)+"\n", "")
+	task, _ = GetTaskById(rent)
+	task.Trash()
+	syncReport, err = syncTodoTxt(filename, 3)
+	if err != nil || syncReport.Deleted != 1 || syncReport.Removed != 1 || findLine(rent) != "" {
+		t.Errorf("syncTodoTxt() with deletions reported [%v], [%v]", syncReport, err)
+	}
+	if task, err := GetTrashedTaskById(plants); err != nil || !task.IsDeleted() {
+		t.Errorf("syncTodoTxt() did not trash the task: [%v], [%v]", task, err)
+	}
+
+	// the letters follow the configured priority scale, not the default one
+	defaultScale := GetPriorityScale()
+	if err := applyConfig(&Config{Priorities: PriorityScale{1, 3, nil}}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filename, []byte(readFile()+"(A) Todo: scaled\n"), 0644)
+	syncReport, err = syncTodoTxt(filename, 3)
+	SetPriorityScale(defaultScale)
+	scaled := 0
+	for _, line := range strings.Split(readFile(), "\n") {
+		if strings.HasPrefix(line, "(A) ") && strings.Contains(line, " Todo: scaled id:") {
+			scaled = ParseTodoTxtLine(line).Id()
+		}
+	}
+	if task, terr := GetTaskById(scaled); err != nil || syncReport.Created != 1 || terr != nil || task.Priority != 3 {
+		t.Errorf("syncTodoTxt() with a configured priority scale created [%v], [%v], reported [%v], [%v]", task, terr, syncReport, err)
+	}
+
+	if _, err := syncTodoTxt(filename, 99); err == nil {
+		t.Error("syncTodoTxt() with an unknown account should fail")
+	}
+
+	tasks := Tasks{}
+	for _, id := range []int{mum, rent, plants, scaled} {
+		task, err := GetTaskById(id)
+		if err != nil {
+			task, err = GetTrashedTaskById(id)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks = append(tasks, *task)
+	}
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
+	for _, name := range []string{"phone", "mobile"} {
+		if tag, err := GetTagByName(3, name); err != nil || tag.Delete() != nil {
+			t.Errorf("Could not delete tag [%v]: [%v]", name, err)
+		}
+	}
+	if err := project.Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_todo_feed(t *testing.T) {
+	task := Task{Id: -1, AccountId: 3, Created: 1704067200, LastUpdated: 1704067200, Priority: 4, Task: "Feed: water plants", Status: "Open"}
+	if err := task.Save(); err != nil {
+		t.Fatal(err)
+	}
+	send := func(method string, handler func(w http.ResponseWriter, r *http.Request, accountId int)) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(method, "http://localhost:8008/tasks/feed", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+		handler(response, request, 3)
+		return response
+	}
+	feed := func(method string, path string) *httptest.ResponseRecorder {
+		request, err := http.NewRequest(method, "http://localhost:8008"+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+		getFeed(response, request)
+		return response
+	}
+
+	response := send("GET", getFeedToken)
+	_checkResponseCode(t, response, 404)
+
+	// ============================================ Token ============================================
+	response = send("POST", addFeedToken)
+	_checkResponseCode(t, response, 200)
+	var token struct {
+		Token   string
+		Path    string
+		Created int
+	}
+	if err := json.Unmarshal([]byte(response.Body.String()), &token); err != nil || token.Token == "" || token.Path != "/feed/"+token.Token+".ics" {
+		t.Fatalf("addFeedToken() returned [%v], [%v]", response.Body.String(), err)
+	}
+	response = send("GET", getFeedToken)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Created\": "+strconv.Itoa(token.Created)+"}")
+
+	// ============================================ Feed ============================================
+	response = feed("GET", token.Path)
+	_checkResponseCode(t, response, 200)
+	if response.Header().Get("Content-Type") != "text/calendar; charset=utf-8" {
+		t.Errorf("getFeed() returned Content-Type [%v]", response.Header().Get("Content-Type"))
+	}
+	body := response.Body.String()
+	vtodo := "BEGIN:VTODO\r\nUID:task-" + strconv.Itoa(task.Id) + "@go-todo\r\nDTSTAMP:20240101T000000Z\r\nCREATED:20240101T000000Z\r\n" +
+		"LAST-MODIFIED:20240101T000000Z\r\nSEQUENCE:1\r\nSUMMARY:Feed: water plants\r\nPRIORITY:3\r\nSTATUS:NEEDS-ACTION\r\nEND:VTODO\r\n"
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(body, "END:VCALENDAR\r\n") || !strings.Contains(body, vtodo) {
+		t.Errorf("getFeed() returned [%v], without [%v]", body, vtodo)
+	}
+
+	_checkResponseCode(t, feed("GET", "/feed/unknown.ics"), 404)
+	_checkResponseCode(t, feed("GET", "/feed/"+token.Token), 404)
+	_checkResponseCode(t, feed("POST", token.Path), 405)
+
+	// accounts that may not sign in do not get their feed either
+	account := Account{Id: -1, Name: "Feedless", Email: "feedless@nowhere", Password: "abcd", Salt: "999", Role: "None", LastAuth: 1234567895, Version: 1}
+	if err := account.Save(); err != nil {
+		t.Fatal(err)
+	}
+	none, secret, err := NewFeedToken(account.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := none.Save(); err != nil {
+		t.Fatal(err)
+	}
+	_checkResponseCode(t, feed("GET", "/feed/"+secret+".ics"), 403)
+	if err := none.Delete(); err != nil {
+		t.Error(err)
+	}
+	if err := account.Delete(); err != nil {
+		t.Error(err)
+	}
+
+	// a new token replaces the previous one
+	response = send("POST", addFeedToken)
+	_checkResponseCode(t, response, 200)
+	old := token.Path
+	json.Unmarshal([]byte(response.Body.String()), &token)
+	_checkResponseCode(t, feed("GET", old), 404)
+	_checkResponseCode(t, feed("GET", token.Path), 200)
+
+	response = send("DELETE", deleteFeedToken)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+	_checkResponseCode(t, feed("GET", token.Path), 404)
+
+	// ============================================ Export ============================================
+	request, err := http.NewRequest("GET", "http://localhost:8008/tasks/export", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Header.Set("Accept", "text/calendar")
+	response = httptest.NewRecorder()
+	getTaskExport(response, request, 3)
+	_checkResponseCode(t, response, 200)
+	if response.Header().Get("Content-Disposition") != "attachment; filename=\"tasks.ics\"" || !strings.Contains(response.Body.String(), vtodo) {
+		t.Errorf("getTaskExport() of iCalendar returned [%v], [%v]", response.Header(), response.Body.String())
+	}
+
+	if err := (Tasks{task}).Delete(); err != nil {
+		t.Error(err)
+	}
+}
+
+func Test_todo_searchTasks(t *testing.T) {
+	search := func(query string, accountId int) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("GET", "http://localhost:8008/tasks/search?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		response := httptest.NewRecorder()
+
+		searchTasks(response, request, accountId)
+		return response
+	}
+
+	_checkResponseCode(t, search("q=", 2), 400)
+	_checkResponseCode(t, search("q=sleep&limit=none", 2), 400)
+
+	if !searchAvailable {
+		_checkResponseCode(t, search("q=sleep", 2), 501)
+		t.Skip("SQLite was built without FTS5")
+	}
+
+	// ============================================ Valid ============================================
+	share := &Share{-1, 7, 0, 3, "View"} // task 7 of AccountId 2 is shared with AccountId 3
+	if err := share.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	defer share.Delete()
+
+	response := search("q="+url.QueryEscape("sleep*"), 3)
+	_checkResponseCode(t, response, 200)
+
+	var results SearchResults
+	if err := json.Unmarshal([]byte(response.Body.String()), &results); err != nil {
+		t.Error(err)
+		return
+	}
+	shared := false
+	for _, r := range results {
+		if r.Task.AccountId == 1 || !strings.Contains(r.Snippet, "<mark>sleep") {
+			t.Errorf("searchTasks() returned an unexpected result: [%v]", r)
+		}
+		shared = shared || r.Task.Id == 7
+	}
+	if !shared {
+		t.Errorf("searchTasks() should return shared tasks: [%v]", response.Body.String())
+	}
+
+	response = search("q="+url.QueryEscape("sleep*")+"&limit=1", 3)
+	_checkResponseCode(t, response, 200)
+	if err := json.Unmarshal([]byte(response.Body.String()), &results); err != nil || len(results) != 1 {
+		t.Errorf("searchTasks() with limit returned [%v]", response.Body.String())
+	}
+
+	// ============================================ Invalid ============================================
+	response = search("q="+url.QueryEscape("sleep AND"), 3)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid search query")
+}
+
+func Test_todo_getPriorities(t *testing.T) {
+	request, err := http.NewRequest("GET", "http://localhost:8008/priorities", nil)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 	response := httptest.NewRecorder()
 
-	deleteTask(response, request, 2) // Use AccountId 2
+	getPriorities(response, request, 2)
 	_checkResponseCode(t, response, 200)
-	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
-
-	task, err := GetTaskById(3)
-	if task != nil {
-		t.Errorf("GetTaskById() after deleteTask() still returned a task object, when it should not! Got [%v]", task)
-		if err != nil {
-			t.Error(err)
-		}
-	}
 
-	// ============================================ Invalid ============================================
-	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/öäü", nil)
-	if err != nil {
+	var scale PriorityScale
+	if err := json.Unmarshal([]byte(response.Body.String()), &scale); err != nil {
 		t.Error(err)
 		return
 	}
-	response = httptest.NewRecorder()
-
-	deleteTask(response, request, 1)
-	_checkResponseCode(t, response, 404)
-	_checkResponseBody(t, response, "404 page not found")
+	if scale.Min != 1 || scale.Max != 5 || len(scale.Levels) != 5 || scale.Levels[0].Name != "low" {
+		t.Errorf("getPriorities() returned [%v]", scale)
+	}
+}
 
-	// ============================================ Unauthorized ============================================
-	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/4", nil) // task belongs to AccountId 1
-	if err != nil {
+func Test_todo_move(t *testing.T) {
+	tasks := Tasks{
+		{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Move a", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Move b", Status: "Open", Version: 1},
+		{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Move c", Status: "Open", Version: 1},
+	}
+	if err := tasks.Save(); err != nil {
 		t.Error(err)
 		return
 	}
-	response = httptest.NewRecorder()
+	// like tasks from before ranks were introduced, which have never been ranked
+	for i := range tasks {
+		tasks[i].Rank = ""
+	}
+	if err := tasks.SaveRanks(); err != nil {
+		t.Fatal(err)
+	}
 
-	deleteTask(response, request, 2) // Use AccountId 2, which does not have Admin role
-	_checkResponseCode(t, response, 401)
-	_checkResponseBody(t, response, "Unauthorized")
+	move := func(id int, form url.Values, accountId int) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("PUT", "http://localhost:8008/task/"+strconv.Itoa(id)+"/move", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.PostForm = form
+		response := httptest.NewRecorder()
 
-	task, err = GetTaskById(4)
-	if err != nil {
-		t.Error(err)
-	}
-	if task == nil {
-		t.Error("GetTaskById() after deleteTask() did not return a task object, but it still should!")
+		moveTask(response, request, accountId)
+		return response
 	}
 
-	// ============================================ Valid Admin ============================================
-	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/2", nil) // task belongs to AccountId 2
-	if err != nil {
-		t.Error(err)
-		return
+	order := func() []int {
+		list, err := GetTaskList(&tasks[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []int{}
+		for _, task := range *list {
+			for _, moved := range tasks {
+				if task.Id == moved.Id {
+					ids = append(ids, task.Id)
+				}
+			}
+		}
+		return ids
 	}
-	response = httptest.NewRecorder()
 
-	deleteTask(response, request, 1) // Use AccountId 1, which has Admin role
+	// ============================================ Valid ============================================
+	response := move(tasks[2].Id, url.Values{"Before": {strconv.Itoa(tasks[0].Id)}}, 2)
 	_checkResponseCode(t, response, 200)
-	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+	_checkResponseBody(t, response, "{\"Move\": \"Success\"}")
+	if ids := order(); fmt.Sprint(ids) != fmt.Sprint([]int{tasks[2].Id, tasks[0].Id, tasks[1].Id}) {
+		t.Errorf("Order after moveTask() Before is not as expected: [%v]", ids)
+	}
+	// tasks that have never been ranked are left alone, as long as the move does not need them to be ranked
+	for _, task := range tasks[:2] {
+		if current, err := GetTaskById(task.Id); err != nil || current.Version != task.Version || current.Rank != "" {
+			t.Errorf("moveTask() should not rank the other tasks: [%v], [%v]", current, err)
+		}
+	}
 
-	task, err = GetTaskById(2)
-	if task != nil {
-		t.Errorf("GetTaskById() after deleteTask() still returned a task object, when it should not! Got [%v]", task)
+	response = move(tasks[2].Id, url.Values{"After": {strconv.Itoa(tasks[1].Id)}}, 2)
+	_checkResponseCode(t, response, 200)
+	if ids := order(); fmt.Sprint(ids) != fmt.Sprint([]int{tasks[0].Id, tasks[1].Id, tasks[2].Id}) {
+		t.Errorf("Order after moveTask() After is not as expected: [%v]", ids)
+	}
+	// moving after a task that has never been ranked ranks it along with the move, which is part of the history and of the undo step
+	changes, err := GetChanges(AuditFilter{Entity: EntityTask, EntityId: tasks[1].Id})
+	if err != nil || len(*changes) == 0 || (*changes)[len(*changes)-1].Field != "Rank" || (*changes)[len(*changes)-1].OldValue != "" {
+		t.Errorf("Ranking a task along with moveTask() should be recorded in the history: [%v], [%v]", changes, err)
+	}
+	steps, err := GetUndoStepsByAccountId(2)
+	if err != nil || len(*steps) == 0 || len((*steps)[0].Changes) != 3 {
+		t.Errorf("Ranking tasks along with moveTask() should be part of its undo step: [%v], [%v]", steps, err)
+	}
+	for i := range tasks {
+		current, err := GetTaskById(tasks[i].Id)
 		if err != nil {
-			t.Error(err)
+			t.Fatal(err)
 		}
+		tasks[i].Version = current.Version
 	}
 
-	// ============================================ Nonexisting Task ============================================
-	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/77", nil)
+	response = move(tasks[0].Id, url.Values{"After": {strconv.Itoa(tasks[1].Id)}}, 2)
+	_checkResponseCode(t, response, 200)
+	if ids := order(); fmt.Sprint(ids) != fmt.Sprint([]int{tasks[1].Id, tasks[0].Id, tasks[2].Id}) {
+		t.Errorf("Order after moveTask() in between is not as expected: [%v]", ids)
+	}
+
+	// only the moved task changes
+	current, err := GetTaskById(tasks[1].Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if current.Version != tasks[1].Version {
+		t.Errorf("moveTask() should only change the moved task: [%v]", current)
+	}
+
+	// ============================================ Sort ============================================
+	request, err := http.NewRequest("GET", "http://localhost:8008/tasks/?sortBy=Rank", nil)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 	response = httptest.NewRecorder()
 
-	deleteTask(response, request, 1)
-	_checkResponseCode(t, response, 404)
-	_checkResponseBody(t, response, "404 page not found")
+	getTasks(response, request, 2)
+	_checkResponseCode(t, response, 200)
+	body := response.Body.String()
+	if strings.Index(body, "Move b") > strings.Index(body, "Move a") || strings.Index(body, "Move a") > strings.Index(body, "Move c") {
+		t.Errorf("getTasks() sorted by Rank returned [%v]", body)
+	}
 
-	// ============================================ Nonexisting Account ============================================
-	request, err = http.NewRequest("DELETE", "http://localhost:8008/task/1", nil)
+	request, err = http.NewRequest("GET", "http://localhost:8008/tasks/?sortBy=Id", nil)
 	if err != nil {
 		t.Error(err)
 		return
 	}
 	response = httptest.NewRecorder()
 
-	deleteTask(response, request, 77)
+	getTasks(response, request, 2)
+	_checkResponseCode(t, response, 400)
+
+	// ============================================ Invalid ============================================
+	response = move(tasks[0].Id, url.Values{"Before": {strconv.Itoa(tasks[1].Id)}, "After": {strconv.Itoa(tasks[2].Id)}}, 2)
+	_checkResponseCode(t, response, 400)
+
+	response = move(tasks[0].Id, url.Values{"Before": {strconv.Itoa(tasks[0].Id)}}, 2)
+	_checkResponseCode(t, response, 400)
+
+	response = move(tasks[0].Id, url.Values{"Before": {"1"}}, 2) // task 1 belongs to another account
+	_checkResponseCode(t, response, 409)
+	_checkResponseBody(t, response, "Tasks are not in the same list\n")
+
+	response = move(tasks[0].Id, url.Values{"Before": {strconv.Itoa(tasks[1].Id)}}, 3)
 	_checkResponseCode(t, response, 401)
-	_checkResponseBody(t, response, "Unauthorized")
+
+	if err := tasks.Delete(); err != nil {
+		t.Error(err)
+	}
 }
 
 func Test_todo_getAccounts(t *testing.T) {
@@ -840,10 +4194,10 @@ func Test_todo_getAccounts(t *testing.T) {
 	response := httptest.NewRecorder()
 
 	expectedAccounts := Accounts{
-		{1, "JamesClonk", "JamesClonk@developer", "abcd", "123", "Admin", 1234567890},
-		{2, "Clude", "clude@CLUDE", "abcd", "456", "User", 1234567891},
-		{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "User", 1234567892},
-		{4, "Sonny", "sonny@sunny", "abcd", "999", "None", 1234567895},
+		{Id: 1, Name: "JamesClonk", Email: "JamesClonk@developer", Password: "abcd", Salt: "123", Role: "Admin", LastAuth: 1234567890, Version: 1},
+		{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "abcd", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1},
+		{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 1},
+		{Id: 4, Name: "Sonny", Email: "sonny@sunny", Password: "abcd", Salt: "999", Role: "None", LastAuth: 1234567895, Version: 1},
 	}
 
 	getAccounts(response, request, 1) // Use AccountId 1, which has Admin role
@@ -862,6 +4216,74 @@ func Test_todo_getAccounts(t *testing.T) {
 		}
 	}
 
+	// ============================================ Sorted ============================================
+	for sort, expected := range map[string][]int{"-id": {4, 3, 2, 1}, "name": {2, 1, 3, 4}, "-email,id": {4, 3, 1, 2}} {
+		request, err = http.NewRequest("GET", "http://localhost:8008/accounts/?sort="+sort, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		response = httptest.NewRecorder()
+
+		getAccounts(response, request, 1)
+		_checkResponseCode(t, response, 200)
+
+		var accounts Accounts
+		if err := json.Unmarshal([]byte(response.Body.String()), &accounts); err != nil {
+			t.Error(err)
+			return
+		}
+		ids := []int{}
+		for _, a := range accounts {
+			ids = append(ids, a.Id)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(expected) {
+			t.Errorf("getAccounts() sorted by [%v] returned [%v], instead of [%v]", sort, ids, expected)
+		}
+	}
+
+	// ============================================ Paged ============================================
+	request, err = http.NewRequest("GET", "http://localhost:8008/accounts/?limit=3&total=true", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAccounts(response, request, 1)
+	_checkResponseCode(t, response, 200)
+	var page Accounts
+	json.Unmarshal([]byte(response.Body.String()), &page)
+	if len(page) != 3 || page[0].Id != 1 || page[2].Id != 3 || response.Header().Get("X-Total-Count") != "4" {
+		t.Errorf("getAccounts() first page is [%v] of [%v]", page, response.Header().Get("X-Total-Count"))
+	}
+
+	request, err = http.NewRequest("GET", "http://localhost:8008"+_getLink(response, "next"), nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAccounts(response, request, 1)
+	_checkResponseCode(t, response, 200)
+	page = Accounts{}
+	json.Unmarshal([]byte(response.Body.String()), &page)
+	if len(page) != 1 || page[0].Id != 4 || _getLink(response, "next") != "" || _getLink(response, "prev") == "" {
+		t.Errorf("getAccounts() last page is [%v], with links [%v]", page, response.Header().Get("Link"))
+	}
+
+	request, err = http.NewRequest("GET", "http://localhost:8008/accounts/?sort=password", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	getAccounts(response, request, 1)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid sort")
+
 	// ============================================ Unauthorized ============================================
 	request, err = http.NewRequest("GET", "http://localhost:8008/accounts/", nil)
 	if err != nil {
@@ -900,7 +4322,7 @@ func Test_todo_getAccount(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 
 	body := response.Body.String()
-	expected := Account{2, "Clude", "clude@CLUDE", "", "456", "User", 1234567891}
+	expected := Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1}
 	var account Account
 	if err := json.Unmarshal([]byte(body), &account); err != nil {
 		t.Error(err)
@@ -945,7 +4367,7 @@ func Test_todo_getAccount(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 
 	body = response.Body.String()
-	expected = Account{2, "Clude", "clude@CLUDE", "", "456", "User", 1234567891}
+	expected = Account{Id: 2, Name: "Clude", Email: "clude@CLUDE", Password: "", Salt: "456", Role: "User", LastAuth: 1234567891, Version: 1}
 	if err := json.Unmarshal([]byte(body), &account); err != nil {
 		t.Error(err)
 	}
@@ -985,7 +4407,7 @@ func Test_todo_addAccount(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	newAccount := Account{5, "Samurai", "Samurai@Ronin", "abcdef", "123456789", "User", 0}
+	newAccount := Account{Id: 5, Name: "Samurai", Email: "Samurai@Ronin", Password: "abcdef", Salt: "123456789", Role: "User", LastAuth: 0, Version: 1}
 	request.PostForm = url.Values{
 		"Id":       {"23"}, // ignored, does not matter
 		"Name":     {"Samurai"},
@@ -1058,7 +4480,7 @@ func Test_todo_editAccount(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	editedAccount := Account{2, "Cluderzky", "clude@CLUDE", "abcd", "123456", "User", 1234567891}
+	editedAccount := Account{Id: 2, Name: "Cluderzky", Email: "clude@CLUDE", Password: "abcd", Salt: "123456", Role: "User", LastAuth: 1234567891, Version: 2}
 	request.PostForm = url.Values{
 		"Id":       {"2"},
 		"Name":     {"Cluderzky"},
@@ -1090,7 +4512,7 @@ func Test_todo_editAccount(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	editedAccount = Account{3, "ozzie123", "ozzie@abrakadabra123", "abcd", "789", "User", 1234567892}
+	editedAccount = Account{Id: 3, Name: "ozzie123", Email: "ozzie@abrakadabra123", Password: "abcd", Salt: "789", Role: "User", LastAuth: 1234567892, Version: 2}
 	request.PostForm = url.Values{
 		"Id":       {"3"},
 		"Name":     {"ozzie123"},
@@ -1121,7 +4543,7 @@ func Test_todo_editAccount(t *testing.T) {
 		t.Error(err)
 		return
 	}
-	editedAccount = Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "None", 1234567892}
+	editedAccount = Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "None", LastAuth: 1234567892, Version: 3}
 	request.PostForm = url.Values{
 		"Id":       {"3"},
 		"Name":     {"ozzie"},
@@ -1191,7 +4613,7 @@ func Test_todo_editAccount(t *testing.T) {
 	_checkResponseCode(t, response, 401)
 	_checkResponseBody(t, response, "Unauthorized")
 
-	editedAccount = Account{3, "ozzie", "ozzie@abrakadabra", "abcd", "789", "None", 1234567892}
+	editedAccount = Account{Id: 3, Name: "ozzie", Email: "ozzie@abrakadabra", Password: "abcd", Salt: "789", Role: "None", LastAuth: 1234567892, Version: 3}
 	account, err = GetAccountById(3)
 	if err != nil {
 		t.Error(err)
@@ -1222,7 +4644,7 @@ func Test_todo_editAccount(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
 
-	editedAccount = Account{3, "OZZY", "ozzy@nodev", "ABCDEF", "12345", "None", 1234567892}
+	editedAccount = Account{Id: 3, Name: "OZZY", Email: "ozzy@nodev", Password: "ABCDEF", Salt: "12345", Role: "None", LastAuth: 1234567892, Version: 4}
 	account, err = GetAccountById(3)
 	if err != nil {
 		t.Error(err)
@@ -1262,7 +4684,7 @@ func Test_todo_editAccount(t *testing.T) {
 		t.Errorf("GetAccountById() after editAccount() returned [%v], but expected account [%v]", account.Name, "JamesClonk")
 	}
 
-	editedAccount = Account{2, "Cluderzky", "clude@CLUDE", "abcd", "123456", "User", 1234567891}
+	editedAccount = Account{Id: 2, Name: "Cluderzky", Email: "clude@CLUDE", Password: "abcd", Salt: "123456", Role: "User", LastAuth: 1234567891, Version: 2}
 	account, err = GetAccountById(2)
 	if err != nil {
 		t.Error(err)
@@ -1321,7 +4743,7 @@ func Test_todo_editAccount(t *testing.T) {
 	_checkResponseCode(t, response, 200)
 	_checkResponseBody(t, response, "{\"Edit\": \"Success\"}")
 
-	editedAccount = Account{7, "Hadron", "hadron@hadron", "ABCDEFGH", "1234567", "User", 0} // LastAuth cannot be set
+	editedAccount = Account{Id: 7, Name: "Hadron", Email: "hadron@hadron", Password: "ABCDEFGH", Salt: "1234567", Role: "User", LastAuth: 0, Version: 1} // LastAuth cannot be set
 	account, err = GetAccountById(7)
 	if err != nil {
 		t.Error(err)
@@ -1473,6 +4895,193 @@ func Test_todo_deleteAccount(t *testing.T) {
 	_checkResponseBody(t, response, "Unauthorized")
 }
 
+func Test_todo_trashAccounts(t *testing.T) {
+	// accounts 2 and 3 have been moved to the trash by Test_todo_deleteAccount
+	request, err := http.NewRequest("GET", "http://localhost:8008/trash/", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response := httptest.NewRecorder()
+
+	getTrash(response, request, 1)
+	_checkResponseCode(t, response, 200)
+
+	var trash Trash
+	if err := json.Unmarshal([]byte(response.Body.String()), &trash); err != nil {
+		t.Error(err)
+		return
+	}
+	if len(trash.Accounts) != 2 {
+		t.Errorf("getTrash() returned [%v]", trash)
+	}
+
+	// ============================================ Restore Account ============================================
+	request, err = http.NewRequest("POST", "http://localhost:8008/trash/account/2", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	restoreTrash(response, request, 1)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Restore\": \"Success\"}")
+
+	account, err := GetAccountById(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if account.IsDeleted() {
+		t.Errorf("GetAccountById() after restoreTrash() returned [%v]", account)
+	}
+
+	// ============================================ Purge Account ============================================
+	request, err = http.NewRequest("DELETE", "http://localhost:8008/trash/account/3", nil)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	response = httptest.NewRecorder()
+
+	purgeTrash(response, request, 2) // Use AccountId 2, which does not have Admin role
+	_checkResponseCode(t, response, 401)
+	_checkResponseBody(t, response, "Unauthorized")
+
+	response = httptest.NewRecorder()
+
+	purgeTrash(response, request, 1)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Delete\": \"Success\"}")
+
+	if _, err := GetTrashedAccountById(3); err == nil {
+		t.Error("Account [3] should be purged from the trash")
+	}
+}
+
+func Test_todo_patch(t *testing.T) {
+	task := &Task{Id: -1, AccountId: 2, Created: 1234567890, LastUpdated: 1234567890, Priority: 1, Task: "Keep my text", Status: "Open", Version: 1}
+	if err := task.Save(); err != nil {
+		t.Error(err)
+		return
+	}
+	taskId := strconv.Itoa(task.Id)
+
+	patch := func(path string, contentType string, body string, accountId int) *httptest.ResponseRecorder {
+		request, err := http.NewRequest("PATCH", "http://localhost:8008"+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contentType != "" {
+			request.Header.Set("Content-Type", contentType)
+		}
+		response := httptest.NewRecorder()
+
+		if strings.HasPrefix(path, "/task/") {
+			patchTask(response, request, accountId)
+		} else {
+			patchAccount(response, request, accountId)
+		}
+		return response
+	}
+
+	// ============================================ Merge Patch ============================================
+	response := patch("/task/"+taskId, MergePatchType, `{"Priority": 5, "Status": "InProgress"}`, 2)
+	_checkResponseCode(t, response, 200)
+	_checkResponseBody(t, response, "{\"Patch\": \"Success\"}")
+
+	patched, err := GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if patched.Task != "Keep my text" || patched.Priority != 5 || patched.Status != "InProgress" || patched.Created != 1234567890 {
+		t.Errorf("Task after patchTask() is not as expected: [%v]", patched)
+	}
+	if response.Header().Get("ETag") != "\""+strconv.Itoa(patched.Version)+"\"" {
+		t.Errorf("patchTask() returned ETag [%v] for [%v]", response.Header().Get("ETag"), patched)
+	}
+
+	// ============================================ JSON Patch ============================================
+	response = patch("/task/"+taskId, JSONPatchType, `[{"op": "test", "path": "/Priority", "value": 5}, {"op": "replace", "path": "/Task", "value": "New text"}]`, 2)
+	_checkResponseCode(t, response, 200)
+
+	patched, err = GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if patched.Task != "New text" || patched.Priority != 5 {
+		t.Errorf("Task after patchTask() is not as expected: [%v]", patched)
+	}
+
+	response = patch("/task/"+taskId, JSONPatchType, `[{"op": "test", "path": "/Priority", "value": 1}, {"op": "replace", "path": "/Task", "value": "Lost"}]`, 2)
+	_checkResponseCode(t, response, 409)
+	_checkResponseBody(t, response, "Patch test failed")
+
+	// priorities can be given by name
+	response = patch("/task/"+taskId, MergePatchType, `{"Priority": "urgent"}`, 2)
+	_checkResponseCode(t, response, 200)
+
+	// ============================================ Invalid Patches ============================================
+	response = patch("/task/"+taskId, MergePatchType, `{"Priority": 1}`, 3) // AccountId 3 is neither owner nor Admin
+	_checkResponseCode(t, response, 401)
+
+	response = patch("/task/"+taskId, MergePatchType, `{"Id": 1}`, 2)
+	_checkResponseCode(t, response, 409)
+
+	response = patch("/task/"+taskId, MergePatchType, `{"Unknown": 1}`, 2)
+	_checkResponseCode(t, response, 400)
+
+	response = patch("/task/"+taskId, MergePatchType, `{"Priority": "extreme"}`, 2)
+	_checkResponseCode(t, response, 400)
+
+	response = patch("/task/"+taskId, MergePatchType, `{"Priority": 9}`, 2)
+	_checkResponseCode(t, response, 400)
+	_checkResponseBody(t, response, "Invalid priority")
+
+	response = patch("/task/"+taskId, MergePatchType, `{"Status": "Sleeping"}`, 2)
+	_checkResponseCode(t, response, 400)
+
+	response = patch("/task/"+taskId, "text/plain", `Priority=1`, 2)
+	_checkResponseCode(t, response, 415)
+
+	patched, err = GetTaskById(task.Id)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if patched.Task != "New text" || patched.Priority != 5 {
+		t.Errorf("Task should not be changed by invalid patches: [%v]", patched)
+	}
+
+	// ============================================ Accounts ============================================
+	account, err := GetAccountById(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	response = patch("/account/2", "", `{"Name": "Patched"}`, 2)
+	_checkResponseCode(t, response, 200)
+
+	patchedAccount, err := GetAccountById(2)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if patchedAccount.Name != "Patched" || patchedAccount.Password != account.Password || patchedAccount.Email != account.Email {
+		t.Errorf("Account after patchAccount() is not as expected: [%v]", patchedAccount)
+	}
+
+	response = patch("/account/2", "", `{"Role": "Admin"}`, 2) // only Admins can change roles
+	_checkResponseCode(t, response, 401)
+
+	response = patch("/account/1", "", `{"Name": "Hijacked"}`, 2)
+	_checkResponseCode(t, response, 401)
+}
+
 func Test_todo_cleanup(t *testing.T) {
 	_storage_cleanup()
 }
@@ -1490,3 +5099,13 @@ func _checkResponseBody(t *testing.T, response *httptest.ResponseRecorder, expec
 		t.Errorf("Response body was [%v], but expected it to contain [%v]", body, expected)
 	}
 }
+
+// _getLink returns the URL of the link with the given relation from the Link header, or "" if there is none.
+func _getLink(response *httptest.ResponseRecorder, rel string) string {
+	for _, link := range strings.Split(response.Header().Get("Link"), ", ") {
+		if strings.HasSuffix(link, "; rel=\""+rel+"\"") {
+			return strings.TrimSuffix(strings.TrimPrefix(link, "<"), ">; rel=\""+rel+"\"")
+		}
+	}
+	return ""
+}
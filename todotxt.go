@@ -0,0 +1,361 @@
+package main
+
+import "io"
+import "sort"
+import "time"
+import "bufio"
+import "errors"
+import "regexp"
+import "strconv"
+import "strings"
+
+const todoTxtDateLayout = "2006-01-02"
+
+var todoTxtPriority = regexp.MustCompile("^\\(([A-Z])\\)$")
+var todoTxtDate = regexp.MustCompile("^[0-9]{4}-[0-9]{2}-[0-9]{2}$")
+
+// TodoTxtItem is a single line of a todo.txt file, see https://github.com/todotxt/todo.txt for the format.
+// Text is the description, with all its +projects, @contexts and key:value tags. Lines are counted from 1.
+type TodoTxtItem struct {
+	Line      int
+	Done      bool
+	Priority  string // a letter from A to Z, or empty
+	Completed string // the completion date, only for done items
+	Created   string
+	Text      string
+}
+
+// TodoTxtTask is a task together with the names of its project and tags, which todo.txt uses instead of ids.
+type TodoTxtTask struct {
+	Task    Task
+	Project string
+	Tags    []string
+}
+
+// TodoTxtConflict is a task that was changed on both sides since the last sync, or deleted on one side and changed on the other.
+// Neither side is changed for a conflict, it is resolved once both sides are the same again.
+type TodoTxtConflict struct {
+	Id      int
+	Line    int    // the line in the file, 0 if it was deleted there
+	File    string // the line as it is in the file
+	Account string // the task as a line, as it is in the account
+	Reason  string
+}
+
+// TodoTxtSyncLine is a line of the file after a sync. Its item was either read from the file, or written from the account.
+// Action is ActionCreate or ActionUpdate if the task in the account has to be changed to the item.
+type TodoTxtSyncLine struct {
+	Id          int
+	Item        TodoTxtItem
+	Action      string
+	FromAccount bool
+	Conflict    bool
+}
+
+// TodoTxtSync is what a sync has to do on both sides.
+type TodoTxtSync struct {
+	Lines     []TodoTxtSyncLine
+	Deletes   []int // tasks whose lines were deleted from the file
+	Removed   int   // lines removed from the file, since their tasks were deleted in the account
+	Conflicts []TodoTxtConflict
+}
+
+// TodoTxtSyncReport sums up a sync, with the lines that could not be turned into tasks as failed import results.
+type TodoTxtSyncReport struct {
+	Created   int
+	Updated   int
+	Deleted   int
+	Written   int
+	Removed   int
+	Failed    []ImportResult
+	Conflicts []TodoTxtConflict
+}
+
+// ParseTodoTxt reads all items of a todo.txt file, empty lines are skipped.
+func ParseTodoTxt(r io.Reader) ([]TodoTxtItem, error) {
+	items := []TodoTxtItem{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		if len(items) >= maxImportRows {
+			return nil, errors.New("Too many rows")
+		}
+		item := ParseTodoTxtLine(scanner.Text())
+		item.Line = n
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// ParseTodoTxtLine splits a line into its parts. A done item starts with "x", followed by its completion date,
+// an open item can start with its priority. The creation date comes right before the text.
+func ParseTodoTxtLine(line string) TodoTxtItem {
+	item := TodoTxtItem{}
+	words := strings.Fields(line)
+	if len(words) > 0 && words[0] == "x" {
+		item.Done = true
+		words = words[1:]
+	}
+	if len(words) > 0 && todoTxtPriority.MatchString(words[0]) {
+		item.Priority = words[0][1:2]
+		words = words[1:]
+	}
+	if item.Done && len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+		item.Completed = words[0]
+		words = words[1:]
+	}
+	if len(words) > 0 && todoTxtDate.MatchString(words[0]) {
+		item.Created = words[0]
+		words = words[1:]
+	}
+	item.Text = strings.Join(words, " ")
+	return item
+}
+
+// String returns the item as a line. The creation date of a done item can only be given along with its completion date.
+func (item TodoTxtItem) String() string {
+	words := []string{}
+	if item.Done {
+		words = append(words, "x")
+	}
+	if item.Priority != "" {
+		words = append(words, "("+item.Priority+")")
+	}
+	if item.Done && item.Completed != "" {
+		words = append(words, item.Completed)
+	}
+	if item.Created != "" && (!item.Done || item.Completed != "") {
+		words = append(words, item.Created)
+	}
+	if item.Text != "" {
+		words = append(words, item.Text)
+	}
+	return strings.Join(words, " ")
+}
+
+// Id returns the task id of the item's id: tag, 0 if it has none.
+func (item TodoTxtItem) Id() int {
+	id, err := strconv.Atoi(todoTxtValue(item.Text, "id"))
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// todoTxtValue returns the value of the first key:value tag with the key.
+func todoTxtValue(text string, key string) string {
+	for _, word := range strings.Fields(text) {
+		if strings.HasPrefix(word, key+":") {
+			return word[len(key)+1:]
+		}
+	}
+	return ""
+}
+
+// todoTxtWithout removes all key:value tags with the key.
+func todoTxtWithout(text string, key string) string {
+	words := []string{}
+	for _, word := range strings.Fields(text) {
+		if !strings.HasPrefix(word, key+":") {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// TodoTxt returns the priority letter, A being the highest priority of the scale and every next letter one priority lower.
+// The lowest priority has no letter, so that items without priority keep it.
+func (p Priority) TodoTxt() string {
+	i := priorityScale.Max - int(p)
+	if int(p) <= priorityScale.Min {
+		return ""
+	}
+	if i < 0 {
+		i = 0
+	} else if i > 25 {
+		i = 25
+	}
+	return string(rune('A' + i))
+}
+
+// ParseTodoTxtPriority returns the priority of a letter, see Priority.TodoTxt.
+// Letters below the scale get the lowest priority that has a letter.
+func ParseTodoTxtPriority(letter string) Priority {
+	if letter == "" {
+		return Priority(priorityScale.Min)
+	}
+	p := priorityScale.Max - int(strings.ToUpper(letter)[0]-'A')
+	if p <= priorityScale.Min {
+		p = priorityScale.Min + 1
+	}
+	return Priority(p).Normalize()
+}
+
+// todoTxtName replaces the white space in names of projects and tags, since the words of a line are separated by it.
+func todoTxtName(name string) string {
+	return strings.Join(strings.Fields(name), "_")
+}
+
+// todoTxtNameMatches checks if the name of a project or tag is the one in the line, which may have had its white space replaced.
+func todoTxtNameMatches(name string, word string) bool {
+	return name == word || todoTxtName(name) == word
+}
+
+// Validate turns the item into a task of the account. The first +project becomes the project of the task, all @contexts its tags.
+// The id: tag gives the id of the task, or -1 for a new task. Done items can keep their priority as pri: tag.
+// Without a creation date the current time is used, like for the completion date of done items.
+func (item TodoTxtItem) Validate(accountId int) (TodoTxtTask, error) {
+	now := int(time.Now().Unix())
+	t := TodoTxtTask{Task{-1, accountId, now, now, 0, "", 0, 0, "Open", 0, 0, ""}, "", []string{}}
+
+	priority := item.Priority
+	words := []string{}
+	seen := map[string]bool{}
+	for _, word := range strings.Fields(item.Text) {
+		switch {
+		case strings.HasPrefix(word, "id:"):
+			if id := item.Id(); id > 0 {
+				t.Task.Id = id
+			}
+		case strings.HasPrefix(word, "pri:") && todoTxtPriority.MatchString("("+word[4:]+")"):
+			if priority == "" {
+				priority = word[4:]
+			}
+		case len(word) > 1 && word[0] == '+' && t.Project == "":
+			t.Project = word[1:]
+		case len(word) > 1 && word[0] == '@':
+			name, err := ParseTagName(word[1:])
+			if err != nil {
+				return TodoTxtTask{}, err
+			}
+			if !seen[strings.ToLower(name)] {
+				seen[strings.ToLower(name)] = true
+				t.Tags = append(t.Tags, name)
+			}
+		default:
+			words = append(words, word)
+		}
+	}
+	t.Task.Task = strings.Join(words, " ")
+	if t.Task.Task == "" {
+		return TodoTxtTask{}, errors.New("Invalid task")
+	}
+	t.Task.Priority = ParseTodoTxtPriority(priority)
+
+	if item.Created != "" {
+		created, err := time.Parse(todoTxtDateLayout, item.Created)
+		if err != nil {
+			return TodoTxtTask{}, errors.New("Invalid created date")
+		}
+		t.Task.Created = int(created.Unix())
+	}
+	if item.Done {
+		t.Task.Status = "Done"
+		if item.Completed != "" {
+			completed, err := time.Parse(todoTxtDateLayout, item.Completed)
+			if err != nil {
+				return TodoTxtTask{}, errors.New("Invalid completion date")
+			}
+			t.Task.LastUpdated = int(completed.Unix())
+		}
+	}
+	return t, nil
+}
+
+// Item turns the task into a line, with its id as id: tag so that an import or sync finds the task again.
+// Tasks in progress are open items, since todo.txt knows no such state.
+func (t *TodoTxtTask) Item() TodoTxtItem {
+	item := TodoTxtItem{Created: todoTxtDay(t.Task.Created)}
+	words := []string{strings.Join(strings.Fields(t.Task.Task), " ")}
+	if t.Project != "" {
+		words = append(words, "+"+todoTxtName(t.Project))
+	}
+	for _, tag := range t.Tags {
+		words = append(words, "@"+todoTxtName(tag))
+	}
+
+	priority := t.Task.Priority.TodoTxt()
+	if t.Task.Status == "Done" {
+		item.Done = true
+		item.Completed = todoTxtDay(t.Task.LastUpdated)
+		if priority != "" {
+			words = append(words, "pri:"+priority)
+		}
+	} else {
+		item.Priority = priority
+	}
+	if t.Task.Id > 0 {
+		words = append(words, "id:"+strconv.Itoa(t.Task.Id))
+	}
+	item.Text = strings.Join(words, " ")
+	return item
+}
+
+func todoTxtDay(timestamp int) string {
+	return time.Unix(int64(timestamp), 0).UTC().Format(todoTxtDateLayout)
+}
+
+// PlanTodoTxtSync compares the items of the file and the tasks of the account, as lines, with the lines of the last sync.
+// A side that still has the line of the last sync takes over the other side, creations and deletions included.
+// Without a line of the last sync, as for tasks exported before, the file wins.
+// If both sides changed a task to something different, or one side changed it and the other deleted it, that is a conflict.
+// The file keeps its order, tasks new in the account are added at its end.
+func PlanTodoTxtSync(items []TodoTxtItem, tasks map[int]TodoTxtItem, state map[int]string) *TodoTxtSync {
+	sync := &TodoTxtSync{Lines: []TodoTxtSyncLine{}, Deletes: []int{}, Conflicts: []TodoTxtConflict{}}
+	seen := map[int]bool{}
+	for _, item := range items {
+		id := item.Id()
+		task, inAccount := tasks[id]
+		base, inState := state[id]
+		if id == 0 || seen[id] || (!inAccount && !inState) {
+			// the task gets a new id, whatever the line claims
+			item.Text = todoTxtWithout(item.Text, "id")
+			sync.Lines = append(sync.Lines, TodoTxtSyncLine{0, item, ActionCreate, false, false})
+			continue
+		}
+		seen[id] = true
+
+		line := item.String()
+		switch {
+		case !inAccount && line == base:
+			sync.Removed++
+		case !inAccount:
+			sync.Lines = append(sync.Lines, TodoTxtSyncLine{id, item, "", false, true})
+			sync.Conflicts = append(sync.Conflicts, TodoTxtConflict{id, item.Line, line, "", "Changed in the file, but deleted in the account"})
+		case line == base || line == task.String():
+			sync.Lines = append(sync.Lines, TodoTxtSyncLine{id, task, "", line != task.String(), false})
+		case !inState || task.String() == base:
+			sync.Lines = append(sync.Lines, TodoTxtSyncLine{id, item, ActionUpdate, false, false})
+		default:
+			sync.Lines = append(sync.Lines, TodoTxtSyncLine{id, item, "", false, true})
+			sync.Conflicts = append(sync.Conflicts, TodoTxtConflict{id, item.Line, line, task.String(), "Changed in the file and in the account"})
+		}
+	}
+
+	ids := []int{}
+	for id := range tasks {
+		if !seen[id] {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		task := tasks[id]
+		base, inState := state[id]
+		switch {
+		case inState && task.String() == base:
+			sync.Deletes = append(sync.Deletes, id)
+		case inState:
+			sync.Lines = append(sync.Lines, TodoTxtSyncLine{id, task, "", true, true})
+			sync.Conflicts = append(sync.Conflicts, TodoTxtConflict{id, 0, "", task.String(), "Deleted in the file, but changed in the account"})
+		default:
+			sync.Lines = append(sync.Lines, TodoTxtSyncLine{id, task, "", true, false})
+		}
+	}
+	return sync
+}
@@ -0,0 +1,164 @@
+package main
+
+import "fmt"
+import "strings"
+import "testing"
+
+func Test_todotxt_ParseTodoTxtLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected TodoTxtItem
+	}{
+		{"(A) Call Mom +Family @phone", TodoTxtItem{Priority: "A", Text: "Call Mom +Family @phone"}},
+		{"(B) 2011-03-02 Call Mom", TodoTxtItem{Priority: "B", Created: "2011-03-02", Text: "Call Mom"}},
+		{"x 2011-03-03 2011-03-01 Review Tim's pull request", TodoTxtItem{Done: true, Completed: "2011-03-03", Created: "2011-03-01", Text: "Review Tim's pull request"}},
+		{"x 2011-03-03 Done  without   creation", TodoTxtItem{Done: true, Completed: "2011-03-03", Text: "Done without creation"}},
+		{"Really gotta call Mom (A) @phone", TodoTxtItem{Text: "Really gotta call Mom (A) @phone"}},
+		{"xylophone lesson", TodoTxtItem{Text: "xylophone lesson"}},
+		{"(b) lower case is no priority", TodoTxtItem{Text: "(b) lower case is no priority"}},
+	}
+	for _, test := range tests {
+		item := ParseTodoTxtLine(test.line)
+		if item != test.expected {
+			t.Errorf("ParseTodoTxtLine of [%v] returned [%v], instead of [%v]", test.line, item, test.expected)
+		}
+		if line := item.String(); line != strings.Join(strings.Fields(test.line), " ") {
+			t.Errorf("String returned [%v], instead of [%v]", line, test.line)
+		}
+	}
+
+	items, err := ParseTodoTxt(strings.NewReader("(A) first\n\n  \nx second id:7\n"))
+	if err != nil || len(items) != 2 || items[0].Line != 1 || items[1].Line != 4 || !items[1].Done || items[1].Id() != 7 || items[0].Id() != 0 {
+		t.Errorf("ParseTodoTxt returned [%v], [%v]", items, err)
+	}
+}
+
+func Test_todotxt_Priority(t *testing.T) {
+	expected := map[Priority]string{5: "A", 4: "B", 3: "C", 2: "D", 1: ""}
+	for p, letter := range expected {
+		if p.TodoTxt() != letter {
+			t.Errorf("Priority [%v] has letter [%v], instead of [%v]", p, p.TodoTxt(), letter)
+		}
+		if ParseTodoTxtPriority(letter) != p {
+			t.Errorf("Letter [%v] has priority [%v], instead of [%v]", letter, ParseTodoTxtPriority(letter), p)
+		}
+	}
+	// letters below the scale still have a higher priority than no letter
+	if ParseTodoTxtPriority("Z") != 2 {
+		t.Errorf("Letter Z has priority [%v]", ParseTodoTxtPriority("Z"))
+	}
+}
+
+func Test_todotxt_Validate(t *testing.T) {
+	item := ParseTodoTxtLine("x 2024-01-05 2024-01-01 Call mum +Family +Other @phone @Phone due:2024-02-01 pri:B id:12")
+	task, err := item.Validate(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Task{Id: 12, AccountId: 3, Created: 1704067200, LastUpdated: 1704412800, Priority: 4, Task: "Call mum +Other due:2024-02-01", Status: "Done"}
+	if task.Task != expected || task.Project != "Family" || fmt.Sprint(task.Tags) != "[phone]" {
+		t.Errorf("Validate returned [%v], instead of [%v]", task, expected)
+	}
+
+	item = ParseTodoTxtLine("Water plants")
+	task, err = item.Validate(3)
+	if err != nil || task.Task.Id != -1 || task.Task.Status != "Open" || task.Task.Priority != 1 || task.Task.Created == 0 {
+		t.Errorf("Validate returned [%v], [%v]", task, err)
+	}
+
+	for _, line := range []string{"x 2024-01-05", "+Family @phone id:3", "2024-13-45 Broken date", "Tag with @a,b"} {
+		if _, err := ParseTodoTxtLine(line).Validate(3); err == nil {
+			t.Errorf("Validate of [%v] should fail", line)
+		}
+	}
+}
+
+func Test_todotxt_Item(t *testing.T) {
+	task := TodoTxtTask{Task{Id: 12, AccountId: 3, Created: 1704067200, LastUpdated: 1704412800, Priority: 4, Task: "Call mum", Status: "Done"}, "Family Stuff", []string{"phone"}}
+	if line := task.Item().String(); line != "x 2024-01-05 2024-01-01 Call mum +Family_Stuff @phone pri:B id:12" {
+		t.Errorf("Item returned [%v]", line)
+	}
+	task.Task.Status = "InProgress"
+	task.Task.Task = "Call\nmum"
+	if line := task.Item().String(); line != "(B) 2024-01-01 Call mum +Family_Stuff @phone id:12" {
+		t.Errorf("Item returned [%v]", line)
+	}
+
+	// reading the line again gives the same task
+	item := ParseTodoTxtLine(task.Item().String())
+	back, err := item.Validate(3)
+	if err != nil || back.Task.Id != 12 || back.Task.Priority != 4 || back.Task.Created != 1704067200 || back.Project != "Family_Stuff" || !todoTxtNameMatches(task.Project, back.Project) {
+		t.Errorf("Validate of [%v] returned [%v], [%v]", item, back, err)
+	}
+}
+
+func Test_todotxt_PlanTodoTxtSync(t *testing.T) {
+	lines := func(ls ...string) []TodoTxtItem {
+		items := []TodoTxtItem{}
+		for i, l := range ls {
+			item := ParseTodoTxtLine(l)
+			item.Line = i + 1
+			items = append(items, item)
+		}
+		return items
+	}
+	tasks := map[int]TodoTxtItem{
+		1: ParseTodoTxtLine("unchanged id:1"),
+		2: ParseTodoTxtLine("changed in the account id:2"),
+		3: ParseTodoTxtLine("task three id:3"),
+		4: ParseTodoTxtLine("changed on both sides id:4"),
+		5: ParseTodoTxtLine("deleted in the file id:5"),
+		6: ParseTodoTxtLine("changed, but deleted in the file id:6"),
+		7: ParseTodoTxtLine("new in the account id:7"),
+		9: ParseTodoTxtLine("same change id:9"),
+	}
+	state := map[int]string{
+		1:  "unchanged id:1",
+		2:  "task two id:2",
+		3:  "task three id:3",
+		4:  "task four id:4",
+		5:  "deleted in the file id:5",
+		6:  "task six id:6",
+		8:  "task eight id:8",
+		10: "task ten id:10",
+		9:  "task nine id:9",
+	}
+	items := lines(
+		"unchanged id:1",
+		"task two id:2",
+		"changed in the file id:3",
+		"both sides differently id:4",
+		"task eight id:8",
+		"changed, but deleted in the account id:10",
+		"new in the file",
+		"copied id:3",
+		"same change id:9",
+	)
+
+	sync := PlanTodoTxtSync(items, tasks, state)
+	result := []string{}
+	for _, line := range sync.Lines {
+		result = append(result, fmt.Sprintf("%v|%v|%v|%v|%v", line.Id, line.Item.String(), line.Action, line.FromAccount, line.Conflict))
+	}
+	expected := []string{
+		"1|unchanged id:1||false|false",
+		"2|changed in the account id:2||true|false",
+		"3|changed in the file id:3|Update|false|false",
+		"4|both sides differently id:4||false|true",
+		"10|changed, but deleted in the account id:10||false|true",
+		"0|new in the file|Create|false|false",
+		"0|copied|Create|false|false",
+		"9|same change id:9||false|false",
+		"6|changed, but deleted in the file id:6||true|true",
+		"7|new in the account id:7||true|false",
+	}
+	if strings.Join(result, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("PlanTodoTxtSync planned\n%v\ninstead of\n%v", strings.Join(result, "\n"), strings.Join(expected, "\n"))
+	}
+	if fmt.Sprint(sync.Deletes) != "[5]" || sync.Removed != 1 || len(sync.Conflicts) != 3 {
+		t.Errorf("PlanTodoTxtSync planned [%v] deletes, [%v] removed lines and [%v] conflicts", sync.Deletes, sync.Removed, sync.Conflicts)
+	}
+	if c := sync.Conflicts[0]; c.Id != 4 || c.Line != 4 || c.File != "both sides differently id:4" || c.Account != "changed on both sides id:4" {
+		t.Errorf("PlanTodoTxtSync reported [%v]", c)
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import "log"
+import "time"
+
+// Trash holds everything that has been deleted, but not purged yet.
+type Trash struct {
+	Tasks    Tasks
+	Accounts Accounts
+}
+
+// purgeTrashPeriodically permanently deletes everything that has been in the trash
+// for longer than the retention period, checking once every interval. It never returns.
+func purgeTrashPeriodically(retention time.Duration, interval time.Duration) {
+	for {
+		before := int(time.Now().Add(-retention).Unix())
+		if purged, err := PurgeTrash(before); err != nil {
+			log.Println(err)
+		} else if isLogging && purged > 0 {
+			log.Printf("Purged [%v] items from trash", purged)
+		}
+		time.Sleep(interval)
+	}
+}
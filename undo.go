@@ -0,0 +1,39 @@
+package main
+
+import "errors"
+
+// how many undo steps are kept per account, older ones are dropped
+var undoDepth = 20
+
+var ErrUndoConflict = errors.New("Conflicting change")
+
+// TaskChange is the state of a task before and after a mutation.
+// Before is nil for a task that has been created by the mutation.
+type TaskChange struct {
+	Before *Task
+	After  *Task
+}
+
+// UndoStep holds all changes of a single mutation of an account, a bulk operation results in
+// a single step with many changes. Undoing a step writes back the state of all tasks before the mutation.
+type UndoStep struct {
+	Id        int          `db:"ID"`
+	AccountId int          `db:"ACCOUNT_ID"`
+	Created   int          `db:"CREATED"`
+	Changes   []TaskChange `db:"CHANGES"`
+}
+
+type UndoSteps []UndoStep
+
+func SetUndoDepth(depth int) {
+	undoDepth = depth
+}
+
+// conflicts checks if the current state of a task still matches the state right after the change.
+// The current state is nil if the task does not exist anymore.
+func (c *TaskChange) conflicts(current *Task) bool {
+	if current == nil || c.After == nil {
+		return current != c.After
+	}
+	return *current != *c.After
+}
@@ -0,0 +1,38 @@
+package main
+
+import "errors"
+import "strings"
+
+// View is a saved search of an account: a named filter expression together with sort keys.
+// Shared views are published by an "Admin" and visible to all accounts.
+type View struct {
+	Id        int    `db:"ID"`
+	AccountId int    `db:"ACCOUNT_ID"`
+	Name      string `db:"NAME"`
+	Filter    string `db:"FILTER"`
+	Sort      string `db:"SORT"`
+	Shared    int    `db:"SHARED"`
+}
+
+type Views []View
+
+func (v *View) IsShared() bool {
+	return v.Shared > 0
+}
+
+// Validate checks that the view has a name, and that its filter expression and sort keys can be parsed.
+func (v *View) Validate() error {
+	v.Name = strings.TrimSpace(v.Name)
+	if v.Name == "" {
+		return errors.New("Invalid view name")
+	}
+	if strings.TrimSpace(v.Filter) != "" {
+		if _, err := ParseFilter(v.Filter); err != nil {
+			return err
+		}
+	}
+	if _, err := ParseSortKeys(v.Sort, taskSortFields); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func Test_view_Validate(t *testing.T) {
+	var tests = []struct {
+		view  View
+		valid bool
+	}{
+		{View{-1, 1, "Urgent this week", "priority>=urgent AND created>2024-01-01", "-priority,created", 0}, true},
+		{View{-1, 1, "Everything", "", "", 0}, true},
+		{View{-1, 1, "  ", "priority>=3", "", 0}, false},
+		{View{-1, 1, "Broken", "priority>=", "", 0}, false},
+		{View{-1, 1, "Unsorted", "", "-secret", 0}, false},
+	}
+	for _, test := range tests {
+		if err := test.view.Validate(); (err == nil) != test.valid {
+			t.Errorf("Validate of [%v] returned [%v]", test.view, err)
+		}
+	}
+}